@@ -1,15 +1,36 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/ARCoder181105/ecom/db"
+	"github.com/ARCoder181105/ecom/services/cart"
+	"github.com/ARCoder181105/ecom/services/catalog"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/ARCoder181105/ecom/services/fulfillment"
+	"github.com/ARCoder181105/ecom/services/inventory"
+	"github.com/ARCoder181105/ecom/services/jobs"
+	"github.com/ARCoder181105/ecom/services/ledger"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	"github.com/ARCoder181105/ecom/services/orders"
+	"github.com/ARCoder181105/ecom/services/orderstream"
+	"github.com/ARCoder181105/ecom/services/payments"
 	"github.com/ARCoder181105/ecom/services/products"
+	"github.com/ARCoder181105/ecom/services/promotions"
+	"github.com/ARCoder181105/ecom/services/returns"
+	"github.com/ARCoder181105/ecom/services/sellers"
+	"github.com/ARCoder181105/ecom/services/shipping"
+	"github.com/ARCoder181105/ecom/services/tax"
 	"github.com/ARCoder181105/ecom/services/user"
+	"github.com/ARCoder181105/ecom/services/webhooks"
+	"github.com/ARCoder181105/ecom/services/wishlists"
+	"github.com/ARCoder181105/ecom/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -33,6 +54,16 @@ func (s *APIServer) Run() error {
 	s.db = conn
 	defer s.db.Close()
 
+	mailer, err := notifications.NewMailer()
+	if err != nil {
+		return fmt.Errorf("❌ failed to set up mail: %v", err)
+	}
+
+	provider, err := payments.DefaultProvider()
+	if err != nil {
+		return fmt.Errorf("❌ %v", err)
+	}
+
 	log.Println("✅ Database connection established")
 
 	r := chi.NewRouter()
@@ -41,8 +72,8 @@ func (s *APIServer) Run() error {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{os.Getenv("FRONTEND_URL"), "http://localhost:3000", "http://localhost:5173"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", utils.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -52,11 +83,56 @@ func (s *APIServer) Run() error {
 	r.Use(middleware.Recoverer)
 
 	r.Route("/api/v1", func(api chi.Router) {
+		api.Use(utils.IdempotencyMiddleware(s.db))
+
 		api.Mount("/user", user.Routes(s.db))
 		api.Mount("/product", products.Routes(s.db))
+		api.Mount("/orders", orders.Routes(s.db))
+		api.Mount("/inventory", inventory.Routes(s.db))
+		api.Mount("/catalog", catalog.Routes(s.db))
+		api.Mount("/sellers", sellers.Routes(s.db))
+		api.Mount("/cart", cart.Routes(s.db))
+		api.Mount("/promotions", promotions.Routes(s.db))
+		api.Mount("/tax", tax.Routes(s.db))
+		api.Mount("/shipping", shipping.Routes(s.db))
+		api.Mount("/payments", payments.Routes(s.db))
+		api.Mount("/returns", returns.Routes(s.db))
+		api.Mount("/seller", fulfillment.Routes(s.db))
+		api.Mount("/ledger", ledger.Routes(s.db))
+		api.Mount("/webhooks", webhooks.Routes(s.db))
+		api.Mount("/jobs", jobs.Routes(s.db))
+		api.Mount("/wishlists", wishlists.Routes(s.db))
 	})
 
+	// Domain event subscribers. Names are stored with the events they handle, don't rename them.
+	bus := events.NewBus()
+	bus.Subscribe("webhooks", webhooks.HandleEvent, webhooks.SubscribedEvents...)
+	bus.Subscribe("notifications", notifications.HandleEvent, notifications.SubscribedEvents...)
+	bus.Subscribe("orderstream", orderstream.HandleEvent, orderstream.SubscribedEvents...)
+	bus.Subscribe("wishlists", wishlists.HandleEvent, wishlists.SubscribedEvents...)
+
+	// Job handlers. Kinds are stored with the jobs, don't rename them.
+	pool := jobs.NewPool(s.db, jobWorkers())
+	catalog.RegisterJobs(pool)
+	notifications.RegisterJobs(pool, mailer)
+	user.RegisterJobs(pool, mailer)
+	payments.RegisterJobs(pool, provider)
+
+	// Background workers
+	go events.NewDispatcher(s.db, bus).Run(context.Background())
+	go pool.Run(context.Background())
+	go webhooks.NewDispatcher(s.db).Run(context.Background())
+	go orderstream.Listen(context.Background(), os.Getenv("DATABASE_URL"))
+
 	// Start server
 	log.Printf("🚀 Server running on %s\n", s.addr)
 	return http.ListenAndServe(s.addr, r)
 }
+
+// jobWorkers is the size of the job worker pool, JOB_WORKERS or 4.
+func jobWorkers() int {
+	if n, err := strconv.Atoi(os.Getenv("JOB_WORKERS")); err == nil && n > 0 {
+		return n
+	}
+	return 4
+}
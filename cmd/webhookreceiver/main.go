@@ -0,0 +1,81 @@
+// Command webhookreceiver is a local endpoint for trying out webhook subscriptions. It
+// prints every delivery, checks its signature when given the subscription's secret, and
+// can answer with an error status to exercise retries.
+//
+//	go run ./cmd/webhookreceiver -addr :9000 -secret whsec_... -status 200
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ARCoder181105/ecom/services/webhooks"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	secret := flag.String("secret", "", "subscription secret; signatures are checked when set")
+	status := flag.Int("status", http.StatusOK, "status code to answer with")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "unreadable body", http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("📨 %s delivery %s", r.Header.Get("X-Webhook-Event"), r.Header.Get("X-Webhook-Delivery"))
+
+		if *secret != "" {
+			if err := verify(*secret, r.Header.Get("X-Webhook-Signature"), body); err != "" {
+				log.Printf("❌ %s", err)
+				http.Error(w, err, http.StatusUnauthorized)
+				return
+			}
+			log.Println("✅ signature verified")
+		}
+
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") == nil {
+			log.Println(pretty.String())
+		} else {
+			log.Println(string(body))
+		}
+
+		w.WriteHeader(*status)
+	})
+
+	log.Printf("🚀 Webhook receiver listening on %s, answering %d\n", *addr, *status)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// verify checks an X-Webhook-Signature header and returns what is wrong with it, if
+// anything.
+func verify(secret, header string, body []byte) string {
+	var timestamp string
+	for _, part := range strings.Split(header, ",") {
+		if v, ok := strings.CutPrefix(part, "t="); ok {
+			timestamp = v
+		}
+	}
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "missing signature timestamp"
+	}
+	if age := time.Since(time.Unix(t, 0)); age > 5*time.Minute || age < -5*time.Minute {
+		return "signature timestamp too old"
+	}
+	if !hmac.Equal([]byte(webhooks.Sign(secret, t, body)), []byte(header)) {
+		return "signature mismatch"
+	}
+	return ""
+}
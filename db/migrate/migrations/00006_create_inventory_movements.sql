@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE inventory_movement_type AS ENUM ('sale', 'restock', 'return', 'adjustment');
+
+CREATE TABLE IF NOT EXISTS inventory_movements (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  movement_type inventory_movement_type NOT NULL,
+  quantity INT NOT NULL CHECK (quantity <> 0), -- Signed delta: negative for stock leaving
+  balance_after INT NOT NULL CHECK (balance_after >= 0), -- products.stock_quantity after this movement
+  actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_inventory_movements_product ON inventory_movements(product_id, created_at DESC);
+
+-- Opening balance so the ledger reconciles with the stock already on hand
+INSERT INTO inventory_movements (product_id, movement_type, quantity, balance_after, actor_id, reason)
+SELECT id, 'adjustment', stock_quantity, stock_quantity, user_id, 'opening balance'
+FROM products
+WHERE stock_quantity > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE inventory_movements;
+DROP TYPE inventory_movement_type;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 0 disables low-stock alerts, sellers are still told when a product sells out
+ALTER TABLE products ADD COLUMN low_stock_threshold INT NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0);
+
+CREATE TABLE IF NOT EXISTS notifications (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type VARCHAR(50) NOT NULL, -- low_stock, out_of_stock, back_in_stock
+  title VARCHAR(255) NOT NULL,
+  body TEXT NOT NULL,
+  read_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS stock_subscriptions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  notified_at TIMESTAMP, -- NULL while the customer is still waiting
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (product_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE stock_subscriptions;
+DROP TABLE notifications;
+ALTER TABLE products DROP COLUMN low_stock_threshold;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN sku VARCHAR(100);
+
+-- SKUs only need to be unique within a seller's own catalog
+CREATE UNIQUE INDEX idx_products_user_sku ON products(user_id, sku) WHERE sku IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  format VARCHAR(10) NOT NULL, -- csv, json
+  status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed
+  total_rows INT NOT NULL DEFAULT 0,
+  processed_rows INT NOT NULL DEFAULT 0,
+  created_count INT NOT NULL DEFAULT 0,
+  updated_count INT NOT NULL DEFAULT 0,
+  error_count INT NOT NULL DEFAULT 0,
+  errors JSONB NOT NULL DEFAULT '[]', -- Row-level errors: [{"row": 3, "sku": "...", "error": "..."}]
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE import_jobs;
+DROP INDEX idx_products_user_sku;
+ALTER TABLE products DROP COLUMN sku;
+-- +goose StatementEnd
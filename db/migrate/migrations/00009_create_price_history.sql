@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+  ADD COLUMN compare_at_price DECIMAL(10, 2) CHECK (compare_at_price >= 0), -- "Was" price shown struck through
+  ADD COLUMN sale_price DECIMAL(10, 2) CHECK (sale_price >= 0),
+  ADD COLUMN sale_starts_at TIMESTAMP, -- NULL starts the sale immediately
+  ADD COLUMN sale_ends_at TIMESTAMP; -- NULL keeps the sale running until cleared
+
+CREATE TABLE IF NOT EXISTS price_history (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  price DECIMAL(10, 2) NOT NULL,
+  compare_at_price DECIMAL(10, 2),
+  sale_price DECIMAL(10, 2),
+  sale_starts_at TIMESTAMP,
+  sale_ends_at TIMESTAMP,
+  changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_price_history_product ON price_history(product_id, created_at);
+
+-- Starting point for products created before price history existed
+INSERT INTO price_history (product_id, price, changed_by, created_at)
+SELECT id, price, user_id, created_at FROM products;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE price_history;
+ALTER TABLE products
+  DROP COLUMN compare_at_price,
+  DROP COLUMN sale_price,
+  DROP COLUMN sale_starts_at,
+  DROP COLUMN sale_ends_at;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN slug VARCHAR(255);
+
+-- Backfill existing products, the id suffix keeps duplicate names unique
+UPDATE products
+SET slug = trim(both '-' from lower(regexp_replace(name, '[^a-zA-Z0-9]+', '-', 'g'))) || '-' || left(id::text, 8);
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE products ADD CONSTRAINT products_slug_key UNIQUE (slug);
+
+-- Old slugs keep resolving after a product is renamed
+CREATE TABLE IF NOT EXISTS product_slug_redirects (
+  slug VARCHAR(255) PRIMARY KEY,
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE product_slug_redirects;
+ALTER TABLE products DROP CONSTRAINT products_slug_key;
+ALTER TABLE products DROP COLUMN slug;
+-- +goose StatementEnd
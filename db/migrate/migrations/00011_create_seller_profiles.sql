@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS seller_profiles (
+  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  display_name VARCHAR(255) NOT NULL,
+  logo VARCHAR(255),
+  description TEXT NOT NULL DEFAULT '',
+  policies TEXT NOT NULL DEFAULT '', -- Shipping/returns policies shown on the storefront
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE seller_profiles;
+-- +goose StatementEnd
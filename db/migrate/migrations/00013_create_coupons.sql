@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE coupon_discount_type AS ENUM ('percentage', 'fixed', 'free_shipping');
+
+CREATE TABLE IF NOT EXISTS coupons (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  code VARCHAR(50) NOT NULL UNIQUE, -- Stored upper case, matched case-insensitively
+  description TEXT NOT NULL DEFAULT '',
+  discount_type coupon_discount_type NOT NULL,
+  amount DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (amount >= 0), -- Percent off or fixed amount off
+  min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  max_uses INT, -- NULL means unlimited
+  max_uses_per_user INT, -- NULL means unlimited
+  starts_at TIMESTAMP,
+  ends_at TIMESTAMP,
+  seller_id UUID REFERENCES users(id) ON DELETE CASCADE, -- Only discounts this seller's products
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- A coupon with rows here only discounts the listed products
+CREATE TABLE IF NOT EXISTS coupon_products (
+  coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  PRIMARY KEY (coupon_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions (coupon_id, user_id);
+
+-- Discount lines applied to an order
+CREATE TABLE IF NOT EXISTS order_discounts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  coupon_id UUID REFERENCES coupons(id) ON DELETE SET NULL,
+  code VARCHAR(50) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  amount DECIMAL(10, 2) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE orders
+  ADD COLUMN subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Sum of items before discounts
+  ADD COLUMN discount_total DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+UPDATE orders SET subtotal = total_price;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+  DROP COLUMN discount_total,
+  DROP COLUMN subtotal;
+DROP TABLE order_discounts;
+DROP TABLE coupon_redemptions;
+DROP TABLE coupon_products;
+DROP TABLE coupons;
+DROP TYPE coupon_discount_type;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  country CHAR(2) NOT NULL, -- ISO 3166-1 alpha-2, upper case
+  state VARCHAR(50) NOT NULL DEFAULT '', -- Empty applies to the whole country
+  tax_class VARCHAR(50) NOT NULL DEFAULT 'standard',
+  rate DECIMAL(7, 4) NOT NULL CHECK (rate >= 0), -- Fraction, 0.0825 is 8.25%
+  name VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (country, state, tax_class)
+);
+
+ALTER TABLE orders
+  ADD COLUMN tax_total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN tax_country VARCHAR(2) NOT NULL DEFAULT '',
+  ADD COLUMN tax_state VARCHAR(50) NOT NULL DEFAULT '';
+
+ALTER TABLE order_items
+  ADD COLUMN tax_rate DECIMAL(7, 4) NOT NULL DEFAULT 0,
+  ADD COLUMN tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Taxes charged on an order, one line per rate
+CREATE TABLE IF NOT EXISTS order_tax_lines (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  rate DECIMAL(7, 4) NOT NULL,
+  taxable_amount DECIMAL(10, 2) NOT NULL,
+  amount DECIMAL(10, 2) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_tax_lines;
+ALTER TABLE order_items
+  DROP COLUMN tax_amount,
+  DROP COLUMN tax_rate;
+ALTER TABLE orders
+  DROP COLUMN tax_state,
+  DROP COLUMN tax_country,
+  DROP COLUMN prices_include_tax,
+  DROP COLUMN tax_total;
+DROP TABLE tax_rates;
+ALTER TABLE products DROP COLUMN tax_class;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS addresses (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  label VARCHAR(50) NOT NULL DEFAULT '', -- e.g. Home, Work
+  full_name VARCHAR(255) NOT NULL,
+  line1 VARCHAR(255) NOT NULL,
+  line2 VARCHAR(255) NOT NULL DEFAULT '',
+  city VARCHAR(100) NOT NULL,
+  state VARCHAR(50) NOT NULL DEFAULT '',
+  postal_code VARCHAR(20) NOT NULL,
+  country CHAR(2) NOT NULL, -- ISO 3166-1 alpha-2, upper case
+  phone VARCHAR(30) NOT NULL DEFAULT '',
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- At most one default address per user
+CREATE UNIQUE INDEX idx_addresses_default ON addresses (user_id) WHERE is_default;
+
+CREATE TYPE shipping_rate_kind AS ENUM ('flat', 'weight_based', 'free_over_threshold');
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  code VARCHAR(50) NOT NULL UNIQUE,
+  name VARCHAR(100) NOT NULL,
+  kind shipping_rate_kind NOT NULL,
+  base_cost DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  per_kg_cost DECIMAL(10, 2) NOT NULL DEFAULT 0, -- weight_based only
+  free_threshold DECIMAL(10, 2) NOT NULL DEFAULT 0, -- free_over_threshold only
+  country CHAR(2), -- NULL ships everywhere
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE products ADD COLUMN weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0);
+
+ALTER TABLE orders
+  ADD COLUMN shipping_address JSONB NOT NULL DEFAULT '{}', -- Snapshot taken at checkout
+  ADD COLUMN shipping_method VARCHAR(50) NOT NULL DEFAULT '',
+  ADD COLUMN shipping_cost DECIMAL(10, 2) NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+  DROP COLUMN shipping_cost,
+  DROP COLUMN shipping_method,
+  DROP COLUMN shipping_address;
+ALTER TABLE products DROP COLUMN weight_grams;
+DROP TABLE shipping_methods;
+DROP TYPE shipping_rate_kind;
+DROP TABLE addresses;
+-- +goose StatementEnd
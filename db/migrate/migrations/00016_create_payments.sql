@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE payment_status AS ENUM ('requires_confirmation', 'authorized', 'captured', 'failed', 'refunded');
+
+CREATE TABLE IF NOT EXISTS payments (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  provider VARCHAR(50) NOT NULL,
+  provider_ref VARCHAR(255) NOT NULL, -- The provider's id for the payment intent
+  amount DECIMAL(10, 2) NOT NULL CHECK (amount >= 0),
+  refunded_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  status payment_status NOT NULL DEFAULT 'requires_confirmation',
+  failure_reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (provider, provider_ref)
+);
+
+CREATE INDEX idx_payments_order ON payments (order_id, created_at DESC);
+
+-- Webhook events already handled, so redelivered events are ignored
+CREATE TABLE IF NOT EXISTS payment_events (
+  provider VARCHAR(50) NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  payment_id UUID REFERENCES payments(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (provider, event_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE payment_events;
+DROP TABLE payments;
+DROP TYPE payment_status;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Responses to mutating requests sent with an Idempotency-Key header, replayed when
+-- the client retries
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  scope VARCHAR(100) NOT NULL, -- Who sent the key: a user or a guest cart token
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL, -- SHA-256 of method, URL and body
+  response_status INT, -- NULL while the first request is still running
+  content_type VARCHAR(255) NOT NULL DEFAULT '',
+  response_body BYTEA NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (scope, idempotency_key)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE idempotency_keys;
+-- +goose StatementEnd
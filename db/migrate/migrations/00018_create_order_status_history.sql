@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE order_status AS ENUM ('pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded');
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status order_status, -- NULL when the order was created
+  to_status order_status NOT NULL,
+  actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for system changes such as payment events
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_order_status_history_order ON order_status_history (order_id, created_at);
+
+-- 'completed' is now 'delivered'. Statuses were free text, so fix case, stray spaces
+-- and common misspellings; corrections are kept in the history.
+CREATE TEMP TABLE order_status_fixes (raw TEXT PRIMARY KEY, status TEXT NOT NULL) ON COMMIT DROP;
+INSERT INTO order_status_fixes (raw, status) VALUES
+  ('pending', 'pending'), ('pendng', 'pending'), ('pening', 'pending'),
+  ('paid', 'paid'), ('payed', 'paid'),
+  ('shipped', 'shipped'), ('shiped', 'shipped'), ('shippped', 'shipped'), ('shipd', 'shipped'),
+  ('delivered', 'delivered'), ('delivred', 'delivered'), ('deliverd', 'delivered'),
+  ('completed', 'delivered'), ('complete', 'delivered'),
+  ('cancelled', 'cancelled'), ('canceled', 'cancelled'), ('cancled', 'cancelled'), ('canceld', 'cancelled'),
+  ('refunded', 'refunded'), ('refund', 'refunded');
+
+INSERT INTO order_status_history (order_id, to_status, note)
+SELECT o.id, f.status::order_status, 'status "' || o.status || '" corrected to ' || f.status
+FROM orders o
+JOIN order_status_fixes f ON f.raw = LOWER(TRIM(o.status))
+WHERE o.status <> f.status AND o.status <> 'completed';
+
+UPDATE orders o SET status = f.status
+FROM order_status_fixes f
+WHERE f.raw = LOWER(TRIM(o.status)) AND o.status <> f.status;
+
+-- Anything left can't be guessed. Stop rather than invent a status; fix the listed
+-- orders by hand and run the migration again.
+DO $$
+DECLARE
+  bad TEXT;
+BEGIN
+  SELECT string_agg(id::text || ' ' || quote_literal(status), ', ' ORDER BY created_at)
+  INTO bad
+  FROM orders
+  WHERE status NOT IN ('pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded');
+
+  IF bad IS NOT NULL THEN
+    RAISE EXCEPTION 'orders with an unknown status: %', bad;
+  END IF;
+END $$;
+
+ALTER TABLE orders
+  ALTER COLUMN status DROP DEFAULT,
+  ALTER COLUMN status TYPE order_status USING status::order_status,
+  ALTER COLUMN status SET DEFAULT 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+  ALTER COLUMN status DROP DEFAULT,
+  ALTER COLUMN status TYPE VARCHAR(50) USING status::text,
+  ALTER COLUMN status SET DEFAULT 'pending';
+UPDATE orders SET status = 'completed' WHERE status = 'delivered';
+DROP TABLE order_status_history;
+DROP TYPE order_status;
+-- +goose StatementEnd
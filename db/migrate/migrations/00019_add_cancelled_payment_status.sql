@@ -0,0 +1,7 @@
+-- +goose Up
+-- Uncaptured payments voided when an order is cancelled
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'cancelled';
+
+-- +goose Down
+-- Postgres can't drop enum values; 'cancelled' stays but is no longer used
+SELECT 1;
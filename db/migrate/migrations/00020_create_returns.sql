@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Coupon discount allocated to the line, so returns refund what was actually paid
+ALTER TABLE order_items ADD COLUMN discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TYPE return_status AS ENUM ('requested', 'approved', 'rejected', 'received', 'refunded');
+CREATE TYPE return_resolution AS ENUM ('restock', 'write_off');
+
+CREATE TABLE IF NOT EXISTS return_requests (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status return_status NOT NULL DEFAULT 'requested',
+  reason TEXT NOT NULL,
+  return_label VARCHAR(255) NOT NULL DEFAULT '', -- Placeholder until a carrier integration issues real labels
+  decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  decision_note TEXT NOT NULL DEFAULT '',
+  refund_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  payment_id UUID REFERENCES payments(id) ON DELETE SET NULL, -- Payment the refund went to
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  decided_at TIMESTAMP,
+  received_at TIMESTAMP,
+  refunded_at TIMESTAMP
+);
+
+CREATE INDEX idx_return_requests_order ON return_requests (order_id);
+CREATE INDEX idx_return_requests_user ON return_requests (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS return_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  return_id UUID NOT NULL REFERENCES return_requests(id) ON DELETE CASCADE,
+  order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  reason TEXT NOT NULL DEFAULT '',
+  photos JSONB NOT NULL DEFAULT '[]', -- Image URLs
+  resolution return_resolution, -- Set when the item is inspected
+  inspection_note TEXT NOT NULL DEFAULT '',
+  UNIQUE (return_id, order_item_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE return_items;
+DROP TABLE return_requests;
+DROP TYPE return_resolution;
+DROP TYPE return_status;
+ALTER TABLE order_items DROP COLUMN discount_amount;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Each order is split into one fulfillment sub-order per seller
+CREATE TABLE IF NOT EXISTS seller_orders (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  seller_id UUID NOT NULL REFERENCES users(id),
+  status order_status NOT NULL DEFAULT 'pending',
+  subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  discount_total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  tax_total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  total DECIMAL(10, 2) NOT NULL DEFAULT 0, -- The seller's items; shipping stays on the order
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (order_id, seller_id)
+);
+
+CREATE INDEX idx_seller_orders_seller ON seller_orders (seller_id, created_at DESC);
+
+ALTER TABLE order_items ADD COLUMN seller_order_id UUID REFERENCES seller_orders(id) ON DELETE CASCADE;
+
+-- Status changes a seller made to their sub-order
+ALTER TABLE order_status_history ADD COLUMN seller_order_id UUID REFERENCES seller_orders(id) ON DELETE CASCADE;
+
+-- Split existing orders
+INSERT INTO seller_orders (order_id, seller_id, status, subtotal, discount_total, tax_total, total, created_at)
+SELECT
+    o.id, p.user_id, o.status,
+    SUM(oi.price * oi.quantity),
+    SUM(oi.discount_amount),
+    SUM(oi.tax_amount),
+    SUM(oi.price * oi.quantity) - SUM(oi.discount_amount)
+        + CASE WHEN o.prices_include_tax THEN 0 ELSE SUM(oi.tax_amount) END,
+    o.created_at
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+GROUP BY o.id, p.user_id;
+
+UPDATE order_items oi
+SET seller_order_id = so.id
+FROM products p, seller_orders so
+WHERE p.id = oi.product_id AND so.order_id = oi.order_id AND so.seller_id = p.user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_status_history DROP COLUMN seller_order_id;
+ALTER TABLE order_items DROP COLUMN seller_order_id;
+DROP TABLE seller_orders;
+-- +goose StatementEnd
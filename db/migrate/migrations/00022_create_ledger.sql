@@ -0,0 +1,86 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Free-form product category, used to pick commission rates
+ALTER TABLE products ADD COLUMN category VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Platform fee charged on sellers' sales. The most specific rule wins: seller and
+-- category, seller, category, then the platform default (no seller, empty category).
+CREATE TABLE IF NOT EXISTS commission_rates (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  seller_id UUID REFERENCES users(id) ON DELETE CASCADE,
+  category VARCHAR(50) NOT NULL DEFAULT '',
+  rate DECIMAL(5, 4) NOT NULL CHECK (rate >= 0 AND rate <= 1), -- 0.1000 = 10%
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_commission_rates_scope
+  ON commission_rates ((COALESCE(seller_id, '00000000-0000-0000-0000-000000000000'::uuid)), category);
+
+INSERT INTO commission_rates (seller_id, category, rate) VALUES (NULL, '', 0.10);
+
+CREATE TYPE ledger_account AS ENUM ('platform_cash', 'platform_revenue', 'seller_payable');
+CREATE TYPE ledger_transaction_kind AS ENUM ('sale', 'commission', 'refund', 'payout');
+
+-- A batch of seller payouts run by an admin
+CREATE TABLE IF NOT EXISTS payout_batches (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  seller_count INTEGER NOT NULL DEFAULT 0,
+  total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- One seller's settlement in a batch
+CREATE TABLE IF NOT EXISTS payouts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  batch_id UUID NOT NULL REFERENCES payout_batches(id) ON DELETE CASCADE,
+  seller_id UUID NOT NULL REFERENCES users(id),
+  sales DECIMAL(12, 2) NOT NULL,
+  commission DECIMAL(12, 2) NOT NULL,
+  refunds DECIMAL(12, 2) NOT NULL,
+  amount DECIMAL(12, 2) NOT NULL, -- sales - commission - refunds
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (batch_id, seller_id)
+);
+
+CREATE INDEX idx_payouts_seller ON payouts (seller_id, created_at DESC);
+
+-- Double-entry ledger: every transaction's entries add up to zero
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  kind ledger_transaction_kind NOT NULL,
+  order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+  seller_order_id UUID REFERENCES seller_orders(id) ON DELETE SET NULL,
+  payout_id UUID REFERENCES payouts(id) ON DELETE SET NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_ledger_transactions_order ON ledger_transactions (order_id);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  transaction_id UUID NOT NULL REFERENCES ledger_transactions(id) ON DELETE CASCADE,
+  account ledger_account NOT NULL,
+  seller_id UUID REFERENCES users(id), -- Set on seller_payable entries
+  amount DECIMAL(12, 2) NOT NULL, -- Debits are positive, credits negative
+  payout_id UUID REFERENCES payouts(id) ON DELETE SET NULL, -- The payout that settled a seller_payable entry
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  CHECK ((account = 'seller_payable') = (seller_id IS NOT NULL))
+);
+
+CREATE INDEX idx_ledger_entries_unsettled ON ledger_entries (seller_id) WHERE payout_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ledger_entries;
+DROP TABLE ledger_transactions;
+DROP TABLE payouts;
+DROP TABLE payout_batches;
+DROP TYPE ledger_transaction_kind;
+DROP TYPE ledger_account;
+DROP TABLE commission_rates;
+ALTER TABLE products DROP COLUMN category;
+-- +goose StatementEnd
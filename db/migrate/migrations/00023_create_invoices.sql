@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE invoice_kind AS ENUM ('invoice', 'credit_note');
+
+-- Numbers are taken by locking the series row, so a rolled back checkout gives its
+-- number back and the sequence has no gaps
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+  series VARCHAR(10) PRIMARY KEY,
+  last_number BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO invoice_sequences (series) VALUES ('INV'), ('CN');
+
+-- Invoices and credit notes keep a copy of everything printed on them, so later changes
+-- to products, profiles or addresses don't alter issued documents
+CREATE TABLE IF NOT EXISTS invoices (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  kind invoice_kind NOT NULL,
+  number VARCHAR(30) NOT NULL UNIQUE,
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  payment_id UUID REFERENCES payments(id) ON DELETE SET NULL, -- The refunded payment, for credit notes
+  credited_invoice_id UUID REFERENCES invoices(id), -- The invoice a credit note corrects
+  issuer JSONB NOT NULL,
+  buyer JSONB NOT NULL,
+  lines JSONB NOT NULL DEFAULT '[]',
+  tax_lines JSONB NOT NULL DEFAULT '[]',
+  subtotal DECIMAL(12, 2) NOT NULL,
+  discount_total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+  shipping_cost DECIMAL(12, 2) NOT NULL DEFAULT 0,
+  tax_total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+  total DECIMAL(12, 2) NOT NULL,
+  prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE,
+  issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- One invoice per order; any number of credit notes
+CREATE UNIQUE INDEX idx_invoices_order_invoice ON invoices (order_id) WHERE kind = 'invoice';
+CREATE INDEX idx_invoices_order ON invoices (order_id, issued_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE invoices;
+DROP TABLE invoice_sequences;
+DROP TYPE invoice_kind;
+-- +goose StatementEnd
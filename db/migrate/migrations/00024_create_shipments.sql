@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE shipment_status AS ENUM ('shipped', 'in_transit', 'out_for_delivery', 'delivered', 'exception');
+
+-- A parcel a seller sent for their sub-order. A sub-order can go out in several
+-- shipments, each carrying some of its items.
+CREATE TABLE IF NOT EXISTS shipments (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  seller_order_id UUID NOT NULL REFERENCES seller_orders(id) ON DELETE CASCADE,
+  seller_id UUID NOT NULL REFERENCES users(id),
+  carrier VARCHAR(50) NOT NULL,
+  tracking_number VARCHAR(100) NOT NULL,
+  tracking_url TEXT NOT NULL DEFAULT '',
+  status shipment_status NOT NULL DEFAULT 'shipped',
+  shipped_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  delivered_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (carrier, tracking_number)
+);
+
+CREATE INDEX idx_shipments_order ON shipments (order_id, shipped_at);
+CREATE INDEX idx_shipments_seller_order ON shipments (seller_order_id);
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+  shipment_id UUID NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+  order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  PRIMARY KEY (shipment_id, order_item_id)
+);
+
+CREATE INDEX idx_shipment_items_order_item ON shipment_items (order_item_id);
+
+-- Tracking history. Carriers redeliver events, so the same status at the same time is
+-- only stored once.
+CREATE TABLE IF NOT EXISTS shipment_events (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  shipment_id UUID NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+  status shipment_status NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  location VARCHAR(255) NOT NULL DEFAULT '',
+  occurred_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (shipment_id, status, occurred_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE shipment_events;
+DROP TABLE shipment_items;
+DROP TABLE shipments;
+DROP TYPE shipment_status;
+-- +goose StatementEnd
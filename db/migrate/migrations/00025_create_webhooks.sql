@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Endpoints that are sent events. Platform-wide subscriptions have no seller and get
+-- events about the whole marketplace; a seller's get events about their own products and
+-- sub-orders.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  seller_id UUID REFERENCES users(id) ON DELETE CASCADE,
+  url TEXT NOT NULL,
+  secret VARCHAR(100) NOT NULL, -- Signs every payload, see X-Webhook-Signature
+  events TEXT[] NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_webhook_subscriptions_seller ON webhook_subscriptions (seller_id) WHERE active;
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- One event for one subscription. event_id is shared by every subscription's copy of
+-- the same event and by redeliveries, so receivers can drop duplicates.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  event_id UUID NOT NULL,
+  event_type VARCHAR(50) NOT NULL,
+  payload JSONB NOT NULL,
+  status webhook_delivery_status NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  last_status_code INT NOT NULL DEFAULT 0, -- 0 when there was no response
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at DESC);
+
+-- Log of every request made for a delivery
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+  attempt INT NOT NULL,
+  status_code INT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  response_body TEXT NOT NULL DEFAULT '', -- First 1 KB
+  duration_ms INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_webhook_attempts_delivery ON webhook_attempts (delivery_id, attempt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_attempts;
+DROP TABLE webhook_deliveries;
+DROP TYPE webhook_delivery_status;
+DROP TABLE webhook_subscriptions;
+-- +goose StatementEnd
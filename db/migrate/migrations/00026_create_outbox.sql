@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE outbox_event_status AS ENUM ('pending', 'processed', 'failed');
+
+-- Domain events, written in the same transaction as the change they describe and handed
+-- to in-process subscribers after it commits.
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  event_type VARCHAR(50) NOT NULL,
+  aggregate_id UUID NOT NULL, -- The order, sub-order or product the event is about
+  payload JSONB NOT NULL,
+  status outbox_event_status NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  processed_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_events_due ON outbox_events (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_events_processed ON outbox_events (processed_at) WHERE status = 'processed';
+
+-- Which subscribers have handled an event, so a retry only reaches the ones that failed
+CREATE TABLE IF NOT EXISTS outbox_receipts (
+  event_id UUID NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+  subscriber VARCHAR(50) NOT NULL,
+  handled_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (event_id, subscriber)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox_receipts;
+DROP TABLE outbox_events;
+DROP TYPE outbox_event_status;
+-- +goose StatementEnd
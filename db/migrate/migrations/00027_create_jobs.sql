@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE job_status AS ENUM ('queued', 'running', 'succeeded', 'dead');
+
+-- Background work. Workers claim due jobs with FOR UPDATE SKIP LOCKED and hold them
+-- until locked_until; a job whose worker died is claimed again once its lease runs out.
+-- Jobs that keep failing end up dead until an admin retries them.
+CREATE TABLE IF NOT EXISTS jobs (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  kind VARCHAR(50) NOT NULL,
+  payload JSONB NOT NULL,
+  status job_status NOT NULL DEFAULT 'queued',
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL DEFAULT 5,
+  run_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL, -- Not before, for delayed jobs and retries
+  locked_until TIMESTAMP,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_due ON jobs (run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running ON jobs (locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_status ON jobs (status, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE jobs;
+DROP TYPE job_status;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Channels a user turned off or back on per category. No row means the default: on.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  category VARCHAR(30) NOT NULL,
+  channel VARCHAR(20) NOT NULL, -- email or in_app
+  enabled BOOLEAN NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, category, channel)
+);
+
+-- Only a hash of the token is stored; the token itself is only in the email
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_password_reset_tokens_user ON password_reset_tokens (user_id) WHERE used_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE password_reset_tokens;
+DROP TABLE notification_preferences;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS wishlists (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  share_token VARCHAR(64) UNIQUE, -- Set while the list is public, part of its share link
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_items (
+  wishlist_id UUID NOT NULL REFERENCES wishlists(id) ON DELETE CASCADE,
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  added_price DECIMAL(10, 2) NOT NULL, -- Price when added
+  last_price DECIMAL(10, 2) NOT NULL, -- Price at the last product update, price drops are measured from it
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (wishlist_id, product_id)
+);
+
+CREATE INDEX idx_wishlist_items_product ON wishlist_items (product_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wishlist_items;
+DROP TABLE wishlists;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Customers rate a seller once per delivered sub-order
+CREATE TABLE IF NOT EXISTS seller_ratings (
+  seller_order_id UUID PRIMARY KEY REFERENCES seller_orders(id) ON DELETE CASCADE,
+  seller_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  comment TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_seller_ratings_seller ON seller_ratings (seller_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE seller_ratings;
+-- +goose StatementEnd
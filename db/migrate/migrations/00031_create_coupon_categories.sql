@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A coupon with rows here also discounts products in the listed categories
+CREATE TABLE IF NOT EXISTS coupon_categories (
+  coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+  category VARCHAR(50) NOT NULL,
+  PRIMARY KEY (coupon_id, category)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE coupon_categories;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- created_at is the transaction's start time, so events recorded together share it.
+-- seq orders events the way they were written, including within one transaction, and
+-- is what readers resume from.
+CREATE SEQUENCE outbox_events_seq_seq;
+ALTER TABLE outbox_events ADD COLUMN seq BIGINT;
+
+UPDATE outbox_events e
+SET seq = numbered.seq
+FROM (
+  SELECT id, nextval('outbox_events_seq_seq') AS seq
+  FROM (SELECT id FROM outbox_events ORDER BY created_at, id) ordered
+) numbered
+WHERE e.id = numbered.id;
+
+ALTER TABLE outbox_events
+  ALTER COLUMN seq SET DEFAULT nextval('outbox_events_seq_seq'),
+  ALTER COLUMN seq SET NOT NULL;
+ALTER SEQUENCE outbox_events_seq_seq OWNED BY outbox_events.seq;
+CREATE UNIQUE INDEX idx_outbox_events_seq ON outbox_events (seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox_events DROP COLUMN seq;
+-- +goose StatementEnd
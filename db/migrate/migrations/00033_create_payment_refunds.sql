@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE payment_refund_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- Refunds and voids waiting for the gateway. They are written in the transaction that
+-- asks for them, such as an order cancellation, and sent by the payments.refund job
+-- once it commits, so a rollback can't undo the bookkeeping of a refund the gateway
+-- already made.
+CREATE TABLE IF NOT EXISTS payment_refunds (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+  amount DECIMAL(10, 2) CHECK (amount > 0), -- NULL releases whatever the payment still holds
+  seller_shares JSONB NOT NULL DEFAULT '{}', -- Seller id to amount, see ledger.RecordRefund
+  return_id UUID REFERENCES return_requests(id) ON DELETE SET NULL,
+  status payment_refund_status NOT NULL DEFAULT 'pending',
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_payment_refunds_payment ON payment_refunds (payment_id) WHERE status = 'pending';
+CREATE INDEX idx_payment_refunds_return ON payment_refunds (return_id) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE payment_refunds;
+DROP TYPE payment_refund_status;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- name: CreateAddress :one
+INSERT INTO addresses (
+    user_id, label, full_name, line1, line2, city, state, postal_code, country, phone, is_default
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: ListAddressesByUser :many
+SELECT * FROM addresses
+WHERE user_id = $1
+ORDER BY is_default DESC, created_at ASC;
+
+-- name: GetAddressByID :one
+SELECT * FROM addresses
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: CountAddressesByUser :one
+SELECT COUNT(*) FROM addresses
+WHERE user_id = $1;
+
+-- name: UpdateAddress :one
+UPDATE addresses
+SET
+    label = $3,
+    full_name = $4,
+    line1 = $5,
+    line2 = $6,
+    city = $7,
+    state = $8,
+    postal_code = $9,
+    country = $10,
+    phone = $11,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteAddress :execrows
+DELETE FROM addresses
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClearDefaultAddress :exec
+UPDATE addresses
+SET is_default = FALSE
+WHERE user_id = $1 AND is_default;
+
+-- name: SetDefaultAddress :execrows
+UPDATE addresses
+SET is_default = TRUE, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2;
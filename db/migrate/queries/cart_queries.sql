@@ -0,0 +1,73 @@
+-- name: CreateCart :one
+INSERT INTO carts (user_id, guest_token)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetCartByUser :one
+SELECT * FROM carts
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: GetCartByUserForUpdate :one
+-- Locks the cart so two checkouts of it run one after the other
+SELECT * FROM carts
+WHERE user_id = $1
+LIMIT 1
+FOR UPDATE;
+
+-- name: GetCartByGuestToken :one
+SELECT * FROM carts
+WHERE guest_token = $1 AND user_id IS NULL
+LIMIT 1;
+
+-- name: AssignCartToUser :exec
+-- Turns a guest cart into the user's cart when they had none
+UPDATE carts
+SET user_id = $2, guest_token = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: TouchCart :exec
+UPDATE carts
+SET updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: DeleteCart :exec
+DELETE FROM carts
+WHERE id = $1;
+
+-- name: AddCartItem :one
+-- Adding a product already in the cart increases its quantity
+INSERT INTO cart_items (cart_id, product_id, quantity, added_price)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cart_id, product_id) DO UPDATE SET
+    quantity = cart_items.quantity + EXCLUDED.quantity,
+    added_price = EXCLUDED.added_price
+RETURNING *;
+
+-- name: UpdateCartItemQuantity :one
+UPDATE cart_items
+SET quantity = $3
+WHERE cart_id = $1 AND product_id = $2
+RETURNING *;
+
+-- name: DeleteCartItem :execrows
+DELETE FROM cart_items
+WHERE cart_id = $1 AND product_id = $2;
+
+-- name: ListCartItems :many
+SELECT * FROM cart_items
+WHERE cart_id = $1
+ORDER BY created_at ASC;
+
+-- name: MergeCartItems :exec
+-- Moves a guest cart's items into the user's cart, summing quantities of shared products
+INSERT INTO cart_items (cart_id, product_id, quantity, added_price)
+SELECT sqlc.arg(target_cart_id)::uuid, product_id, quantity, added_price
+FROM cart_items
+WHERE cart_id = sqlc.arg(source_cart_id)
+ON CONFLICT (cart_id, product_id) DO UPDATE SET
+    quantity = cart_items.quantity + EXCLUDED.quantity;
+
+-- name: ClearCart :exec
+DELETE FROM cart_items
+WHERE cart_id = $1;
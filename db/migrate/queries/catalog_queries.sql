@@ -0,0 +1,27 @@
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (user_id, format, total_rows)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetImportJob :one
+SELECT * FROM import_jobs
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: UpdateImportJobProgress :exec
+UPDATE import_jobs
+SET 
+    status = 'running',
+    processed_rows = $2,
+    created_count = $3,
+    updated_count = $4,
+    error_count = $5,
+    errors = $6
+WHERE id = $1;
+
+-- name: FinishImportJob :exec
+UPDATE import_jobs
+SET 
+    status = $2,
+    finished_at = CURRENT_TIMESTAMP
+WHERE id = $1;
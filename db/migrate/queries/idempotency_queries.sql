@@ -0,0 +1,29 @@
+-- name: ClaimIdempotencyKey :execrows
+-- Returns 0 when the key is already taken. Keys older than a day, and requests that
+-- never finished within five minutes, can be claimed again.
+INSERT INTO idempotency_keys (scope, idempotency_key, request_hash)
+VALUES ($1, $2, $3)
+ON CONFLICT (scope, idempotency_key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    response_status = NULL,
+    content_type = '',
+    response_body = '',
+    created_at = CURRENT_TIMESTAMP
+WHERE idempotency_keys.created_at < CURRENT_TIMESTAMP - INTERVAL '24 hours'
+   OR (idempotency_keys.response_status IS NULL
+       AND idempotency_keys.created_at < CURRENT_TIMESTAMP - INTERVAL '5 minutes');
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1 AND idempotency_key = $2;
+
+-- name: SaveIdempotencyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $3,
+    content_type = $4,
+    response_body = $5
+WHERE scope = $1 AND idempotency_key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND idempotency_key = $2;
@@ -0,0 +1,42 @@
+-- name: CreateInventoryMovement :one
+INSERT INTO inventory_movements (
+    product_id, movement_type, quantity, balance_after, actor_id, order_id, reason
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListInventoryMovementsByProduct :many
+SELECT * FROM inventory_movements
+WHERE product_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountInventoryMovementsByProduct :one
+SELECT COUNT(*) FROM inventory_movements
+WHERE product_id = $1;
+
+-- name: GetLedgerStockByProduct :one
+-- On-hand quantity derived purely from the ledger, used to reconcile products.stock_quantity
+SELECT COALESCE(SUM(quantity), 0)::int AS on_hand
+FROM inventory_movements
+WHERE product_id = $1;
+
+-- name: CreateStockSubscription :one
+-- Re-subscribing after a previous alert re-arms the subscription
+INSERT INTO stock_subscriptions (product_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (product_id, user_id) DO UPDATE SET notified_at = NULL
+RETURNING *;
+
+-- name: DeleteStockSubscription :execrows
+DELETE FROM stock_subscriptions
+WHERE product_id = $1 AND user_id = $2;
+
+-- name: ListPendingStockSubscriptions :many
+SELECT * FROM stock_subscriptions
+WHERE product_id = $1 AND notified_at IS NULL;
+
+-- name: MarkStockSubscriptionsNotified :exec
+UPDATE stock_subscriptions
+SET notified_at = CURRENT_TIMESTAMP
+WHERE product_id = $1 AND notified_at IS NULL;
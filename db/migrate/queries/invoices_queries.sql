@@ -0,0 +1,40 @@
+-- name: NextInvoiceNumber :one
+-- Locks the series until the transaction ends, so numbers are handed out without gaps
+UPDATE invoice_sequences
+SET last_number = last_number + 1
+WHERE series = $1
+RETURNING last_number;
+
+-- name: CreateInvoice :one
+INSERT INTO invoices (
+    kind, number, order_id, user_id, payment_id, credited_invoice_id, issuer, buyer, lines, tax_lines,
+    subtotal, discount_total, shipping_cost, tax_total, total, prices_include_tax
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING *;
+
+-- name: GetInvoiceByID :one
+SELECT * FROM invoices
+WHERE id = $1;
+
+-- name: GetOrderInvoice :one
+SELECT * FROM invoices
+WHERE order_id = $1 AND kind = 'invoice';
+
+-- name: ListCreditNotesByOrder :many
+SELECT * FROM invoices
+WHERE order_id = $1 AND kind = 'credit_note'
+ORDER BY issued_at ASC, number ASC;
+
+-- name: ListInvoiceLineSources :many
+-- Order items with the product and seller names printed on the invoice
+SELECT
+    oi.quantity, oi.price, oi.tax_rate, oi.tax_amount, oi.discount_amount,
+    p.name AS product_name, p.sku AS product_sku,
+    COALESCE(NULLIF(sp.display_name, ''), u.username)::TEXT AS seller_name
+FROM order_items oi
+JOIN products p ON p.id = oi.product_id
+JOIN users u ON u.id = p.user_id
+LEFT JOIN seller_profiles sp ON sp.user_id = p.user_id
+WHERE oi.order_id = $1
+ORDER BY p.name ASC;
@@ -0,0 +1,81 @@
+-- name: CreateJob :one
+INSERT INTO jobs (kind, payload, run_at, max_attempts)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ClaimJob :one
+-- Takes the oldest due job of a kind this worker handles, or one whose worker's lease
+-- ran out, and counts the attempt
+UPDATE jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(lease_seconds)::INT),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE kind = ANY(sqlc.arg(kinds)::TEXT[])
+      AND ((status = 'queued' AND run_at <= CURRENT_TIMESTAMP)
+        OR (status = 'running' AND locked_until < CURRENT_TIMESTAMP))
+    ORDER BY run_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ExtendJobLease :exec
+UPDATE jobs
+SET locked_until = CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(lease_seconds)::INT),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'succeeded', locked_until = NULL, last_error = '',
+    finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RetryJobLater :exec
+UPDATE jobs
+SET status = 'queued', locked_until = NULL, run_at = $2, last_error = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: KillJob :exec
+UPDATE jobs
+SET status = 'dead', locked_until = NULL, last_error = $2,
+    finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1;
+
+-- name: ListJobs :many
+-- Empty status or kind matches every job
+SELECT * FROM jobs
+WHERE (sqlc.arg(status)::TEXT = '' OR status::TEXT = sqlc.arg(status)::TEXT)
+  AND (sqlc.arg(kind)::TEXT = '' OR kind = sqlc.arg(kind)::TEXT)
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountJobs :one
+SELECT COUNT(*) FROM jobs
+WHERE (sqlc.arg(status)::TEXT = '' OR status::TEXT = sqlc.arg(status)::TEXT)
+  AND (sqlc.arg(kind)::TEXT = '' OR kind = sqlc.arg(kind)::TEXT);
+
+-- name: CountJobsByStatus :many
+SELECT kind, status, COUNT(*) AS count FROM jobs
+GROUP BY kind, status
+ORDER BY kind, status;
+
+-- name: RequeueDeadJob :one
+-- A dead job gets a fresh set of attempts
+UPDATE jobs
+SET status = 'queued', attempts = 0, run_at = CURRENT_TIMESTAMP, finished_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'dead'
+RETURNING *;
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE status = 'succeeded' AND finished_at < $1;
@@ -0,0 +1,143 @@
+-- name: LockLedger :exec
+-- Held by payout runs so no entry is posted while balances are being settled
+SELECT pg_advisory_xact_lock(hashtext('ledger'));
+
+-- name: LockLedgerShared :exec
+-- Held while posting, so postings don't block each other but wait for payout runs
+SELECT pg_advisory_xact_lock_shared(hashtext('ledger'));
+
+-- name: CreateLedgerTransaction :one
+INSERT INTO ledger_transactions (kind, order_id, seller_order_id, payout_id, description)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (transaction_id, account, seller_id, amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: CountOrderSales :one
+SELECT COUNT(*) FROM ledger_transactions
+WHERE order_id = $1 AND kind = 'sale';
+
+-- name: ListOrderRefundedAmounts :many
+-- Money refunded so far per sub-order; shipping refunds have no sub-order
+SELECT t.seller_order_id, (-SUM(e.amount))::DECIMAL AS refunded
+FROM ledger_transactions t
+JOIN ledger_entries e ON e.transaction_id = t.id
+WHERE t.order_id = $1 AND t.kind = 'refund' AND e.account = 'platform_cash'
+GROUP BY t.seller_order_id;
+
+-- name: ListOrderCommissions :many
+-- Commission charged per sub-order, net of commission given back on refunds
+SELECT t.seller_order_id, (-SUM(e.amount))::DECIMAL AS commission
+FROM ledger_transactions t
+JOIN ledger_entries e ON e.transaction_id = t.id
+WHERE t.order_id = $1 AND t.kind = 'commission' AND e.account = 'platform_revenue'
+GROUP BY t.seller_order_id;
+
+-- name: GetSellerBalance :one
+-- What the platform owes the seller since their last payout
+SELECT
+    COALESCE(-SUM(e.amount), 0)::DECIMAL AS balance,
+    COALESCE(-SUM(e.amount) FILTER (WHERE t.kind = 'sale'), 0)::DECIMAL AS sales,
+    COALESCE(SUM(e.amount) FILTER (WHERE t.kind = 'commission'), 0)::DECIMAL AS commission,
+    COALESCE(SUM(e.amount) FILTER (WHERE t.kind = 'refund'), 0)::DECIMAL AS refunds
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account = 'seller_payable' AND e.seller_id = $1 AND e.payout_id IS NULL;
+
+-- name: ListPayableSellers :many
+-- Sellers owed at least the minimum amount
+SELECT
+    e.seller_id,
+    (-SUM(e.amount))::DECIMAL AS balance,
+    COALESCE(-SUM(e.amount) FILTER (WHERE t.kind = 'sale'), 0)::DECIMAL AS sales,
+    COALESCE(SUM(e.amount) FILTER (WHERE t.kind = 'commission'), 0)::DECIMAL AS commission,
+    COALESCE(SUM(e.amount) FILTER (WHERE t.kind = 'refund'), 0)::DECIMAL AS refunds
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account = 'seller_payable' AND e.payout_id IS NULL
+GROUP BY e.seller_id
+HAVING -SUM(e.amount) >= GREATEST(sqlc.arg(min_amount)::DECIMAL, 0.01)
+ORDER BY e.seller_id;
+
+-- name: SettleSellerEntries :execrows
+UPDATE ledger_entries
+SET payout_id = $2
+WHERE account = 'seller_payable' AND seller_id = $1 AND payout_id IS NULL;
+
+-- name: ListSellerLedgerEntries :many
+SELECT
+    e.id, e.amount, e.payout_id, e.created_at,
+    t.kind, t.order_id, t.seller_order_id, t.description
+FROM ledger_entries e
+JOIN ledger_transactions t ON t.id = e.transaction_id
+WHERE e.account = 'seller_payable' AND e.seller_id = $1
+ORDER BY e.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountSellerLedgerEntries :one
+SELECT COUNT(*) FROM ledger_entries
+WHERE account = 'seller_payable' AND seller_id = $1;
+
+-- name: CreatePayoutBatch :one
+INSERT INTO payout_batches (created_by)
+VALUES ($1)
+RETURNING *;
+
+-- name: UpdatePayoutBatchTotals :one
+UPDATE payout_batches
+SET seller_count = $2,
+    total = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: GetPayoutBatch :one
+SELECT * FROM payout_batches
+WHERE id = $1;
+
+-- name: ListPayoutBatches :many
+SELECT * FROM payout_batches
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountPayoutBatches :one
+SELECT COUNT(*) FROM payout_batches;
+
+-- name: CreatePayout :one
+INSERT INTO payouts (batch_id, seller_id, sales, commission, refunds, amount)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListPayoutsByBatch :many
+SELECT * FROM payouts
+WHERE batch_id = $1
+ORDER BY seller_id;
+
+-- name: ListPayoutsBySeller :many
+SELECT * FROM payouts
+WHERE seller_id = $1
+ORDER BY created_at DESC;
+
+-- name: FindCommissionRate :one
+-- The most specific rule for the seller and category
+SELECT * FROM commission_rates
+WHERE (seller_id = $1 OR seller_id IS NULL) AND (category = $2 OR category = '')
+ORDER BY seller_id IS NULL, category = ''
+LIMIT 1;
+
+-- name: ListCommissionRates :many
+SELECT * FROM commission_rates
+ORDER BY seller_id NULLS FIRST, category;
+
+-- name: UpsertCommissionRate :one
+INSERT INTO commission_rates (seller_id, category, rate)
+VALUES ($1, $2, $3)
+ON CONFLICT ((COALESCE(seller_id, '00000000-0000-0000-0000-000000000000'::uuid)), category) DO UPDATE SET
+    rate = EXCLUDED.rate,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteCommissionRate :execrows
+DELETE FROM commission_rates
+WHERE id = $1;
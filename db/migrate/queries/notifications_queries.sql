@@ -0,0 +1,34 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, type, title, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListNotificationsByUser :many
+SELECT * FROM notifications
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY category, channel;
+
+-- name: GetNotificationPreference :one
+SELECT enabled FROM notification_preferences
+WHERE user_id = $1 AND category = $2 AND channel = $3;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, category, channel, enabled)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, category, channel) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_at = CURRENT_TIMESTAMP;
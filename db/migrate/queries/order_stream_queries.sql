@@ -0,0 +1,17 @@
+-- name: NotifyOrderStream :exec
+-- Delivered to every listening server once the transaction commits
+SELECT pg_notify('order_stream', sqlc.arg(payload)::TEXT);
+
+-- name: ListOrderStreamEventsSince :many
+-- Order events for a customer's orders and a seller's sub-orders recorded after the
+-- given event, for clients catching up after a reconnect
+SELECT e.* FROM outbox_events e
+WHERE e.seq > (SELECT seq FROM outbox_events WHERE id = sqlc.arg(last_event_id))
+  AND (
+    (e.event_type IN ('order.created', 'order.status_changed')
+      AND e.aggregate_id IN (SELECT id FROM orders WHERE user_id = sqlc.arg(user_id)))
+    OR (e.event_type IN ('seller_order.created', 'seller_order.status_changed')
+      AND e.aggregate_id IN (SELECT id FROM seller_orders WHERE seller_id = sqlc.arg(user_id)))
+  )
+ORDER BY e.seq ASC
+LIMIT sqlc.arg(max_events);
@@ -1,13 +1,21 @@
 -- name: CreateOrder :one
-INSERT INTO orders (user_id, total_price, status)
-VALUES ($1, $2, $3)
+INSERT INTO orders (
+    user_id, total_price, status, subtotal, discount_total,
+    tax_total, prices_include_tax, tax_country, tax_state,
+    shipping_address, shipping_method, shipping_cost
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: CreateOrderItem :one
-INSERT INTO order_items (order_id, product_id, quantity, price)
-VALUES ($1, $2, $3, $4)
+INSERT INTO order_items (order_id, product_id, quantity, price, tax_rate, tax_amount, discount_amount, seller_order_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
+-- name: GetOrderItemByID :one
+SELECT * FROM order_items
+WHERE id = $1 AND order_id = $2;
+
 -- name: GetOrderByID :one
 SELECT * FROM orders 
 WHERE id = $1 AND user_id = $2 
@@ -20,7 +28,7 @@ ORDER BY created_at DESC;
 
 -- name: GetOrderItems :many
 SELECT 
-    oi.id, oi.product_id, oi.quantity, oi.price,
+    oi.id, oi.product_id, oi.quantity, oi.price, oi.tax_rate, oi.tax_amount, oi.discount_amount,
     p.name as product_name, p.image as product_image
 FROM order_items oi
 JOIN products p ON oi.product_id = p.id
@@ -29,4 +37,22 @@ WHERE oi.order_id = $1;
 -- name: UpdateOrderStatus :exec
 UPDATE orders 
 SET status = $2 
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetOrderByIDForUpdate :one
+SELECT * FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CreateOrderStatusHistory :exec
+INSERT INTO order_status_history (order_id, seller_order_id, from_status, to_status, actor_id, note)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListOrderStatusHistory :many
+SELECT * FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetOrderUserID :one
+SELECT user_id FROM orders
+WHERE id = $1;
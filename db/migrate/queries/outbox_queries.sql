@@ -0,0 +1,39 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (event_type, aggregate_id, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimDueOutboxEvents :many
+-- Leases due events the same way ClaimDueWebhookDeliveries does, oldest first
+UPDATE outbox_events
+SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(lease_seconds)::INT)
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+    ORDER BY seq ASC
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ListOutboxReceipts :many
+SELECT subscriber FROM outbox_receipts
+WHERE event_id = $1;
+
+-- name: CreateOutboxReceipt :exec
+INSERT INTO outbox_receipts (event_id, subscriber)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: MarkOutboxEventProcessed :exec
+UPDATE outbox_events
+SET status = 'processed', last_error = '', processed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5
+WHERE id = $1;
+
+-- name: DeleteProcessedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE status = 'processed' AND processed_at < $1;
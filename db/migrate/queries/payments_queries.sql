@@ -0,0 +1,82 @@
+-- name: CreatePayment :one
+INSERT INTO payments (order_id, provider, provider_ref, amount, status)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetPaymentByID :one
+SELECT * FROM payments
+WHERE id = $1;
+
+-- name: GetPaymentByIDForUpdate :one
+SELECT * FROM payments
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetPaymentByProviderRefForUpdate :one
+SELECT * FROM payments
+WHERE provider = $1 AND provider_ref = $2
+FOR UPDATE;
+
+-- name: GetOpenPaymentByOrder :one
+-- The latest payment for the order that can still be completed
+SELECT * FROM payments
+WHERE order_id = $1 AND status IN ('requires_confirmation', 'authorized')
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListPaymentsByOrder :many
+SELECT * FROM payments
+WHERE order_id = $1
+ORDER BY created_at DESC;
+
+-- name: UpdatePayment :one
+-- refunded_amount never goes down, so replayed refund events are harmless
+UPDATE payments
+SET status = $2,
+    refunded_amount = GREATEST(refunded_amount, $3),
+    failure_reason = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordPaymentEvent :execrows
+-- Returns 0 when the event was already handled
+INSERT INTO payment_events (provider, event_id, event_type, payment_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (provider, event_id) DO NOTHING;
+
+-- name: CreatePaymentRefund :one
+INSERT INTO payment_refunds (payment_id, amount, seller_shares, return_id)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetPaymentRefund :one
+SELECT * FROM payment_refunds
+WHERE id = $1;
+
+-- name: GetPaymentRefundForUpdate :one
+SELECT * FROM payment_refunds
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SumPendingRefundsByPayment :one
+-- Refunds of a set amount that the gateway hasn't made yet
+SELECT COALESCE(SUM(amount), 0)::decimal AS pending FROM payment_refunds
+WHERE payment_id = $1 AND status = 'pending';
+
+-- name: CountPendingRefundsByReturn :one
+SELECT COUNT(*) FROM payment_refunds
+WHERE return_id = $1 AND status = 'pending';
+
+-- name: CompletePaymentRefund :one
+UPDATE payment_refunds
+SET status = $2,
+    last_error = $3,
+    completed_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordPaymentRefundError :exec
+UPDATE payment_refunds
+SET last_error = $2
+WHERE id = $1 AND status = 'pending';
@@ -0,0 +1,10 @@
+-- name: CreatePriceHistory :exec
+INSERT INTO price_history (
+    product_id, price, compare_at_price, sale_price, sale_starts_at, sale_ends_at, changed_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListPriceHistoryByProduct :many
+SELECT * FROM price_history
+WHERE product_id = $1
+ORDER BY created_at ASC;
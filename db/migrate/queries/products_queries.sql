@@ -1,8 +1,8 @@
 -- name: CreateProduct :one
 INSERT INTO products (
-    name, description, image, price, stock_quantity, user_id
+    name, description, image, price, stock_quantity, user_id, low_stock_threshold, sku, slug, tax_class, weight_grams, category
 )
-VALUES ($1, $2, $3, $4, $5, $6)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: GetProductByID :one
@@ -10,10 +10,23 @@ SELECT * FROM products
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetProductBySlug :one
+SELECT * FROM products
+WHERE slug = $1
+LIMIT 1;
+
+-- name: GetProductByIDForUpdate :one
+-- Locks the product row until the surrounding transaction ends
+SELECT * FROM products
+WHERE id = $1
+LIMIT 1
+FOR UPDATE;
+
 -- name: ListProducts :many
 SELECT * FROM products
 WHERE 
     (name ILIKE '%' || $3 || '%' OR description ILIKE '%' || $3 || '%') -- Search logic
+    AND ($4::boolean = FALSE OR stock_quantity > 0) -- Hide sold out products
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
@@ -24,10 +37,75 @@ SET
     description = $3,
     image = $4,
     price = $5,
-    stock_quantity = $6
-WHERE id = $1 AND user_id = $7
+    low_stock_threshold = $7,
+    sku = $8,
+    tax_class = $9,
+    weight_grams = $10,
+    category = $11
+WHERE id = $1 AND user_id = $6
 RETURNING *;
 
+-- name: UpdateProductSlug :exec
+UPDATE products
+SET slug = $2
+WHERE id = $1;
+
+-- name: SlugTaken :one
+-- A slug is taken if another product uses it now or used to (redirect)
+SELECT (
+    EXISTS (SELECT 1 FROM products WHERE products.slug = sqlc.arg(slug) AND products.id <> sqlc.arg(product_id))
+    OR EXISTS (SELECT 1 FROM product_slug_redirects WHERE product_slug_redirects.slug = sqlc.arg(slug) AND product_slug_redirects.product_id <> sqlc.arg(product_id))
+)::boolean AS taken;
+
+-- name: CreateSlugRedirect :exec
+INSERT INTO product_slug_redirects (slug, product_id)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO UPDATE SET product_id = EXCLUDED.product_id;
+
+-- name: DeleteSlugRedirect :exec
+DELETE FROM product_slug_redirects
+WHERE slug = $1;
+
+-- name: GetSlugRedirectTarget :one
+-- Current slug of the product an old slug points to
+SELECT p.slug
+FROM product_slug_redirects r
+JOIN products p ON p.id = r.product_id
+WHERE r.slug = $1
+LIMIT 1;
+
+-- name: UpdateProductPricing :one
+UPDATE products
+SET 
+    compare_at_price = $3,
+    sale_price = $4,
+    sale_starts_at = $5,
+    sale_ends_at = $6
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: AdjustProductStock :one
+-- Stock must only change through the inventory ledger, see inventory_movements
+UPDATE products
+SET stock_quantity = stock_quantity + sqlc.arg(delta)::int
+WHERE id = sqlc.arg(id)
+RETURNING stock_quantity, low_stock_threshold, user_id, name;
+
+-- name: GetProductBySellerAndSku :one
+SELECT * FROM products
+WHERE user_id = $1 AND sku = $2
+LIMIT 1;
+
+-- name: ListProductsBySeller :many
+SELECT * FROM products
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListLowStockProductsBySeller :many
+SELECT * FROM products
+WHERE user_id = $1 AND stock_quantity <= low_stock_threshold
+ORDER BY stock_quantity ASC, name ASC;
+
 -- name: DeleteProduct :one
 -- Used by Sellers/Customers: Deletes only if they own it
 DELETE FROM products
@@ -43,4 +121,5 @@ RETURNING id;
 -- name: CountProducts :one
 SELECT COUNT(*) FROM products
 WHERE 
-    (name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%');
\ No newline at end of file
+    (name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%')
+    AND ($2::boolean = FALSE OR stock_quantity > 0);
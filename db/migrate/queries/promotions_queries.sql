@@ -0,0 +1,84 @@
+-- name: CreateCoupon :one
+INSERT INTO coupons (
+    code, description, discount_type, amount, min_order_value,
+    max_uses, max_uses_per_user, starts_at, ends_at, seller_id, created_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: AddCouponProduct :exec
+INSERT INTO coupon_products (coupon_id, product_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: ListCouponProductIDs :many
+SELECT product_id FROM coupon_products
+WHERE coupon_id = $1;
+
+-- name: AddCouponCategory :exec
+INSERT INTO coupon_categories (coupon_id, category)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: ListCouponCategories :many
+SELECT category FROM coupon_categories
+WHERE coupon_id = $1
+ORDER BY category ASC;
+
+-- name: GetCouponByCode :one
+SELECT * FROM coupons
+WHERE code = $1
+LIMIT 1;
+
+-- name: GetCouponByCodeForUpdate :one
+-- Locks the coupon so concurrent checkouts cannot exceed its usage limits
+SELECT * FROM coupons
+WHERE code = $1
+LIMIT 1
+FOR UPDATE;
+
+-- name: ListCoupons :many
+SELECT * FROM coupons
+ORDER BY created_at DESC;
+
+-- name: ListCouponsByCreator :many
+SELECT * FROM coupons
+WHERE created_by = $1
+ORDER BY created_at DESC;
+
+-- name: DeactivateCoupon :execrows
+UPDATE coupons
+SET active = FALSE
+WHERE id = $1 AND created_by = $2;
+
+-- name: DeactivateCouponByAdmin :execrows
+UPDATE coupons
+SET active = FALSE
+WHERE id = $1;
+
+-- name: CountCouponRedemptions :one
+SELECT COUNT(*) FROM coupon_redemptions
+WHERE coupon_id = $1;
+
+-- name: CountCouponRedemptionsByUser :one
+SELECT COUNT(*) FROM coupon_redemptions
+WHERE coupon_id = $1 AND user_id = $2;
+
+-- name: CreateCouponRedemption :exec
+INSERT INTO coupon_redemptions (coupon_id, user_id, order_id)
+VALUES ($1, $2, $3);
+
+-- name: DeleteCouponRedemptionsByOrder :exec
+-- Gives the coupon uses of a cancelled order back
+DELETE FROM coupon_redemptions
+WHERE order_id = $1;
+
+-- name: CreateOrderDiscount :one
+INSERT INTO order_discounts (order_id, coupon_id, code, description, amount)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListOrderDiscounts :many
+SELECT * FROM order_discounts
+WHERE order_id = $1
+ORDER BY created_at ASC;
@@ -0,0 +1,98 @@
+-- name: CreateReturnRequest :one
+INSERT INTO return_requests (order_id, user_id, reason)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CreateReturnItem :one
+INSERT INTO return_items (return_id, order_item_id, quantity, reason, photos)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetReturnRequestByID :one
+SELECT * FROM return_requests
+WHERE id = $1;
+
+-- name: GetReturnRequestByIDForUpdate :one
+SELECT * FROM return_requests
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListReturnRequests :many
+SELECT * FROM return_requests
+ORDER BY created_at DESC;
+
+-- name: ListReturnRequestsByUser :many
+SELECT * FROM return_requests
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListReturnRequestsBySeller :many
+-- Returns that include at least one of the seller's products
+SELECT rr.* FROM return_requests rr
+WHERE EXISTS (
+    SELECT 1 FROM return_items ri
+    JOIN order_items oi ON oi.id = ri.order_item_id
+    JOIN products p ON p.id = oi.product_id
+    WHERE ri.return_id = rr.id AND p.user_id = $1
+)
+ORDER BY rr.created_at DESC;
+
+-- name: ListReturnRequestsByOrder :many
+SELECT * FROM return_requests
+WHERE order_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListReturnItems :many
+SELECT
+    ri.id, ri.order_item_id, ri.quantity, ri.reason, ri.photos, ri.resolution, ri.inspection_note,
+    oi.product_id, oi.quantity AS ordered_quantity, oi.price, oi.tax_amount, oi.discount_amount,
+    p.name AS product_name, p.user_id AS seller_id
+FROM return_items ri
+JOIN order_items oi ON oi.id = ri.order_item_id
+JOIN products p ON p.id = oi.product_id
+WHERE ri.return_id = $1
+ORDER BY p.name ASC;
+
+-- name: SumReturnedQuantity :one
+-- Units of the order item already in a return that wasn't rejected
+SELECT COALESCE(SUM(ri.quantity), 0)::BIGINT AS returned_quantity FROM return_items ri
+JOIN return_requests rr ON rr.id = ri.return_id
+WHERE ri.order_item_id = $1 AND rr.status <> 'rejected';
+
+-- name: CountReturnItemsNotOwnedBy :one
+SELECT COUNT(*) FROM return_items ri
+JOIN order_items oi ON oi.id = ri.order_item_id
+JOIN products p ON p.id = oi.product_id
+WHERE ri.return_id = $1 AND p.user_id <> $2;
+
+-- name: DecideReturnRequest :one
+UPDATE return_requests
+SET status = $2,
+    decided_by = $3,
+    decision_note = $4,
+    return_label = $5,
+    decided_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: InspectReturnItem :execrows
+UPDATE return_items
+SET resolution = $3,
+    inspection_note = $4
+WHERE id = $1 AND return_id = $2;
+
+-- name: MarkReturnReceived :one
+UPDATE return_requests
+SET status = 'received',
+    received_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkReturnRefunded :one
+UPDATE return_requests
+SET status = 'refunded',
+    refund_amount = $2,
+    payment_id = $3,
+    refunded_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
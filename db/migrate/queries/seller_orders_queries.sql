@@ -0,0 +1,61 @@
+-- name: CreateSellerOrder :one
+INSERT INTO seller_orders (order_id, seller_id, status, subtotal, discount_total, tax_total, total)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListSellerOrdersByOrder :many
+SELECT * FROM seller_orders
+WHERE order_id = $1
+ORDER BY created_at ASC, seller_id ASC;
+
+-- name: ListSellerOrdersByOrderForUpdate :many
+SELECT * FROM seller_orders
+WHERE order_id = $1
+ORDER BY created_at ASC, seller_id ASC
+FOR UPDATE;
+
+-- name: GetSellerOrder :one
+SELECT * FROM seller_orders
+WHERE id = $1;
+
+-- name: GetSellerOrderForUpdate :one
+SELECT * FROM seller_orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetSellerOrderShipping :one
+SELECT o.shipping_address, o.shipping_method
+FROM seller_orders so
+JOIN orders o ON o.id = so.order_id
+WHERE so.id = $1;
+
+-- name: ListSellerOrdersBySeller :many
+-- The seller's sub-orders with where to ship them
+SELECT
+    so.id, so.order_id, so.status, so.subtotal, so.discount_total, so.tax_total, so.total, so.created_at,
+    o.shipping_address, o.shipping_method
+FROM seller_orders so
+JOIN orders o ON o.id = so.order_id
+WHERE so.seller_id = $1
+ORDER BY so.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountSellerOrdersBySeller :one
+SELECT COUNT(*) FROM seller_orders
+WHERE seller_id = $1;
+
+-- name: UpdateSellerOrderStatus :exec
+UPDATE seller_orders
+SET status = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ListSellerOrderItems :many
+SELECT
+    oi.id, oi.product_id, oi.quantity, oi.price, oi.tax_amount, oi.discount_amount,
+    p.name AS product_name, p.sku AS product_sku, p.category AS product_category,
+    COALESCE((SELECT SUM(si.quantity) FROM shipment_items si WHERE si.order_item_id = oi.id), 0)::INT AS shipped_quantity
+FROM order_items oi
+JOIN products p ON p.id = oi.product_id
+WHERE oi.seller_order_id = $1
+ORDER BY p.name ASC;
@@ -0,0 +1,41 @@
+-- name: UpsertSellerProfile :one
+INSERT INTO seller_profiles (user_id, display_name, logo, description, policies)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE SET
+    display_name = EXCLUDED.display_name,
+    logo = EXCLUDED.logo,
+    description = EXCLUDED.description,
+    policies = EXCLUDED.policies,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetSellerProfile :one
+SELECT * FROM seller_profiles
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: ListSellerProducts :many
+SELECT * FROM products
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: GetSellerStats :one
+SELECT
+    (SELECT COUNT(*) FROM products WHERE products.user_id = $1) AS product_count,
+    (
+        SELECT COUNT(*) FROM seller_orders
+        WHERE seller_orders.seller_id = $1 AND seller_orders.status = 'delivered'
+    ) AS fulfilled_orders,
+    (SELECT AVG(rating)::FLOAT8 FROM seller_ratings WHERE seller_ratings.seller_id = $1) AS average_rating,
+    (SELECT COUNT(*) FROM seller_ratings WHERE seller_ratings.seller_id = $1) AS rating_count;
+
+-- name: UpsertSellerRating :one
+-- A customer's rating of a delivered sub-order; rating it again replaces it
+INSERT INTO seller_ratings (seller_order_id, seller_id, user_id, rating, comment)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (seller_order_id) DO UPDATE SET
+    rating = EXCLUDED.rating,
+    comment = EXCLUDED.comment,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
@@ -0,0 +1,76 @@
+-- name: CreateShipment :one
+INSERT INTO shipments (order_id, seller_order_id, seller_id, carrier, tracking_number, tracking_url)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: CreateShipmentItem :exec
+INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+VALUES ($1, $2, $3);
+
+-- name: GetShipment :one
+SELECT * FROM shipments
+WHERE id = $1;
+
+-- name: GetShipmentForUpdate :one
+SELECT * FROM shipments
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetShipmentByTracking :one
+SELECT * FROM shipments
+WHERE carrier = $1 AND tracking_number = $2;
+
+-- name: ListShipmentsByOrder :many
+SELECT * FROM shipments
+WHERE order_id = $1
+ORDER BY shipped_at ASC;
+
+-- name: CountShipmentItemsByOrder :one
+SELECT COUNT(*) FROM shipment_items si
+JOIN shipments s ON s.id = si.shipment_id
+WHERE s.order_id = $1;
+
+-- name: ListShipmentsBySellerOrder :many
+SELECT * FROM shipments
+WHERE seller_order_id = $1
+ORDER BY shipped_at ASC;
+
+-- name: ListShipmentItems :many
+SELECT si.order_item_id, si.quantity, oi.product_id, p.name AS product_name
+FROM shipment_items si
+JOIN order_items oi ON oi.id = si.order_item_id
+JOIN products p ON p.id = oi.product_id
+WHERE si.shipment_id = $1
+ORDER BY p.name ASC;
+
+-- name: ListShippedQuantities :many
+-- Units of each of the sub-order's items already in a shipment
+SELECT si.order_item_id, SUM(si.quantity)::INT AS shipped
+FROM shipment_items si
+JOIN shipments s ON s.id = si.shipment_id
+WHERE s.seller_order_id = $1
+GROUP BY si.order_item_id;
+
+-- name: UpdateShipmentStatus :one
+UPDATE shipments
+SET status = sqlc.arg(status),
+    delivered_at = CASE WHEN sqlc.arg(status) = 'delivered' THEN COALESCE(delivered_at, sqlc.arg(delivered_at)::TIMESTAMP) ELSE delivered_at END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CountUndeliveredShipments :one
+SELECT COUNT(*) FROM shipments
+WHERE seller_order_id = $1 AND status != 'delivered';
+
+-- name: CreateShipmentEvent :one
+-- Returns no row when the carrier sent the event before
+INSERT INTO shipment_events (shipment_id, status, description, location, occurred_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (shipment_id, status, occurred_at) DO NOTHING
+RETURNING *;
+
+-- name: ListShipmentEvents :many
+SELECT * FROM shipment_events
+WHERE shipment_id = $1
+ORDER BY occurred_at ASC, created_at ASC;
@@ -0,0 +1,19 @@
+-- name: CreateShippingMethod :one
+INSERT INTO shipping_methods (code, name, kind, base_cost, per_kg_cost, free_threshold, country)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListShippingMethods :many
+SELECT * FROM shipping_methods
+ORDER BY created_at ASC;
+
+-- name: ListShippingMethodsForCountry :many
+-- Active methods that ship to the country
+SELECT * FROM shipping_methods
+WHERE active AND (country IS NULL OR country = $1)
+ORDER BY created_at ASC;
+
+-- name: DeactivateShippingMethod :execrows
+UPDATE shipping_methods
+SET active = FALSE
+WHERE id = $1;
@@ -0,0 +1,31 @@
+-- name: UpsertTaxRate :one
+INSERT INTO tax_rates (country, state, tax_class, rate, name)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (country, state, tax_class) DO UPDATE SET
+    rate = EXCLUDED.rate,
+    name = EXCLUDED.name
+RETURNING *;
+
+-- name: ListTaxRates :many
+SELECT * FROM tax_rates
+ORDER BY country, state, tax_class;
+
+-- name: DeleteTaxRate :execrows
+DELETE FROM tax_rates
+WHERE id = $1;
+
+-- name: FindTaxRate :one
+-- A state rule wins over the country-wide rule for the same tax class
+SELECT * FROM tax_rates
+WHERE country = $1 AND tax_class = $2 AND (state = $3 OR state = '')
+ORDER BY state DESC
+LIMIT 1;
+
+-- name: CreateOrderTaxLine :exec
+INSERT INTO order_tax_lines (order_id, name, rate, taxable_amount, amount)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListOrderTaxLines :many
+SELECT * FROM order_tax_lines
+WHERE order_id = $1
+ORDER BY created_at ASC;
@@ -29,3 +29,24 @@ RETURNING *;
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2
+WHERE id = $1;
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetPasswordResetTokenForUpdate :one
+-- Only a token that is unused and hasn't expired
+SELECT * FROM password_reset_tokens
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+FOR UPDATE;
+
+-- name: UsePasswordResetTokens :exec
+-- Spends every outstanding token of the user once one has been used
+UPDATE password_reset_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND used_at IS NULL;
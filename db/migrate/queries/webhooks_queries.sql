@@ -0,0 +1,90 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (seller_id, url, secret, events, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: ListWebhookSubscriptions :many
+-- A seller's subscriptions, or the platform-wide ones when seller_id is NULL
+SELECT * FROM webhook_subscriptions
+WHERE seller_id IS NOT DISTINCT FROM $1
+ORDER BY created_at DESC;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = $2, events = $3, active = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: QueueWebhookDeliveries :execrows
+-- One delivery per active subscription in the scope that wants the event
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+SELECT id, sqlc.arg(event_id), sqlc.arg(event_type), sqlc.arg(payload)
+FROM webhook_subscriptions
+WHERE active
+  AND seller_id IS NOT DISTINCT FROM sqlc.arg(seller_id)
+  AND sqlc.arg(event_type)::TEXT = ANY(events);
+
+-- name: QueueWebhookDelivery :one
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: RedeliverWebhookDelivery :one
+-- A fresh delivery of the same event, with its own retries
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+SELECT subscription_id, event_id, event_type, payload
+FROM webhook_deliveries
+WHERE webhook_deliveries.id = $1
+RETURNING *;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases due deliveries by pushing next_attempt_at past the send, so other dispatchers
+-- skip them; a dispatcher that dies mid-send leaves them to be retried after the lease
+UPDATE webhook_deliveries
+SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(lease_seconds)::INT),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RecordWebhookDeliveryResult :exec
+UPDATE webhook_deliveries
+SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: CreateWebhookAttempt :exec
+INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, response_body, duration_ms)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE subscription_id = $1;
+
+-- name: ListWebhookAttempts :many
+SELECT * FROM webhook_attempts
+WHERE delivery_id = $1
+ORDER BY attempt ASC;
@@ -0,0 +1,59 @@
+-- name: CreateWishlist :one
+INSERT INTO wishlists (user_id, name, share_token)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListWishlistsByUser :many
+SELECT w.*, COUNT(wi.product_id) AS item_count
+FROM wishlists w
+LEFT JOIN wishlist_items wi ON wi.wishlist_id = w.id
+WHERE w.user_id = $1
+GROUP BY w.id
+ORDER BY w.created_at ASC;
+
+-- name: GetWishlist :one
+SELECT * FROM wishlists
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetWishlistByShareToken :one
+SELECT * FROM wishlists
+WHERE share_token = $1;
+
+-- name: UpdateWishlist :one
+UPDATE wishlists
+SET name = $3, share_token = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteWishlist :execrows
+DELETE FROM wishlists
+WHERE id = $1 AND user_id = $2;
+
+-- name: AddWishlistItem :exec
+-- Adding a product that is already on the list keeps its original price
+INSERT INTO wishlist_items (wishlist_id, product_id, added_price, last_price)
+VALUES ($1, $2, $3, $3)
+ON CONFLICT (wishlist_id, product_id) DO NOTHING;
+
+-- name: DeleteWishlistItem :execrows
+DELETE FROM wishlist_items
+WHERE wishlist_id = $1 AND product_id = $2;
+
+-- name: ListWishlistItems :many
+SELECT * FROM wishlist_items
+WHERE wishlist_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListWishlistPriceDrops :many
+-- Owners of lists with the product whose last seen price is above the new one, once
+-- per owner with the highest of those prices
+SELECT DISTINCT ON (w.user_id) w.user_id, w.name, wi.last_price
+FROM wishlist_items wi
+JOIN wishlists w ON w.id = wi.wishlist_id
+WHERE wi.product_id = $1 AND wi.last_price > $2
+ORDER BY w.user_id, wi.last_price DESC;
+
+-- name: UpdateWishlistItemPrices :exec
+UPDATE wishlist_items
+SET last_price = $2
+WHERE product_id = $1 AND last_price <> $2;
@@ -7,6 +7,7 @@ package database
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -14,6 +15,619 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+type CouponDiscountType string
+
+const (
+	CouponDiscountTypePercentage   CouponDiscountType = "percentage"
+	CouponDiscountTypeFixed        CouponDiscountType = "fixed"
+	CouponDiscountTypeFreeShipping CouponDiscountType = "free_shipping"
+)
+
+func (e *CouponDiscountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CouponDiscountType(s)
+	case string:
+		*e = CouponDiscountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CouponDiscountType: %T", src)
+	}
+	return nil
+}
+
+type NullCouponDiscountType struct {
+	CouponDiscountType CouponDiscountType
+	Valid              bool // Valid is true if CouponDiscountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCouponDiscountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.CouponDiscountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CouponDiscountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCouponDiscountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CouponDiscountType), nil
+}
+
+type InventoryMovementType string
+
+const (
+	InventoryMovementTypeSale       InventoryMovementType = "sale"
+	InventoryMovementTypeRestock    InventoryMovementType = "restock"
+	InventoryMovementTypeReturn     InventoryMovementType = "return"
+	InventoryMovementTypeAdjustment InventoryMovementType = "adjustment"
+)
+
+func (e *InventoryMovementType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InventoryMovementType(s)
+	case string:
+		*e = InventoryMovementType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InventoryMovementType: %T", src)
+	}
+	return nil
+}
+
+type NullInventoryMovementType struct {
+	InventoryMovementType InventoryMovementType
+	Valid                 bool // Valid is true if InventoryMovementType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInventoryMovementType) Scan(value interface{}) error {
+	if value == nil {
+		ns.InventoryMovementType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InventoryMovementType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInventoryMovementType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InventoryMovementType), nil
+}
+
+type InvoiceKind string
+
+const (
+	InvoiceKindInvoice    InvoiceKind = "invoice"
+	InvoiceKindCreditNote InvoiceKind = "credit_note"
+)
+
+func (e *InvoiceKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InvoiceKind(s)
+	case string:
+		*e = InvoiceKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InvoiceKind: %T", src)
+	}
+	return nil
+}
+
+type NullInvoiceKind struct {
+	InvoiceKind InvoiceKind
+	Valid       bool // Valid is true if InvoiceKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInvoiceKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.InvoiceKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InvoiceKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInvoiceKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InvoiceKind), nil
+}
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusDead      JobStatus = "dead"
+)
+
+func (e *JobStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobStatus(s)
+	case string:
+		*e = JobStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobStatus: %T", src)
+	}
+	return nil
+}
+
+type NullJobStatus struct {
+	JobStatus JobStatus
+	Valid     bool // Valid is true if JobStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobStatus), nil
+}
+
+type LedgerAccount string
+
+const (
+	LedgerAccountPlatformCash    LedgerAccount = "platform_cash"
+	LedgerAccountPlatformRevenue LedgerAccount = "platform_revenue"
+	LedgerAccountSellerPayable   LedgerAccount = "seller_payable"
+)
+
+func (e *LedgerAccount) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerAccount(s)
+	case string:
+		*e = LedgerAccount(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerAccount: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerAccount struct {
+	LedgerAccount LedgerAccount
+	Valid         bool // Valid is true if LedgerAccount is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerAccount) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerAccount, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerAccount.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerAccount) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerAccount), nil
+}
+
+type LedgerTransactionKind string
+
+const (
+	LedgerTransactionKindSale       LedgerTransactionKind = "sale"
+	LedgerTransactionKindCommission LedgerTransactionKind = "commission"
+	LedgerTransactionKindRefund     LedgerTransactionKind = "refund"
+	LedgerTransactionKindPayout     LedgerTransactionKind = "payout"
+)
+
+func (e *LedgerTransactionKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerTransactionKind(s)
+	case string:
+		*e = LedgerTransactionKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerTransactionKind: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerTransactionKind struct {
+	LedgerTransactionKind LedgerTransactionKind
+	Valid                 bool // Valid is true if LedgerTransactionKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerTransactionKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerTransactionKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerTransactionKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerTransactionKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerTransactionKind), nil
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+func (e *OrderStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OrderStatus(s)
+	case string:
+		*e = OrderStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OrderStatus: %T", src)
+	}
+	return nil
+}
+
+type NullOrderStatus struct {
+	OrderStatus OrderStatus
+	Valid       bool // Valid is true if OrderStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOrderStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.OrderStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OrderStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOrderStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OrderStatus), nil
+}
+
+type OutboxEventStatus string
+
+const (
+	OutboxEventStatusPending   OutboxEventStatus = "pending"
+	OutboxEventStatusProcessed OutboxEventStatus = "processed"
+	OutboxEventStatusFailed    OutboxEventStatus = "failed"
+)
+
+func (e *OutboxEventStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OutboxEventStatus(s)
+	case string:
+		*e = OutboxEventStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OutboxEventStatus: %T", src)
+	}
+	return nil
+}
+
+type NullOutboxEventStatus struct {
+	OutboxEventStatus OutboxEventStatus
+	Valid             bool // Valid is true if OutboxEventStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOutboxEventStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.OutboxEventStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OutboxEventStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOutboxEventStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OutboxEventStatus), nil
+}
+
+type PaymentRefundStatus string
+
+const (
+	PaymentRefundStatusPending   PaymentRefundStatus = "pending"
+	PaymentRefundStatusSucceeded PaymentRefundStatus = "succeeded"
+	PaymentRefundStatusFailed    PaymentRefundStatus = "failed"
+)
+
+func (e *PaymentRefundStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PaymentRefundStatus(s)
+	case string:
+		*e = PaymentRefundStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PaymentRefundStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPaymentRefundStatus struct {
+	PaymentRefundStatus PaymentRefundStatus
+	Valid               bool // Valid is true if PaymentRefundStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPaymentRefundStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PaymentRefundStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PaymentRefundStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPaymentRefundStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PaymentRefundStatus), nil
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusRequiresConfirmation PaymentStatus = "requires_confirmation"
+	PaymentStatusAuthorized           PaymentStatus = "authorized"
+	PaymentStatusCaptured             PaymentStatus = "captured"
+	PaymentStatusFailed               PaymentStatus = "failed"
+	PaymentStatusRefunded             PaymentStatus = "refunded"
+	PaymentStatusCancelled            PaymentStatus = "cancelled"
+)
+
+func (e *PaymentStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PaymentStatus(s)
+	case string:
+		*e = PaymentStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PaymentStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPaymentStatus struct {
+	PaymentStatus PaymentStatus
+	Valid         bool // Valid is true if PaymentStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPaymentStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PaymentStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PaymentStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPaymentStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PaymentStatus), nil
+}
+
+type ReturnResolution string
+
+const (
+	ReturnResolutionRestock  ReturnResolution = "restock"
+	ReturnResolutionWriteOff ReturnResolution = "write_off"
+)
+
+func (e *ReturnResolution) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReturnResolution(s)
+	case string:
+		*e = ReturnResolution(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReturnResolution: %T", src)
+	}
+	return nil
+}
+
+type NullReturnResolution struct {
+	ReturnResolution ReturnResolution
+	Valid            bool // Valid is true if ReturnResolution is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReturnResolution) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReturnResolution, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReturnResolution.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReturnResolution) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReturnResolution), nil
+}
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusRefunded  ReturnStatus = "refunded"
+)
+
+func (e *ReturnStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReturnStatus(s)
+	case string:
+		*e = ReturnStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReturnStatus: %T", src)
+	}
+	return nil
+}
+
+type NullReturnStatus struct {
+	ReturnStatus ReturnStatus
+	Valid        bool // Valid is true if ReturnStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReturnStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReturnStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReturnStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReturnStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReturnStatus), nil
+}
+
+type ShipmentStatus string
+
+const (
+	ShipmentStatusShipped        ShipmentStatus = "shipped"
+	ShipmentStatusInTransit      ShipmentStatus = "in_transit"
+	ShipmentStatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentStatusDelivered      ShipmentStatus = "delivered"
+	ShipmentStatusException      ShipmentStatus = "exception"
+)
+
+func (e *ShipmentStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ShipmentStatus(s)
+	case string:
+		*e = ShipmentStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ShipmentStatus: %T", src)
+	}
+	return nil
+}
+
+type NullShipmentStatus struct {
+	ShipmentStatus ShipmentStatus
+	Valid          bool // Valid is true if ShipmentStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullShipmentStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ShipmentStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ShipmentStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullShipmentStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ShipmentStatus), nil
+}
+
+type ShippingRateKind string
+
+const (
+	ShippingRateKindFlat              ShippingRateKind = "flat"
+	ShippingRateKindWeightBased       ShippingRateKind = "weight_based"
+	ShippingRateKindFreeOverThreshold ShippingRateKind = "free_over_threshold"
+)
+
+func (e *ShippingRateKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ShippingRateKind(s)
+	case string:
+		*e = ShippingRateKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ShippingRateKind: %T", src)
+	}
+	return nil
+}
+
+type NullShippingRateKind struct {
+	ShippingRateKind ShippingRateKind
+	Valid            bool // Valid is true if ShippingRateKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullShippingRateKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.ShippingRateKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ShippingRateKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullShippingRateKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ShippingRateKind), nil
+}
+
 type UserRole string
 
 const (
@@ -57,31 +671,544 @@ func (ns NullUserRole) Value() (driver.Value, error) {
 	return string(ns.UserRole), nil
 }
 
-type Order struct {
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus
+	Valid                 bool // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type Address struct {
 	ID         uuid.UUID
 	UserID     uuid.UUID
-	TotalPrice decimal.Decimal
-	Status     string
+	Label      string
+	FullName   string
+	Line1      string
+	Line2      string
+	City       string
+	State      string
+	PostalCode string
+	Country    string
+	Phone      string
+	IsDefault  bool
 	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
-type OrderItem struct {
+type Cart struct {
+	ID         uuid.UUID
+	UserID     uuid.NullUUID
+	GuestToken sql.NullString
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type CartItem struct {
+	ID         uuid.UUID
+	CartID     uuid.UUID
+	ProductID  uuid.UUID
+	Quantity   int32
+	AddedPrice decimal.Decimal
+	CreatedAt  time.Time
+}
+
+type CommissionRate struct {
 	ID        uuid.UUID
-	OrderID   uuid.UUID
+	SellerID  uuid.NullUUID
+	Category  string
+	Rate      decimal.Decimal
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Coupon struct {
+	ID             uuid.UUID
+	Code           string
+	Description    string
+	DiscountType   CouponDiscountType
+	Amount         decimal.Decimal
+	MinOrderValue  decimal.Decimal
+	MaxUses        sql.NullInt32
+	MaxUsesPerUser sql.NullInt32
+	StartsAt       sql.NullTime
+	EndsAt         sql.NullTime
+	SellerID       uuid.NullUUID
+	Active         bool
+	CreatedBy      uuid.UUID
+	CreatedAt      time.Time
+}
+
+type CouponCategory struct {
+	CouponID uuid.UUID
+	Category string
+}
+
+type CouponProduct struct {
+	CouponID  uuid.UUID
 	ProductID uuid.UUID
-	Quantity  int32
-	Price     decimal.Decimal
 }
 
-type Product struct {
+type CouponRedemption struct {
+	ID        uuid.UUID
+	CouponID  uuid.UUID
+	UserID    uuid.UUID
+	OrderID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type IdempotencyKey struct {
+	Scope          string
+	IdempotencyKey string
+	RequestHash    string
+	ResponseStatus sql.NullInt32
+	ContentType    string
+	ResponseBody   []byte
+	CreatedAt      time.Time
+}
+
+type ImportJob struct {
 	ID            uuid.UUID
-	Name          string
+	UserID        uuid.UUID
+	Format        string
+	Status        string
+	TotalRows     int32
+	ProcessedRows int32
+	CreatedCount  int32
+	UpdatedCount  int32
+	ErrorCount    int32
+	Errors        json.RawMessage
+	CreatedAt     time.Time
+	FinishedAt    sql.NullTime
+}
+
+type InventoryMovement struct {
+	ID           uuid.UUID
+	ProductID    uuid.UUID
+	MovementType InventoryMovementType
+	Quantity     int32
+	BalanceAfter int32
+	ActorID      uuid.NullUUID
+	OrderID      uuid.NullUUID
+	Reason       string
+	CreatedAt    time.Time
+}
+
+type Invoice struct {
+	ID                uuid.UUID
+	Kind              InvoiceKind
+	Number            string
+	OrderID           uuid.UUID
+	UserID            uuid.UUID
+	PaymentID         uuid.NullUUID
+	CreditedInvoiceID uuid.NullUUID
+	Issuer            json.RawMessage
+	Buyer             json.RawMessage
+	Lines             json.RawMessage
+	TaxLines          json.RawMessage
+	Subtotal          decimal.Decimal
+	DiscountTotal     decimal.Decimal
+	ShippingCost      decimal.Decimal
+	TaxTotal          decimal.Decimal
+	Total             decimal.Decimal
+	PricesIncludeTax  bool
+	IssuedAt          time.Time
+}
+
+type InvoiceSequence struct {
+	Series     string
+	LastNumber int64
+}
+
+type Job struct {
+	ID          uuid.UUID
+	Kind        string
+	Payload     json.RawMessage
+	Status      JobStatus
+	Attempts    int32
+	MaxAttempts int32
+	RunAt       time.Time
+	LockedUntil sql.NullTime
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+type LedgerEntry struct {
+	ID            uuid.UUID
+	TransactionID uuid.UUID
+	Account       LedgerAccount
+	SellerID      uuid.NullUUID
+	Amount        decimal.Decimal
+	PayoutID      uuid.NullUUID
+	CreatedAt     time.Time
+}
+
+type LedgerTransaction struct {
+	ID            uuid.UUID
+	Kind          LedgerTransactionKind
+	OrderID       uuid.NullUUID
+	SellerOrderID uuid.NullUUID
+	PayoutID      uuid.NullUUID
 	Description   string
-	Image         sql.NullString
-	Price         decimal.Decimal
-	StockQuantity int32
 	CreatedAt     time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      string
+	Title     string
+	Body      string
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID
+	Category  string
+	Channel   string
+	Enabled   bool
+	UpdatedAt time.Time
+}
+
+type Order struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	TotalPrice       decimal.Decimal
+	Status           OrderStatus
+	CreatedAt        time.Time
+	Subtotal         decimal.Decimal
+	DiscountTotal    decimal.Decimal
+	TaxTotal         decimal.Decimal
+	PricesIncludeTax bool
+	TaxCountry       string
+	TaxState         string
+	ShippingAddress  json.RawMessage
+	ShippingMethod   string
+	ShippingCost     decimal.Decimal
+}
+
+type OrderDiscount struct {
+	ID          uuid.UUID
+	OrderID     uuid.UUID
+	CouponID    uuid.NullUUID
+	Code        string
+	Description string
+	Amount      decimal.Decimal
+	CreatedAt   time.Time
+}
+
+type OrderItem struct {
+	ID             uuid.UUID
+	OrderID        uuid.UUID
+	ProductID      uuid.UUID
+	Quantity       int32
+	Price          decimal.Decimal
+	TaxRate        decimal.Decimal
+	TaxAmount      decimal.Decimal
+	DiscountAmount decimal.Decimal
+	SellerOrderID  uuid.NullUUID
+}
+
+type OrderStatusHistory struct {
+	ID            uuid.UUID
+	OrderID       uuid.UUID
+	FromStatus    NullOrderStatus
+	ToStatus      OrderStatus
+	ActorID       uuid.NullUUID
+	Note          string
+	CreatedAt     time.Time
+	SellerOrderID uuid.NullUUID
+}
+
+type OrderTaxLine struct {
+	ID            uuid.UUID
+	OrderID       uuid.UUID
+	Name          string
+	Rate          decimal.Decimal
+	TaxableAmount decimal.Decimal
+	Amount        decimal.Decimal
+	CreatedAt     time.Time
+}
+
+type OutboxEvent struct {
+	ID            uuid.UUID
+	EventType     string
+	AggregateID   uuid.UUID
+	Payload       json.RawMessage
+	Status        OutboxEventStatus
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	ProcessedAt   sql.NullTime
+	Seq           int64
+}
+
+type OutboxReceipt struct {
+	EventID    uuid.UUID
+	Subscriber string
+	HandledAt  time.Time
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type Payment struct {
+	ID             uuid.UUID
+	OrderID        uuid.UUID
+	Provider       string
+	ProviderRef    string
+	Amount         decimal.Decimal
+	RefundedAmount decimal.Decimal
+	Status         PaymentStatus
+	FailureReason  string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type PaymentEvent struct {
+	Provider  string
+	EventID   string
+	EventType string
+	PaymentID uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type PaymentRefund struct {
+	ID           uuid.UUID
+	PaymentID    uuid.UUID
+	Amount       decimal.NullDecimal
+	SellerShares json.RawMessage
+	ReturnID     uuid.NullUUID
+	Status       PaymentRefundStatus
+	LastError    string
+	CreatedAt    time.Time
+	CompletedAt  sql.NullTime
+}
+
+type Payout struct {
+	ID         uuid.UUID
+	BatchID    uuid.UUID
+	SellerID   uuid.UUID
+	Sales      decimal.Decimal
+	Commission decimal.Decimal
+	Refunds    decimal.Decimal
+	Amount     decimal.Decimal
+	CreatedAt  time.Time
+}
+
+type PayoutBatch struct {
+	ID          uuid.UUID
+	CreatedBy   uuid.NullUUID
+	SellerCount int32
+	Total       decimal.Decimal
+	CreatedAt   time.Time
+}
+
+type PriceHistory struct {
+	ID             uuid.UUID
+	ProductID      uuid.UUID
+	Price          decimal.Decimal
+	CompareAtPrice decimal.NullDecimal
+	SalePrice      decimal.NullDecimal
+	SaleStartsAt   sql.NullTime
+	SaleEndsAt     sql.NullTime
+	ChangedBy      uuid.NullUUID
+	CreatedAt      time.Time
+}
+
+type Product struct {
+	ID                uuid.UUID
+	Name              string
+	Description       string
+	Image             sql.NullString
+	Price             decimal.Decimal
+	StockQuantity     int32
+	CreatedAt         time.Time
+	UserID            uuid.UUID
+	LowStockThreshold int32
+	Sku               sql.NullString
+	CompareAtPrice    decimal.NullDecimal
+	SalePrice         decimal.NullDecimal
+	SaleStartsAt      sql.NullTime
+	SaleEndsAt        sql.NullTime
+	Slug              string
+	TaxClass          string
+	WeightGrams       int32
+	Category          string
+}
+
+type ProductSlugRedirect struct {
+	Slug      string
+	ProductID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ReturnItem struct {
+	ID             uuid.UUID
+	ReturnID       uuid.UUID
+	OrderItemID    uuid.UUID
+	Quantity       int32
+	Reason         string
+	Photos         json.RawMessage
+	Resolution     NullReturnResolution
+	InspectionNote string
+}
+
+type ReturnRequest struct {
+	ID           uuid.UUID
+	OrderID      uuid.UUID
+	UserID       uuid.UUID
+	Status       ReturnStatus
+	Reason       string
+	ReturnLabel  string
+	DecidedBy    uuid.NullUUID
+	DecisionNote string
+	RefundAmount decimal.Decimal
+	PaymentID    uuid.NullUUID
+	CreatedAt    time.Time
+	DecidedAt    sql.NullTime
+	ReceivedAt   sql.NullTime
+	RefundedAt   sql.NullTime
+}
+
+type SellerOrder struct {
+	ID            uuid.UUID
+	OrderID       uuid.UUID
+	SellerID      uuid.UUID
+	Status        OrderStatus
+	Subtotal      decimal.Decimal
+	DiscountTotal decimal.Decimal
+	TaxTotal      decimal.Decimal
+	Total         decimal.Decimal
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type SellerProfile struct {
+	UserID      uuid.UUID
+	DisplayName string
+	Logo        sql.NullString
+	Description string
+	Policies    string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type SellerRating struct {
+	SellerOrderID uuid.UUID
+	SellerID      uuid.UUID
 	UserID        uuid.UUID
+	Rating        int16
+	Comment       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Shipment struct {
+	ID             uuid.UUID
+	OrderID        uuid.UUID
+	SellerOrderID  uuid.UUID
+	SellerID       uuid.UUID
+	Carrier        string
+	TrackingNumber string
+	TrackingUrl    string
+	Status         ShipmentStatus
+	ShippedAt      time.Time
+	DeliveredAt    sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type ShipmentEvent struct {
+	ID          uuid.UUID
+	ShipmentID  uuid.UUID
+	Status      ShipmentStatus
+	Description string
+	Location    string
+	OccurredAt  time.Time
+	CreatedAt   time.Time
+}
+
+type ShipmentItem struct {
+	ShipmentID  uuid.UUID
+	OrderItemID uuid.UUID
+	Quantity    int32
+}
+
+type ShippingMethod struct {
+	ID            uuid.UUID
+	Code          string
+	Name          string
+	Kind          ShippingRateKind
+	BaseCost      decimal.Decimal
+	PerKgCost     decimal.Decimal
+	FreeThreshold decimal.Decimal
+	Country       sql.NullString
+	Active        bool
+	CreatedAt     time.Time
+}
+
+type StockSubscription struct {
+	ID         uuid.UUID
+	ProductID  uuid.UUID
+	UserID     uuid.UUID
+	NotifiedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+type TaxRate struct {
+	ID        uuid.UUID
+	Country   string
+	State     string
+	TaxClass  string
+	Rate      decimal.Decimal
+	Name      string
+	CreatedAt time.Time
 }
 
 type User struct {
@@ -94,3 +1221,58 @@ type User struct {
 	CreatedAt time.Time
 	Role      UserRole
 }
+
+type WebhookAttempt struct {
+	ID           uuid.UUID
+	DeliveryID   uuid.UUID
+	Attempt      int32
+	StatusCode   int32
+	Error        string
+	ResponseBody string
+	DurationMs   int32
+	CreatedAt    time.Time
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        json.RawMessage
+	Status         WebhookDeliveryStatus
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode int32
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type WebhookSubscription struct {
+	ID        uuid.UUID
+	SellerID  uuid.NullUUID
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedBy uuid.NullUUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Wishlist struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	ShareToken sql.NullString
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type WishlistItem struct {
+	WishlistID uuid.UUID
+	ProductID  uuid.UUID
+	AddedPrice decimal.Decimal
+	LastPrice  decimal.Decimal
+	CreatedAt  time.Time
+}
@@ -8,25 +8,52 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (user_id, total_price, status)
-VALUES ($1, $2, $3)
-RETURNING id, user_id, total_price, status, created_at
+INSERT INTO orders (
+    user_id, total_price, status, subtotal, discount_total,
+    tax_total, prices_include_tax, tax_country, tax_state,
+    shipping_address, shipping_method, shipping_cost
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, user_id, total_price, status, created_at, subtotal, discount_total, tax_total, prices_include_tax, tax_country, tax_state, shipping_address, shipping_method, shipping_cost
 `
 
 type CreateOrderParams struct {
-	UserID     uuid.UUID
-	TotalPrice decimal.Decimal
-	Status     string
+	UserID           uuid.UUID
+	TotalPrice       decimal.Decimal
+	Status           OrderStatus
+	Subtotal         decimal.Decimal
+	DiscountTotal    decimal.Decimal
+	TaxTotal         decimal.Decimal
+	PricesIncludeTax bool
+	TaxCountry       string
+	TaxState         string
+	ShippingAddress  json.RawMessage
+	ShippingMethod   string
+	ShippingCost     decimal.Decimal
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
-	row := q.db.QueryRowContext(ctx, createOrder, arg.UserID, arg.TotalPrice, arg.Status)
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.UserID,
+		arg.TotalPrice,
+		arg.Status,
+		arg.Subtotal,
+		arg.DiscountTotal,
+		arg.TaxTotal,
+		arg.PricesIncludeTax,
+		arg.TaxCountry,
+		arg.TaxState,
+		arg.ShippingAddress,
+		arg.ShippingMethod,
+		arg.ShippingCost,
+	)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -34,21 +61,34 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.TotalPrice,
 		&i.Status,
 		&i.CreatedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.PricesIncludeTax,
+		&i.TaxCountry,
+		&i.TaxState,
+		&i.ShippingAddress,
+		&i.ShippingMethod,
+		&i.ShippingCost,
 	)
 	return i, err
 }
 
 const createOrderItem = `-- name: CreateOrderItem :one
-INSERT INTO order_items (order_id, product_id, quantity, price)
-VALUES ($1, $2, $3, $4)
-RETURNING id, order_id, product_id, quantity, price
+INSERT INTO order_items (order_id, product_id, quantity, price, tax_rate, tax_amount, discount_amount, seller_order_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, order_id, product_id, quantity, price, tax_rate, tax_amount, discount_amount, seller_order_id
 `
 
 type CreateOrderItemParams struct {
-	OrderID   uuid.UUID
-	ProductID uuid.UUID
-	Quantity  int32
-	Price     decimal.Decimal
+	OrderID        uuid.UUID
+	ProductID      uuid.UUID
+	Quantity       int32
+	Price          decimal.Decimal
+	TaxRate        decimal.Decimal
+	TaxAmount      decimal.Decimal
+	DiscountAmount decimal.Decimal
+	SellerOrderID  uuid.NullUUID
 }
 
 func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error) {
@@ -57,6 +97,10 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 		arg.ProductID,
 		arg.Quantity,
 		arg.Price,
+		arg.TaxRate,
+		arg.TaxAmount,
+		arg.DiscountAmount,
+		arg.SellerOrderID,
 	)
 	var i OrderItem
 	err := row.Scan(
@@ -65,12 +109,42 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 		&i.ProductID,
 		&i.Quantity,
 		&i.Price,
+		&i.TaxRate,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.SellerOrderID,
 	)
 	return i, err
 }
 
+const createOrderStatusHistory = `-- name: CreateOrderStatusHistory :exec
+INSERT INTO order_status_history (order_id, seller_order_id, from_status, to_status, actor_id, note)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateOrderStatusHistoryParams struct {
+	OrderID       uuid.UUID
+	SellerOrderID uuid.NullUUID
+	FromStatus    NullOrderStatus
+	ToStatus      OrderStatus
+	ActorID       uuid.NullUUID
+	Note          string
+}
+
+func (q *Queries) CreateOrderStatusHistory(ctx context.Context, arg CreateOrderStatusHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderStatusHistory,
+		arg.OrderID,
+		arg.SellerOrderID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.ActorID,
+		arg.Note,
+	)
+	return err
+}
+
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, user_id, total_price, status, created_at FROM orders 
+SELECT id, user_id, total_price, status, created_at, subtotal, discount_total, tax_total, prices_include_tax, tax_country, tax_state, shipping_address, shipping_method, shipping_cost FROM orders 
 WHERE id = $1 AND user_id = $2 
 LIMIT 1
 `
@@ -89,13 +163,77 @@ func (q *Queries) GetOrderByID(ctx context.Context, arg GetOrderByIDParams) (Ord
 		&i.TotalPrice,
 		&i.Status,
 		&i.CreatedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.PricesIncludeTax,
+		&i.TaxCountry,
+		&i.TaxState,
+		&i.ShippingAddress,
+		&i.ShippingMethod,
+		&i.ShippingCost,
+	)
+	return i, err
+}
+
+const getOrderByIDForUpdate = `-- name: GetOrderByIDForUpdate :one
+SELECT id, user_id, total_price, status, created_at, subtotal, discount_total, tax_total, prices_include_tax, tax_country, tax_state, shipping_address, shipping_method, shipping_cost FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderByIDForUpdate(ctx context.Context, id uuid.UUID) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderByIDForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TotalPrice,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Subtotal,
+		&i.DiscountTotal,
+		&i.TaxTotal,
+		&i.PricesIncludeTax,
+		&i.TaxCountry,
+		&i.TaxState,
+		&i.ShippingAddress,
+		&i.ShippingMethod,
+		&i.ShippingCost,
+	)
+	return i, err
+}
+
+const getOrderItemByID = `-- name: GetOrderItemByID :one
+SELECT id, order_id, product_id, quantity, price, tax_rate, tax_amount, discount_amount, seller_order_id FROM order_items
+WHERE id = $1 AND order_id = $2
+`
+
+type GetOrderItemByIDParams struct {
+	ID      uuid.UUID
+	OrderID uuid.UUID
+}
+
+func (q *Queries) GetOrderItemByID(ctx context.Context, arg GetOrderItemByIDParams) (OrderItem, error) {
+	row := q.db.QueryRowContext(ctx, getOrderItemByID, arg.ID, arg.OrderID)
+	var i OrderItem
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.ProductID,
+		&i.Quantity,
+		&i.Price,
+		&i.TaxRate,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.SellerOrderID,
 	)
 	return i, err
 }
 
 const getOrderItems = `-- name: GetOrderItems :many
 SELECT 
-    oi.id, oi.product_id, oi.quantity, oi.price,
+    oi.id, oi.product_id, oi.quantity, oi.price, oi.tax_rate, oi.tax_amount, oi.discount_amount,
     p.name as product_name, p.image as product_image
 FROM order_items oi
 JOIN products p ON oi.product_id = p.id
@@ -103,12 +241,15 @@ WHERE oi.order_id = $1
 `
 
 type GetOrderItemsRow struct {
-	ID           uuid.UUID
-	ProductID    uuid.UUID
-	Quantity     int32
-	Price        decimal.Decimal
-	ProductName  string
-	ProductImage sql.NullString
+	ID             uuid.UUID
+	ProductID      uuid.UUID
+	Quantity       int32
+	Price          decimal.Decimal
+	TaxRate        decimal.Decimal
+	TaxAmount      decimal.Decimal
+	DiscountAmount decimal.Decimal
+	ProductName    string
+	ProductImage   sql.NullString
 }
 
 func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]GetOrderItemsRow, error) {
@@ -125,6 +266,9 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]GetOr
 			&i.ProductID,
 			&i.Quantity,
 			&i.Price,
+			&i.TaxRate,
+			&i.TaxAmount,
+			&i.DiscountAmount,
 			&i.ProductName,
 			&i.ProductImage,
 		); err != nil {
@@ -141,8 +285,58 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]GetOr
 	return items, nil
 }
 
+const getOrderUserID = `-- name: GetOrderUserID :one
+SELECT user_id FROM orders
+WHERE id = $1
+`
+
+func (q *Queries) GetOrderUserID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getOrderUserID, id)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const listOrderStatusHistory = `-- name: ListOrderStatusHistory :many
+SELECT id, order_id, from_status, to_status, actor_id, note, created_at, seller_order_id FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListOrderStatusHistory(ctx context.Context, orderID uuid.UUID) ([]OrderStatusHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderStatusHistory, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderStatusHistory
+	for rows.Next() {
+		var i OrderStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ActorID,
+			&i.Note,
+			&i.CreatedAt,
+			&i.SellerOrderID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listOrdersByUser = `-- name: ListOrdersByUser :many
-SELECT id, user_id, total_price, status, created_at FROM orders 
+SELECT id, user_id, total_price, status, created_at, subtotal, discount_total, tax_total, prices_include_tax, tax_country, tax_state, shipping_address, shipping_method, shipping_cost FROM orders 
 WHERE user_id = $1 
 ORDER BY created_at DESC
 `
@@ -162,6 +356,15 @@ func (q *Queries) ListOrdersByUser(ctx context.Context, userID uuid.UUID) ([]Ord
 			&i.TotalPrice,
 			&i.Status,
 			&i.CreatedAt,
+			&i.Subtotal,
+			&i.DiscountTotal,
+			&i.TaxTotal,
+			&i.PricesIncludeTax,
+			&i.TaxCountry,
+			&i.TaxState,
+			&i.ShippingAddress,
+			&i.ShippingMethod,
+			&i.ShippingCost,
 		); err != nil {
 			return nil, err
 		}
@@ -184,7 +387,7 @@ WHERE id = $1
 
 type UpdateOrderStatusParams struct {
 	ID     uuid.UUID
-	Status string
+	Status OrderStatus
 }
 
 func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) error {
@@ -13,14 +13,52 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const adjustProductStock = `-- name: AdjustProductStock :one
+UPDATE products
+SET stock_quantity = stock_quantity + $1::int
+WHERE id = $2
+RETURNING stock_quantity, low_stock_threshold, user_id, name
+`
+
+type AdjustProductStockParams struct {
+	Delta int32
+	ID    uuid.UUID
+}
+
+type AdjustProductStockRow struct {
+	StockQuantity     int32
+	LowStockThreshold int32
+	UserID            uuid.UUID
+	Name              string
+}
+
+// Stock must only change through the inventory ledger, see inventory_movements
+func (q *Queries) AdjustProductStock(ctx context.Context, arg AdjustProductStockParams) (AdjustProductStockRow, error) {
+	row := q.db.QueryRowContext(ctx, adjustProductStock, arg.Delta, arg.ID)
+	var i AdjustProductStockRow
+	err := row.Scan(
+		&i.StockQuantity,
+		&i.LowStockThreshold,
+		&i.UserID,
+		&i.Name,
+	)
+	return i, err
+}
+
 const countProducts = `-- name: CountProducts :one
 SELECT COUNT(*) FROM products
 WHERE 
     (name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%')
+    AND ($2::boolean = FALSE OR stock_quantity > 0)
 `
 
-func (q *Queries) CountProducts(ctx context.Context, dollar_1 sql.NullString) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countProducts, dollar_1)
+type CountProductsParams struct {
+	Column1 sql.NullString
+	Column2 bool
+}
+
+func (q *Queries) CountProducts(ctx context.Context, arg CountProductsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countProducts, arg.Column1, arg.Column2)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -28,19 +66,25 @@ func (q *Queries) CountProducts(ctx context.Context, dollar_1 sql.NullString) (i
 
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
-    name, description, image, price, stock_quantity, user_id
+    name, description, image, price, stock_quantity, user_id, low_stock_threshold, sku, slug, tax_class, weight_grams, category
 )
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, name, description, image, price, stock_quantity, created_at, user_id
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category
 `
 
 type CreateProductParams struct {
-	Name          string
-	Description   string
-	Image         sql.NullString
-	Price         decimal.Decimal
-	StockQuantity int32
-	UserID        uuid.UUID
+	Name              string
+	Description       string
+	Image             sql.NullString
+	Price             decimal.Decimal
+	StockQuantity     int32
+	UserID            uuid.UUID
+	LowStockThreshold int32
+	Sku               sql.NullString
+	Slug              string
+	TaxClass          string
+	WeightGrams       int32
+	Category          string
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
@@ -51,6 +95,12 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Price,
 		arg.StockQuantity,
 		arg.UserID,
+		arg.LowStockThreshold,
+		arg.Sku,
+		arg.Slug,
+		arg.TaxClass,
+		arg.WeightGrams,
+		arg.Category,
 	)
 	var i Product
 	err := row.Scan(
@@ -62,10 +112,36 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.StockQuantity,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
 	)
 	return i, err
 }
 
+const createSlugRedirect = `-- name: CreateSlugRedirect :exec
+INSERT INTO product_slug_redirects (slug, product_id)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO UPDATE SET product_id = EXCLUDED.product_id
+`
+
+type CreateSlugRedirectParams struct {
+	Slug      string
+	ProductID uuid.UUID
+}
+
+func (q *Queries) CreateSlugRedirect(ctx context.Context, arg CreateSlugRedirectParams) error {
+	_, err := q.db.ExecContext(ctx, createSlugRedirect, arg.Slug, arg.ProductID)
+	return err
+}
+
 const deleteProduct = `-- name: DeleteProduct :one
 DELETE FROM products
 WHERE id = $1 AND user_id = $2
@@ -98,8 +174,18 @@ func (q *Queries) DeleteProductByAdmin(ctx context.Context, id uuid.UUID) (uuid.
 	return id, err
 }
 
+const deleteSlugRedirect = `-- name: DeleteSlugRedirect :exec
+DELETE FROM product_slug_redirects
+WHERE slug = $1
+`
+
+func (q *Queries) DeleteSlugRedirect(ctx context.Context, slug string) error {
+	_, err := q.db.ExecContext(ctx, deleteSlugRedirect, slug)
+	return err
+}
+
 const getProductByID = `-- name: GetProductByID :one
-SELECT id, name, description, image, price, stock_quantity, created_at, user_id FROM products
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
 WHERE id = $1
 LIMIT 1
 `
@@ -116,14 +202,191 @@ func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (Product, er
 		&i.StockQuantity,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
+	)
+	return i, err
+}
+
+const getProductByIDForUpdate = `-- name: GetProductByIDForUpdate :one
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
+WHERE id = $1
+LIMIT 1
+FOR UPDATE
+`
+
+// Locks the product row until the surrounding transaction ends
+func (q *Queries) GetProductByIDForUpdate(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByIDForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Image,
+		&i.Price,
+		&i.StockQuantity,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
+	)
+	return i, err
+}
+
+const getProductBySellerAndSku = `-- name: GetProductBySellerAndSku :one
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
+WHERE user_id = $1 AND sku = $2
+LIMIT 1
+`
+
+type GetProductBySellerAndSkuParams struct {
+	UserID uuid.UUID
+	Sku    sql.NullString
+}
+
+func (q *Queries) GetProductBySellerAndSku(ctx context.Context, arg GetProductBySellerAndSkuParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductBySellerAndSku, arg.UserID, arg.Sku)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Image,
+		&i.Price,
+		&i.StockQuantity,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
+	)
+	return i, err
+}
+
+const getProductBySlug = `-- name: GetProductBySlug :one
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
+WHERE slug = $1
+LIMIT 1
+`
+
+func (q *Queries) GetProductBySlug(ctx context.Context, slug string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductBySlug, slug)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Image,
+		&i.Price,
+		&i.StockQuantity,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
 	)
 	return i, err
 }
 
+const getSlugRedirectTarget = `-- name: GetSlugRedirectTarget :one
+SELECT p.slug
+FROM product_slug_redirects r
+JOIN products p ON p.id = r.product_id
+WHERE r.slug = $1
+LIMIT 1
+`
+
+// Current slug of the product an old slug points to
+func (q *Queries) GetSlugRedirectTarget(ctx context.Context, slug string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getSlugRedirectTarget, slug)
+	err := row.Scan(&slug)
+	return slug, err
+}
+
+const listLowStockProductsBySeller = `-- name: ListLowStockProductsBySeller :many
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
+WHERE user_id = $1 AND stock_quantity <= low_stock_threshold
+ORDER BY stock_quantity ASC, name ASC
+`
+
+func (q *Queries) ListLowStockProductsBySeller(ctx context.Context, userID uuid.UUID) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, listLowStockProductsBySeller, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Image,
+			&i.Price,
+			&i.StockQuantity,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.LowStockThreshold,
+			&i.Sku,
+			&i.CompareAtPrice,
+			&i.SalePrice,
+			&i.SaleStartsAt,
+			&i.SaleEndsAt,
+			&i.Slug,
+			&i.TaxClass,
+			&i.WeightGrams,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProducts = `-- name: ListProducts :many
-SELECT id, name, description, image, price, stock_quantity, created_at, user_id FROM products
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
 WHERE 
     (name ILIKE '%' || $3 || '%' OR description ILIKE '%' || $3 || '%') -- Search logic
+    AND ($4::boolean = FALSE OR stock_quantity > 0) -- Hide sold out products
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -132,10 +395,16 @@ type ListProductsParams struct {
 	Limit   int32
 	Offset  int32
 	Column3 sql.NullString
+	Column4 bool
 }
 
 func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, listProducts, arg.Limit, arg.Offset, arg.Column3)
+	rows, err := q.db.QueryContext(ctx, listProducts,
+		arg.Limit,
+		arg.Offset,
+		arg.Column3,
+		arg.Column4,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -152,6 +421,16 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 			&i.StockQuantity,
 			&i.CreatedAt,
 			&i.UserID,
+			&i.LowStockThreshold,
+			&i.Sku,
+			&i.CompareAtPrice,
+			&i.SalePrice,
+			&i.SaleStartsAt,
+			&i.SaleEndsAt,
+			&i.Slug,
+			&i.TaxClass,
+			&i.WeightGrams,
+			&i.Category,
 		); err != nil {
 			return nil, err
 		}
@@ -166,6 +445,74 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 	return items, nil
 }
 
+const listProductsBySeller = `-- name: ListProductsBySeller :many
+SELECT id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category FROM products
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListProductsBySeller(ctx context.Context, userID uuid.UUID) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, listProductsBySeller, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Image,
+			&i.Price,
+			&i.StockQuantity,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.LowStockThreshold,
+			&i.Sku,
+			&i.CompareAtPrice,
+			&i.SalePrice,
+			&i.SaleStartsAt,
+			&i.SaleEndsAt,
+			&i.Slug,
+			&i.TaxClass,
+			&i.WeightGrams,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const slugTaken = `-- name: SlugTaken :one
+SELECT (
+    EXISTS (SELECT 1 FROM products WHERE products.slug = $1 AND products.id <> $2)
+    OR EXISTS (SELECT 1 FROM product_slug_redirects WHERE product_slug_redirects.slug = $1 AND product_slug_redirects.product_id <> $2)
+)::boolean AS taken
+`
+
+type SlugTakenParams struct {
+	Slug      string
+	ProductID uuid.UUID
+}
+
+// A slug is taken if another product uses it now or used to (redirect)
+func (q *Queries) SlugTaken(ctx context.Context, arg SlugTakenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, slugTaken, arg.Slug, arg.ProductID)
+	var taken bool
+	err := row.Scan(&taken)
+	return taken, err
+}
+
 const updateProduct = `-- name: UpdateProduct :one
 UPDATE products
 SET 
@@ -173,19 +520,27 @@ SET
     description = $3,
     image = $4,
     price = $5,
-    stock_quantity = $6
-WHERE id = $1 AND user_id = $7
-RETURNING id, name, description, image, price, stock_quantity, created_at, user_id
+    low_stock_threshold = $7,
+    sku = $8,
+    tax_class = $9,
+    weight_grams = $10,
+    category = $11
+WHERE id = $1 AND user_id = $6
+RETURNING id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category
 `
 
 type UpdateProductParams struct {
-	ID            uuid.UUID
-	Name          string
-	Description   string
-	Image         sql.NullString
-	Price         decimal.Decimal
-	StockQuantity int32
-	UserID        uuid.UUID
+	ID                uuid.UUID
+	Name              string
+	Description       string
+	Image             sql.NullString
+	Price             decimal.Decimal
+	UserID            uuid.UUID
+	LowStockThreshold int32
+	Sku               sql.NullString
+	TaxClass          string
+	WeightGrams       int32
+	Category          string
 }
 
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
@@ -195,8 +550,12 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		arg.Description,
 		arg.Image,
 		arg.Price,
-		arg.StockQuantity,
 		arg.UserID,
+		arg.LowStockThreshold,
+		arg.Sku,
+		arg.TaxClass,
+		arg.WeightGrams,
+		arg.Category,
 	)
 	var i Product
 	err := row.Scan(
@@ -208,6 +567,85 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.StockQuantity,
 		&i.CreatedAt,
 		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
 	)
 	return i, err
 }
+
+const updateProductPricing = `-- name: UpdateProductPricing :one
+UPDATE products
+SET 
+    compare_at_price = $3,
+    sale_price = $4,
+    sale_starts_at = $5,
+    sale_ends_at = $6
+WHERE id = $1 AND user_id = $2
+RETURNING id, name, description, image, price, stock_quantity, created_at, user_id, low_stock_threshold, sku, compare_at_price, sale_price, sale_starts_at, sale_ends_at, slug, tax_class, weight_grams, category
+`
+
+type UpdateProductPricingParams struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	CompareAtPrice decimal.NullDecimal
+	SalePrice      decimal.NullDecimal
+	SaleStartsAt   sql.NullTime
+	SaleEndsAt     sql.NullTime
+}
+
+func (q *Queries) UpdateProductPricing(ctx context.Context, arg UpdateProductPricingParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, updateProductPricing,
+		arg.ID,
+		arg.UserID,
+		arg.CompareAtPrice,
+		arg.SalePrice,
+		arg.SaleStartsAt,
+		arg.SaleEndsAt,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Image,
+		&i.Price,
+		&i.StockQuantity,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.LowStockThreshold,
+		&i.Sku,
+		&i.CompareAtPrice,
+		&i.SalePrice,
+		&i.SaleStartsAt,
+		&i.SaleEndsAt,
+		&i.Slug,
+		&i.TaxClass,
+		&i.WeightGrams,
+		&i.Category,
+	)
+	return i, err
+}
+
+const updateProductSlug = `-- name: UpdateProductSlug :exec
+UPDATE products
+SET slug = $2
+WHERE id = $1
+`
+
+type UpdateProductSlugParams struct {
+	ID   uuid.UUID
+	Slug string
+}
+
+func (q *Queries) UpdateProductSlug(ctx context.Context, arg UpdateProductSlugParams) error {
+	_, err := q.db.ExecContext(ctx, updateProductSlug, arg.ID, arg.Slug)
+	return err
+}
@@ -0,0 +1,214 @@
+package addresses
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func handleListAddresses(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	rows, err := q.ListAddressesByUser(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list addresses"))
+		return
+	}
+
+	resp := make([]mytypes.AddressResponse, 0, len(rows))
+	for _, a := range rows {
+		resp = append(resp, toAddressResponse(a))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+func handleCreateAddress(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	var payload mytypes.AddressPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	a, err := normalizeAddress(payload)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The first address saved becomes the default
+	count, err := q.CountAddressesByUser(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	address, err := q.CreateAddress(r.Context(), database.CreateAddressParams{
+		UserID:     userID,
+		Label:      a.Label,
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		State:      a.State,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+		IsDefault:  count == 0,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, toAddressResponse(address))
+}
+
+func handleUpdateAddress(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "addressID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	var payload mytypes.AddressPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	a, err := normalizeAddress(payload)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	address, err := q.UpdateAddress(r.Context(), database.UpdateAddressParams{
+		ID:         addressID,
+		UserID:     userID,
+		Label:      a.Label,
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		State:      a.State,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("address not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, toAddressResponse(address))
+}
+
+func handleDeleteAddress(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "addressID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	// Orders keep their own copy of the address, so deleting it is always safe
+	rows, err := q.DeleteAddress(r.Context(), database.DeleteAddressParams{
+		ID:     addressID,
+		UserID: userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("address not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "address deleted"})
+}
+
+func handleSetDefaultAddress(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "addressID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	if err := qtx.ClearDefaultAddress(r.Context(), userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rows, err := qtx.SetDefaultAddress(r.Context(), database.SetDefaultAddressParams{
+		ID:     addressID,
+		UserID: userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("address not found"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "default address updated"})
+}
@@ -0,0 +1,48 @@
+package addresses
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+)
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// normalizeAddress trims the payload, upper-cases country and state so they match tax
+// and shipping rules, and checks the required fields.
+func normalizeAddress(payload mytypes.AddressPayload) (mytypes.AddressPayload, error) {
+	a := payload
+	a.Label = strings.TrimSpace(a.Label)
+	a.FullName = strings.TrimSpace(a.FullName)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.State = strings.ToUpper(strings.TrimSpace(a.State))
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Phone = strings.TrimSpace(a.Phone)
+
+	if a.FullName == "" || a.Line1 == "" || a.City == "" || a.PostalCode == "" {
+		return a, fmt.Errorf("full_name, line1, city and postal_code are required")
+	}
+	if !countryPattern.MatchString(a.Country) {
+		return a, fmt.Errorf("country must be a two letter ISO code")
+	}
+	if len(a.Label) > 50 || len(a.State) > 50 || len(a.PostalCode) > 20 || len(a.Phone) > 30 {
+		return a, fmt.Errorf("address field too long")
+	}
+	return a, nil
+}
+
+func toAddressResponse(a database.Address) mytypes.AddressResponse {
+	return mytypes.AddressResponse{
+		ID:            a.ID.String(),
+		Label:         a.Label,
+		PostalAddress: mytypes.NewPostalAddress(a),
+		IsDefault:     a.IsDefault,
+		CreatedAt:     a.CreatedAt,
+	}
+}
@@ -0,0 +1,39 @@
+package addresses
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the address book endpoints. It expects to be mounted behind
+// utils.AuthMiddleware.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		handleListAddresses(w, r, q)
+	})
+
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		handleCreateAddress(w, r, q)
+	})
+
+	r.Put("/{addressID}", func(w http.ResponseWriter, r *http.Request) {
+		handleUpdateAddress(w, r, q)
+	})
+
+	r.Delete("/{addressID}", func(w http.ResponseWriter, r *http.Request) {
+		handleDeleteAddress(w, r, q)
+	})
+
+	// Need Database transaction
+	r.Post("/{addressID}/default", func(w http.ResponseWriter, r *http.Request) {
+		handleSetDefaultAddress(w, r, db)
+	})
+
+	return r
+}
@@ -0,0 +1,61 @@
+package background
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Backoff is an exponential retry schedule: Base after the first failed attempt, then
+// doubling with every further failure, never more than Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay is how long to wait before retrying after the given number of failed attempts.
+func (b Backoff) Delay(attempts int32) time.Duration {
+	delay := b.Base
+	for i := int32(1); i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
+
+// Poller drives a dispatcher that claims work from the database in batches.
+type Poller struct {
+	Name      string // For the log, e.g. "event dispatch"
+	Interval  time.Duration
+	BatchSize int
+	// Poll claims and handles one batch, returning how many items it claimed
+	Poll func(ctx context.Context) (int, error)
+	// Idle runs, when set, each time the backlog is cleared, before waiting
+	Idle func(ctx context.Context)
+}
+
+// Run polls until ctx is cancelled. A full batch means there is probably more, so the
+// next one is claimed straight away; otherwise it waits for the next tick.
+func (p Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := p.Poll(ctx)
+		if err != nil {
+			log.Printf("⚠️  %s failed: %v", p.Name, err)
+		}
+		if n == p.BatchSize && err == nil {
+			continue
+		}
+
+		if p.Idle != nil {
+			p.Idle(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
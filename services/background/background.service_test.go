@@ -0,0 +1,73 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  Backoff
+		attempts int32
+		want     time.Duration
+	}{
+		{"first retry", Backoff{Base: 30 * time.Second, Max: 6 * time.Hour}, 1, 30 * time.Second},
+		{"doubles", Backoff{Base: 30 * time.Second, Max: 6 * time.Hour}, 4, 4 * time.Minute},
+		{"capped", Backoff{Base: 30 * time.Second, Max: 6 * time.Hour}, 20, 6 * time.Hour},
+		{"cap between doublings", Backoff{Base: 15 * time.Second, Max: time.Hour}, 10, time.Hour},
+		{"no attempts yet", Backoff{Base: 10 * time.Second, Max: time.Hour}, 0, 10 * time.Second},
+		{"huge attempt count", Backoff{Base: 10 * time.Second, Max: time.Hour}, 1 << 30, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backoff.Delay(tt.attempts); got != tt.want {
+				t.Errorf("Delay(%d) = %s, want %s", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two full batches, a failed poll and a short batch, then nothing
+	results := []struct {
+		n   int
+		err error
+	}{{3, nil}, {3, nil}, {3, errors.New("boom")}, {1, nil}}
+	var polls, idles []int
+
+	Poller{
+		Name:      "test",
+		Interval:  time.Millisecond,
+		BatchSize: 3,
+		Poll: func(context.Context) (int, error) {
+			i := len(polls)
+			polls = append(polls, i)
+			if i < len(results) {
+				return results[i].n, results[i].err
+			}
+			cancel()
+			return 0, nil
+		},
+		Idle: func(context.Context) {
+			idles = append(idles, len(polls))
+		},
+	}.Run(ctx)
+
+	// The full batches are followed straight away; the failure and the short batch wait
+	want := []int{3, 4, 5}
+	if len(idles) != len(want) {
+		t.Fatalf("idle after polls %v, want %v", idles, want)
+	}
+	for i := range want {
+		if idles[i] != want[i] {
+			t.Fatalf("idle after polls %v, want %v", idles, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package cart
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/orders"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	"github.com/ARCoder181105/ecom/services/tax"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func handleGetCart(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	c, err := resolveCart(r.Context(), q, w, r, false)
+	if err == sql.ErrNoRows {
+		utils.RespondWithJSON(w, http.StatusOK, emptyCartResponse())
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := buildCartResponse(r.Context(), q, c)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+func handleAddCartItem(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	var payload mytypes.CreateOrderPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	productID, err := uuid.Parse(payload.ProductID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	if payload.Quantity <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("quantity must be positive"))
+		return
+	}
+
+	product, err := q.GetProductByID(r.Context(), productID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if int(product.StockQuantity) < payload.Quantity {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("only %d in stock", product.StockQuantity))
+		return
+	}
+
+	c, err := resolveCart(r.Context(), q, w, r, true)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err := q.AddCartItem(r.Context(), database.AddCartItemParams{
+		CartID:     c.ID,
+		ProductID:  product.ID,
+		Quantity:   int32(payload.Quantity),
+		AddedPrice: pricing.EffectivePrice(product, time.Now()),
+	}); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to add item"))
+		return
+	}
+
+	respondWithCart(w, r, q, c)
+}
+
+func handleUpdateCartItem(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	var payload mytypes.UpdateCartItemPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if payload.Quantity < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("quantity cannot be negative"))
+		return
+	}
+
+	c, err := resolveCart(r.Context(), q, w, r, false)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("item not in cart"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if payload.Quantity == 0 {
+		removeCartItem(w, r, q, c, productID)
+		return
+	}
+
+	_, err = q.UpdateCartItemQuantity(r.Context(), database.UpdateCartItemQuantityParams{
+		CartID:    c.ID,
+		ProductID: productID,
+		Quantity:  int32(payload.Quantity),
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("item not in cart"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithCart(w, r, q, c)
+}
+
+func handleRemoveCartItem(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	c, err := resolveCart(r.Context(), q, w, r, false)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("item not in cart"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	removeCartItem(w, r, q, c, productID)
+}
+
+func removeCartItem(w http.ResponseWriter, r *http.Request, q *database.Queries, c database.Cart, productID uuid.UUID) {
+	rows, err := q.DeleteCartItem(r.Context(), database.DeleteCartItemParams{
+		CartID:    c.ID,
+		ProductID: productID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("item not in cart"))
+		return
+	}
+
+	respondWithCart(w, r, q, c)
+}
+
+func respondWithCart(w http.ResponseWriter, r *http.Request, q *database.Queries, c database.Cart) {
+	if err := q.TouchCart(r.Context(), c.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := buildCartResponse(r.Context(), q, c)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleCheckout places an order for everything in the user's cart at current prices
+// and empties the cart, all in one transaction.
+func handleCheckout(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	// The body is optional, it only carries the coupon code, shipping and tax region
+	var payload mytypes.CheckoutPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	addressID, err := orders.ParseAddressID(payload.AddressID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	// Lock the cart until the order is placed and the cart cleared, so a double submit
+	// waits here and then finds the cart empty instead of ordering it twice
+	c, err := qtx.GetCartByUserForUpdate(r.Context(), uuid.NullUUID{UUID: userID, Valid: true})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("cart is empty"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err := qtx.ListCartItems(r.Context(), c.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	orderItems := make([]mytypes.CreateOrderPayload, 0, len(items))
+	for _, item := range items {
+		orderItems = append(orderItems, mytypes.CreateOrderPayload{
+			ProductID: item.ProductID.String(),
+			Quantity:  int(item.Quantity),
+		})
+	}
+
+	order, err := orders.PlaceOrder(r.Context(), qtx, orders.CheckoutRequest{
+		UserID:         userID,
+		Items:          orderItems,
+		CouponCode:     payload.CouponCode,
+		TaxRegion:      tax.Region{Country: payload.Country, State: payload.State},
+		AddressID:      addressID,
+		ShippingMethod: payload.ShippingMethod,
+	})
+	if err != nil {
+		orders.RespondWithOrderError(w, err)
+		return
+	}
+
+	if err := qtx.ClearCart(r.Context(), c.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to clear cart"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":        "Order placed successfully",
+		"order_id":       order.ID,
+		"subtotal":       order.Subtotal,
+		"discount_total": order.DiscountTotal,
+		"shipping_cost":  order.ShippingCost,
+		"tax_total":      order.TaxTotal,
+		"total_price":    order.TotalPrice,
+	})
+}
@@ -0,0 +1,244 @@
+package cart
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Guests are identified by an opaque token in this cookie until they log in
+const cartCookieName = "cartToken"
+
+const cartCookieMaxAge = 3600 * 24 * 30
+
+func newGuestToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func setCartCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cartCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+		MaxAge:   cartCookieMaxAge,
+	})
+}
+
+func clearCartCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cartCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+		MaxAge:   -1,
+	})
+}
+
+// requestUserID returns the logged in user, if any. Cart routes use the optional auth
+// middleware so anonymous requests have no claims.
+func requestUserID(r *http.Request) (uuid.UUID, bool) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// resolveCart finds the cart for the logged in user or the guest cookie. When create is
+// set a missing cart is created (and guests get a new cookie), otherwise sql.ErrNoRows is
+// returned.
+func resolveCart(ctx context.Context, q *database.Queries, w http.ResponseWriter, r *http.Request, create bool) (database.Cart, error) {
+	if userID, ok := requestUserID(r); ok {
+		owner := uuid.NullUUID{UUID: userID, Valid: true}
+		c, err := q.GetCartByUser(ctx, owner)
+		if err == sql.ErrNoRows && create {
+			return q.CreateCart(ctx, database.CreateCartParams{UserID: owner})
+		}
+		return c, err
+	}
+
+	if cookie, err := r.Cookie(cartCookieName); err == nil && cookie.Value != "" {
+		c, err := q.GetCartByGuestToken(ctx, sql.NullString{String: cookie.Value, Valid: true})
+		if err != sql.ErrNoRows || !create {
+			return c, err
+		}
+	} else if !create {
+		return database.Cart{}, sql.ErrNoRows
+	}
+
+	token, err := newGuestToken()
+	if err != nil {
+		return database.Cart{}, err
+	}
+	c, err := q.CreateCart(ctx, database.CreateCartParams{
+		GuestToken: sql.NullString{String: token, Valid: true},
+	})
+	if err != nil {
+		return database.Cart{}, err
+	}
+	setCartCookie(w, token)
+	return c, nil
+}
+
+// AddItem puts units of a product in the user's cart at its current price, creating the
+// cart if they have none. Checking stock is up to the caller.
+func AddItem(ctx context.Context, q *database.Queries, userID uuid.UUID, product database.Product, quantity int32) error {
+	owner := uuid.NullUUID{UUID: userID, Valid: true}
+	c, err := q.GetCartByUser(ctx, owner)
+	if err == sql.ErrNoRows {
+		c, err = q.CreateCart(ctx, database.CreateCartParams{UserID: owner})
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.AddCartItem(ctx, database.AddCartItemParams{
+		CartID:     c.ID,
+		ProductID:  product.ID,
+		Quantity:   quantity,
+		AddedPrice: pricing.EffectivePrice(product, time.Now()),
+	}); err != nil {
+		return err
+	}
+	return q.TouchCart(ctx, c.ID)
+}
+
+// buildCartResponse revalidates every item against the live catalog: the current
+// effective price replaces the price at the time of adding, and quantities are checked
+// against the stock on hand.
+func buildCartResponse(ctx context.Context, q *database.Queries, c database.Cart) (mytypes.CartResponse, error) {
+	items, err := q.ListCartItems(ctx, c.ID)
+	if err != nil {
+		return mytypes.CartResponse{}, err
+	}
+
+	now := time.Now()
+	subtotal := decimal.NewFromInt(0)
+	resp := mytypes.CartResponse{
+		ID:    c.ID.String(),
+		Items: make([]mytypes.CartItemResponse, 0, len(items)),
+		Valid: true,
+	}
+
+	for _, item := range items {
+		product, err := q.GetProductByID(ctx, item.ProductID)
+		if err != nil {
+			return mytypes.CartResponse{}, err
+		}
+
+		unitPrice := pricing.EffectivePrice(product, now)
+		lineTotal := unitPrice.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		insufficient := item.Quantity > product.StockQuantity
+
+		resp.Items = append(resp.Items, mytypes.CartItemResponse{
+			ProductID:         product.ID.String(),
+			Slug:              product.Slug,
+			Name:              product.Name,
+			Image:             product.Image.String,
+			Quantity:          int(item.Quantity),
+			AddedPrice:        item.AddedPrice.String(),
+			UnitPrice:         unitPrice.String(),
+			PriceChanged:      !unitPrice.Equal(item.AddedPrice),
+			AvailableStock:    int(product.StockQuantity),
+			InsufficientStock: insufficient,
+			LineTotal:         lineTotal.String(),
+		})
+
+		resp.ItemCount += int(item.Quantity)
+		subtotal = subtotal.Add(lineTotal)
+		if insufficient {
+			resp.Valid = false
+		}
+	}
+
+	resp.Subtotal = subtotal.String()
+	return resp, nil
+}
+
+func emptyCartResponse() mytypes.CartResponse {
+	return mytypes.CartResponse{
+		Items:    []mytypes.CartItemResponse{},
+		Subtotal: decimal.NewFromInt(0).String(),
+		Valid:    true,
+	}
+}
+
+// MergeGuestCart moves the request's guest cart into the user's cart after they log in
+// or register. A user without a cart simply takes over the guest cart; otherwise the
+// items are merged, summing quantities of products in both. The guest cookie is cleared.
+func MergeGuestCart(ctx context.Context, db *sql.DB, w http.ResponseWriter, r *http.Request, userID uuid.UUID) error {
+	cookie, err := r.Cookie(cartCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	clearCartCookie(w)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	guest, err := qtx.GetCartByGuestToken(ctx, sql.NullString{String: cookie.Value, Valid: true})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	owner := uuid.NullUUID{UUID: userID, Valid: true}
+	userCart, err := qtx.GetCartByUser(ctx, owner)
+	switch {
+	case err == sql.ErrNoRows:
+		if err := qtx.AssignCartToUser(ctx, database.AssignCartToUserParams{
+			ID:     guest.ID,
+			UserID: owner,
+		}); err != nil {
+			return fmt.Errorf("failed to assign guest cart: %w", err)
+		}
+	case err != nil:
+		return err
+	default:
+		if err := qtx.MergeCartItems(ctx, database.MergeCartItemsParams{
+			TargetCartID: userCart.ID,
+			SourceCartID: guest.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to merge guest cart: %w", err)
+		}
+		if err := qtx.DeleteCart(ctx, guest.ID); err != nil {
+			return err
+		}
+		if err := qtx.TouchCart(ctx, userCart.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
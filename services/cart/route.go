@@ -0,0 +1,49 @@
+package cart
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the shopping cart endpoints. Guests get a cart tied to a cookie,
+// checkout requires logging in.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	// guest or logged in
+	r.Group(func(guest chi.Router) {
+		guest.Use(utils.OptionalAuthMiddleware)
+
+		guest.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			handleGetCart(w, r, q)
+		})
+
+		guest.Post("/items", func(w http.ResponseWriter, r *http.Request) {
+			handleAddCartItem(w, r, q)
+		})
+
+		guest.Put("/items/{productID}", func(w http.ResponseWriter, r *http.Request) {
+			handleUpdateCartItem(w, r, q)
+		})
+
+		guest.Delete("/items/{productID}", func(w http.ResponseWriter, r *http.Request) {
+			handleRemoveCartItem(w, r, q)
+		})
+	})
+
+	// protected routes
+	r.Group(func(pr chi.Router) {
+		pr.Use(utils.AuthMiddleware)
+
+		pr.Post("/checkout", func(w http.ResponseWriter, r *http.Request) {
+			handleCheckout(w, r, db)
+		})
+	})
+
+	return r
+}
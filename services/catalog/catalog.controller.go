@@ -0,0 +1,261 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxImportSize caps uploaded catalog files at 10MB, the same as image uploads.
+const maxImportSize = 10 << 20
+
+func handleImportCatalog(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "seller" && claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("only sellers can import products"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	format, rows, err := readUpload(w, r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		utils.RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("file must be at most %d MB", maxImportSize>>20))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+	qtx := database.New(tx)
+
+	job, err := qtx.CreateImportJob(r.Context(), database.CreateImportJobParams{
+		UserID:    userID,
+		Format:    format,
+		TotalRows: int32(len(rows)),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to create import job"))
+		return
+	}
+
+	if _, err := importJob.Enqueue(r.Context(), qtx, importPayload{
+		ImportJobID: job.ID,
+		UserID:      userID,
+		Rows:        rows,
+	}); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to queue import"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, toImportJobResponse(job))
+}
+
+// readUpload parses the uploaded file, either a multipart upload in the "file" field or
+// the raw file as the body. The format is taken from ?format, then the file name or the
+// content type. A file over maxImportSize fails with a *http.MaxBytesError.
+func readUpload(w http.ResponseWriter, r *http.Request) (string, []importRow, error) {
+	// The limit covers both kinds of upload, so it goes on the request body before
+	// anything reads it
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	var body io.Reader = r.Body
+	format := r.URL.Query().Get("format")
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxImportSize); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return "", nil, err
+			}
+			return "", nil, fmt.Errorf("invalid multipart form")
+		}
+
+		file, handler, err := r.FormFile("file")
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid file")
+		}
+		defer file.Close()
+
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(handler.Filename)), ".")
+		}
+	} else if format == "" {
+		if strings.Contains(r.Header.Get("Content-Type"), "json") {
+			format = "json"
+		} else {
+			format = "csv"
+		}
+	}
+
+	var rows []importRow
+	var err error
+	switch format {
+	case "csv":
+		rows, err = parseCSVRows(body)
+	case "json":
+		rows, err = parseJSONRows(body)
+	default:
+		return "", nil, fmt.Errorf("format must be csv or json")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(rows) == 0 {
+		return "", nil, fmt.Errorf("file has no products")
+	}
+	return format, rows, nil
+}
+
+func handleGetImportJob(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid job id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	job, err := q.GetImportJob(r.Context(), database.GetImportJobParams{
+		ID:     jobID,
+		UserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("import job not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, toImportJobResponse(job))
+}
+
+func handleExportCatalog(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "seller" && claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("only sellers can export products"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("format must be csv or json"))
+		return
+	}
+
+	products, err := q.ListProductsBySeller(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list products"))
+		return
+	}
+
+	// Exported rows use the import shape so the file can be edited and uploaded again
+	payloads := []mytypes.CreateProductPayload{}
+	for _, p := range products {
+		payloads = append(payloads, mytypes.CreateProductPayload{
+			SKU:               p.Sku.String,
+			Name:              p.Name,
+			Description:       p.Description,
+			Image:             p.Image.String,
+			Price:             p.Price.String(),
+			StockQuantity:     int(p.StockQuantity),
+			LowStockThreshold: int(p.LowStockThreshold),
+			TaxClass:          p.TaxClass,
+			WeightGrams:       int(p.WeightGrams),
+			Category:          p.Category,
+		})
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=catalog.%s", format))
+
+	if format == "json" {
+		utils.RespondWithJSON(w, http.StatusOK, payloads)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(csvColumns)
+	for _, p := range payloads {
+		writer.Write([]string{
+			p.SKU,
+			p.Name,
+			p.Description,
+			p.Image,
+			p.Price,
+			strconv.Itoa(p.StockQuantity),
+			strconv.Itoa(p.LowStockThreshold),
+			p.TaxClass,
+			strconv.Itoa(p.WeightGrams),
+			p.Category,
+		})
+	}
+	writer.Flush()
+}
+
+func toImportJobResponse(job database.ImportJob) mytypes.ImportJobResponse {
+	resp := mytypes.ImportJobResponse{
+		ID:            job.ID.String(),
+		Format:        job.Format,
+		Status:        job.Status,
+		TotalRows:     int(job.TotalRows),
+		ProcessedRows: int(job.ProcessedRows),
+		CreatedCount:  int(job.CreatedCount),
+		UpdatedCount:  int(job.UpdatedCount),
+		ErrorCount:    int(job.ErrorCount),
+		Errors:        job.Errors,
+		CreatedAt:     job.CreatedAt,
+	}
+	if job.FinishedAt.Valid {
+		resp.FinishedAt = &job.FinishedAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,387 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/inventory"
+	"github.com/ARCoder181105/ecom/services/jobs"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	"github.com/ARCoder181105/ecom/services/products"
+	"github.com/ARCoder181105/ecom/services/tax"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Import job statuses stored in import_jobs.status
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// csvColumns is the header shared by imports and exports so an export can be re-imported.
+var csvColumns = []string{"sku", "name", "description", "image", "price", "stock_quantity", "low_stock_threshold", "tax_class", "weight_grams", "category"}
+
+// importRow is one product from an uploaded file. Err holds a problem found while
+// parsing the row, which is reported instead of applying it.
+type importRow struct {
+	Line    int                          `json:"line"`
+	Payload mytypes.CreateProductPayload `json:"payload"`
+	Err     string                       `json:"error,omitempty"`
+}
+
+// importPayload is the job that applies an accepted upload.
+type importPayload struct {
+	ImportJobID uuid.UUID   `json:"import_job_id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	Rows        []importRow `json:"rows"`
+}
+
+// importJob runs uploads on the job queue, so an import a restart interrupts is picked up
+// again. Rows are upserted by SKU, so running one twice gives the same catalog.
+var importJob = jobs.Type[importPayload]{Kind: "catalog.import"}
+
+// RegisterJobs adds the catalog's job handlers to the pool.
+func RegisterJobs(pool *jobs.Pool) {
+	jobs.Handle(pool, importJob, runImport)
+}
+
+func parseJSONRows(r io.Reader) ([]importRow, error) {
+	var payloads []mytypes.CreateProductPayload
+	if err := json.NewDecoder(r).Decode(&payloads); err != nil {
+		if isTooLarge(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid json: expected an array of products")
+	}
+
+	rows := make([]importRow, 0, len(payloads))
+	for i, payload := range payloads {
+		rows = append(rows, importRow{Line: i + 1, Payload: payload})
+	}
+	return rows, nil
+}
+
+func parseCSVRows(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if isTooLarge(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid csv: missing header row")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"sku", "name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("invalid csv: missing %s column", required)
+		}
+	}
+
+	var rows []importRow
+	// Line numbers count the header so they match what the seller sees in a spreadsheet
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if isTooLarge(err) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("invalid csv on line %d: %v", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := importRow{Line: line, Payload: mytypes.CreateProductPayload{
+			SKU:         field("sku"),
+			Name:        field("name"),
+			Description: field("description"),
+			Image:       field("image"),
+			Price:       field("price"),
+			TaxClass:    field("tax_class"),
+			Category:    field("category"),
+		}}
+
+		if row.Payload.StockQuantity, err = parseOptionalInt(field("stock_quantity")); err != nil {
+			row.Err = "invalid stock_quantity"
+		} else if row.Payload.LowStockThreshold, err = parseOptionalInt(field("low_stock_threshold")); err != nil {
+			row.Err = "invalid low_stock_threshold"
+		} else if row.Payload.WeightGrams, err = parseOptionalInt(field("weight_grams")); err != nil {
+			row.Err = "invalid weight_grams"
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// isTooLarge reports whether reading an upload stopped at maxImportSize.
+func isTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+func parseOptionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// validateRow checks the fields a product needs and returns the parsed price.
+func validateRow(payload mytypes.CreateProductPayload) (decimal.Decimal, error) {
+	if payload.SKU == "" {
+		return decimal.Decimal{}, fmt.Errorf("sku is required")
+	}
+	if len(payload.SKU) > 100 {
+		return decimal.Decimal{}, fmt.Errorf("sku must be at most 100 characters")
+	}
+	if payload.Name == "" {
+		return decimal.Decimal{}, fmt.Errorf("name is required")
+	}
+
+	price, err := decimal.NewFromString(payload.Price)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("invalid price format")
+	}
+	if price.IsNegative() {
+		return decimal.Decimal{}, fmt.Errorf("price cannot be negative")
+	}
+
+	if payload.StockQuantity < 0 {
+		return decimal.Decimal{}, fmt.Errorf("stock_quantity cannot be negative")
+	}
+	if payload.LowStockThreshold < 0 {
+		return decimal.Decimal{}, fmt.Errorf("low_stock_threshold cannot be negative")
+	}
+	if payload.WeightGrams < 0 {
+		return decimal.Decimal{}, fmt.Errorf("weight_grams cannot be negative")
+	}
+	if _, err := tax.ParseClass(payload.TaxClass); err != nil {
+		return decimal.Decimal{}, err
+	}
+	if _, err := products.ParseCategory(payload.Category); err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	return price, nil
+}
+
+// runImport applies every valid row and records progress and row-level errors on the
+// import job. Only failing to finish is worth a retry; anything else fails the import.
+func runImport(ctx context.Context, db *sql.DB, p importPayload) error {
+	jobID, userID := p.ImportJobID, p.UserID
+	q := database.New(db)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("❌ import job %s panicked: %v", jobID, rec)
+			q.FinishImportJob(ctx, database.FinishImportJobParams{ID: jobID, Status: JobStatusFailed})
+		}
+	}()
+
+	upsert := func(payload mytypes.CreateProductPayload, price decimal.Decimal) (bool, error) {
+		return upsertProduct(ctx, db, userID, payload, price)
+	}
+	report := func(progress importProgress) error {
+		errorsJSON, _ := json.Marshal(progress.Errors)
+		return q.UpdateImportJobProgress(ctx, database.UpdateImportJobProgressParams{
+			ID:            jobID,
+			ProcessedRows: progress.Processed,
+			CreatedCount:  progress.Created,
+			UpdatedCount:  progress.Updated,
+			ErrorCount:    int32(len(progress.Errors)),
+			Errors:        errorsJSON,
+		})
+	}
+	if err := applyRows(p.Rows, upsert, report); err != nil {
+		log.Printf("❌ import job %s: failed to save progress: %v", jobID, err)
+		q.FinishImportJob(ctx, database.FinishImportJobParams{ID: jobID, Status: JobStatusFailed})
+		return nil
+	}
+
+	if err := q.FinishImportJob(ctx, database.FinishImportJobParams{ID: jobID, Status: JobStatusCompleted}); err != nil {
+		return fmt.Errorf("import job %s: failed to finish: %w", jobID, err)
+	}
+	return nil
+}
+
+// importProgress is how far an import has got, saved on the import job after each row.
+type importProgress struct {
+	Processed, Created, Updated int32
+	Errors                      []mytypes.ImportRowError
+}
+
+// applyRows upserts every valid row by SKU and reports progress after each one. Rows
+// that don't parse or validate, reuse a SKU from earlier in the file or fail to save
+// are reported as row errors. Only an error from report stops the import.
+func applyRows(rows []importRow, upsert func(mytypes.CreateProductPayload, decimal.Decimal) (bool, error), report func(importProgress) error) error {
+	progress := importProgress{Errors: []mytypes.ImportRowError{}}
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		var err error
+		if row.Err != "" {
+			err = errors.New(row.Err)
+		}
+		var price decimal.Decimal
+		if err == nil {
+			price, err = validateRow(row.Payload)
+		}
+		if err == nil {
+			if firstLine, dup := seen[row.Payload.SKU]; dup {
+				err = fmt.Errorf("duplicate sku, first used on row %d", firstLine)
+			}
+		}
+
+		if err == nil {
+			seen[row.Payload.SKU] = row.Line
+
+			var isNew bool
+			isNew, err = upsert(row.Payload, price)
+			if err == nil && isNew {
+				progress.Created++
+			} else if err == nil {
+				progress.Updated++
+			}
+		}
+
+		if err != nil {
+			progress.Errors = append(progress.Errors, mytypes.ImportRowError{Row: row.Line, SKU: row.Payload.SKU, Error: err.Error()})
+		}
+
+		progress.Processed++
+		if err := report(progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertProduct creates the product or updates the one with the same SKU in the seller's
+// catalog. Stock differences go through the inventory ledger.
+func upsertProduct(ctx context.Context, db *sql.DB, userID uuid.UUID, payload mytypes.CreateProductPayload, price decimal.Decimal) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+	sku := sql.NullString{String: payload.SKU, Valid: true}
+	image := sql.NullString{String: payload.Image, Valid: payload.Image != ""}
+	taxClass, _ := tax.ParseClass(payload.TaxClass) // checked by validateRow
+	category, _ := products.ParseCategory(payload.Category)
+
+	existing, err := qtx.GetProductBySellerAndSku(ctx, database.GetProductBySellerAndSkuParams{
+		UserID: userID,
+		Sku:    sku,
+	})
+	isNew := err == sql.ErrNoRows
+	if err != nil && !isNew {
+		return false, fmt.Errorf("failed to look up sku")
+	}
+
+	var product database.Product
+	if isNew {
+		slug, err := products.UniqueSlug(ctx, qtx, payload.Name, uuid.Nil)
+		if err != nil {
+			return false, fmt.Errorf("failed to generate slug")
+		}
+
+		product, err = qtx.CreateProduct(ctx, database.CreateProductParams{
+			Name:              payload.Name,
+			Description:       payload.Description,
+			Image:             image,
+			Price:             price,
+			StockQuantity:     0,
+			UserID:            userID,
+			LowStockThreshold: int32(payload.LowStockThreshold),
+			Sku:               sku,
+			Slug:              slug,
+			TaxClass:          taxClass,
+			WeightGrams:       int32(payload.WeightGrams),
+			Category:          category,
+		})
+	} else {
+		product, err = qtx.UpdateProduct(ctx, database.UpdateProductParams{
+			ID:                existing.ID,
+			Name:              payload.Name,
+			Description:       payload.Description,
+			Image:             image,
+			Price:             price,
+			UserID:            userID,
+			LowStockThreshold: int32(payload.LowStockThreshold),
+			Sku:               sku,
+			TaxClass:          taxClass,
+			WeightGrams:       int32(payload.WeightGrams),
+			Category:          category,
+		})
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save product")
+	}
+
+	if !isNew && existing.Name != product.Name {
+		if _, err := products.SyncSlug(ctx, qtx, product); err != nil {
+			return false, fmt.Errorf("failed to update slug")
+		}
+	}
+
+	if isNew || !existing.Price.Equal(product.Price) {
+		if err := pricing.RecordPriceChange(ctx, qtx, product, userID); err != nil {
+			return false, fmt.Errorf("failed to record price history")
+		}
+	}
+
+	if delta := int32(payload.StockQuantity) - product.StockQuantity; delta != 0 {
+		movementType := database.InventoryMovementTypeAdjustment
+		if isNew {
+			movementType = database.InventoryMovementTypeRestock
+		}
+
+		if _, err := inventory.RecordMovement(ctx, qtx, inventory.Movement{
+			ProductID: product.ID,
+			Type:      movementType,
+			Quantity:  delta,
+			ActorID:   uuid.NullUUID{UUID: userID, Valid: true},
+			Reason:    "bulk import",
+		}); err != nil {
+			return false, fmt.Errorf("failed to record stock")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction")
+	}
+
+	return isNew, nil
+}
@@ -0,0 +1,390 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseCSVRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []importRow
+		wantErr string
+	}{
+		{
+			name: "every column",
+			csv:  "sku,name,description,image,price,stock_quantity,low_stock_threshold,tax_class,weight_grams,category\nL-1,Lamp,Bright,https://img/l.png,19.99,5,2,reduced,800,lighting\n",
+			want: []importRow{{Line: 2, Payload: mytypes.CreateProductPayload{
+				SKU: "L-1", Name: "Lamp", Description: "Bright", Image: "https://img/l.png", Price: "19.99",
+				StockQuantity: 5, LowStockThreshold: 2, TaxClass: "reduced", WeightGrams: 800, Category: "lighting",
+			}}},
+		},
+		{
+			name: "columns in any order and case, optional ones left out",
+			csv:  "Price, SKU ,NAME\n 4.50 , M-1 , Mug \n",
+			want: []importRow{{Line: 2, Payload: mytypes.CreateProductPayload{SKU: "M-1", Name: "Mug", Price: "4.50"}}},
+		},
+		{
+			name: "quoted fields with commas and new lines",
+			csv:  "sku,name,description,price\nS-1,\"Sofa, large\",\"Two\nlines\",300\nS-2,Stool,,20\n",
+			want: []importRow{
+				{Line: 2, Payload: mytypes.CreateProductPayload{SKU: "S-1", Name: "Sofa, large", Description: "Two\nlines", Price: "300"}},
+				{Line: 3, Payload: mytypes.CreateProductPayload{SKU: "S-2", Name: "Stool", Price: "20"}},
+			},
+		},
+		{
+			name: "short rows leave the missing fields empty",
+			csv:  "sku,name,price,stock_quantity\nA-1,Apple\n",
+			want: []importRow{{Line: 2, Payload: mytypes.CreateProductPayload{SKU: "A-1", Name: "Apple"}}},
+		},
+		{
+			name: "bad numbers are kept as row errors",
+			csv:  "sku,name,price,stock_quantity,low_stock_threshold,weight_grams\nA,a,1,many,,\nB,b,1,1,low,\nC,c,1,,,1.5\nD,d,1,3,1,200\n",
+			want: []importRow{
+				{Line: 2, Payload: mytypes.CreateProductPayload{SKU: "A", Name: "a", Price: "1"}, Err: "invalid stock_quantity"},
+				{Line: 3, Payload: mytypes.CreateProductPayload{SKU: "B", Name: "b", Price: "1", StockQuantity: 1}, Err: "invalid low_stock_threshold"},
+				{Line: 4, Payload: mytypes.CreateProductPayload{SKU: "C", Name: "c", Price: "1"}, Err: "invalid weight_grams"},
+				{Line: 5, Payload: mytypes.CreateProductPayload{SKU: "D", Name: "d", Price: "1", StockQuantity: 3, LowStockThreshold: 1, WeightGrams: 200}},
+			},
+		},
+		{
+			name: "header only",
+			csv:  "sku,name,price\n",
+			want: nil,
+		},
+		{name: "empty file", csv: "", wantErr: "invalid csv: missing header row"},
+		{name: "missing sku column", csv: "name,price\nLamp,1\n", wantErr: "invalid csv: missing sku column"},
+		{name: "missing price column", csv: "sku,name\nL-1,Lamp\n", wantErr: "invalid csv: missing price column"},
+		{name: "broken quoting", csv: "sku,name,price\nL-1,\"Lamp,1\nL-2,Lamp 2,2\n", wantErr: "invalid csv on line 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCSVRows(strings.NewReader(tt.csv))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("parseCSVRows() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCSVRows() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCSVRows() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONRows(t *testing.T) {
+	got, err := parseJSONRows(strings.NewReader(`[{"sku":"L-1","name":"Lamp","price":"19.99","stock_quantity":5},{"sku":"M-1","name":"Mug","price":"4"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []importRow{
+		{Line: 1, Payload: mytypes.CreateProductPayload{SKU: "L-1", Name: "Lamp", Price: "19.99", StockQuantity: 5}},
+		{Line: 2, Payload: mytypes.CreateProductPayload{SKU: "M-1", Name: "Mug", Price: "4"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONRows() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	for _, body := range []string{`{"sku":"L-1"}`, `[{"sku":1}]`, `not json`, ``} {
+		if _, err := parseJSONRows(strings.NewReader(body)); err == nil || err.Error() != "invalid json: expected an array of products" {
+			t.Errorf("parseJSONRows(%q) error = %v", body, err)
+		}
+	}
+}
+
+func TestValidateRow(t *testing.T) {
+	valid := mytypes.CreateProductPayload{SKU: "L-1", Name: "Lamp", Price: "19.99"}
+	with := func(edit func(*mytypes.CreateProductPayload)) mytypes.CreateProductPayload {
+		p := valid
+		edit(&p)
+		return p
+	}
+
+	tests := []struct {
+		name      string
+		payload   mytypes.CreateProductPayload
+		wantPrice string
+		wantErr   string
+	}{
+		{name: "valid", payload: valid, wantPrice: "19.99"},
+		{name: "free", payload: with(func(p *mytypes.CreateProductPayload) { p.Price = "0" }), wantPrice: "0"},
+		{name: "no sku", payload: with(func(p *mytypes.CreateProductPayload) { p.SKU = "" }), wantErr: "sku is required"},
+		{name: "long sku", payload: with(func(p *mytypes.CreateProductPayload) { p.SKU = strings.Repeat("s", 101) }), wantErr: "sku must be at most 100 characters"},
+		{name: "no name", payload: with(func(p *mytypes.CreateProductPayload) { p.Name = "" }), wantErr: "name is required"},
+		{name: "no price", payload: with(func(p *mytypes.CreateProductPayload) { p.Price = "" }), wantErr: "invalid price format"},
+		{name: "price with currency", payload: with(func(p *mytypes.CreateProductPayload) { p.Price = "$5" }), wantErr: "invalid price format"},
+		{name: "negative price", payload: with(func(p *mytypes.CreateProductPayload) { p.Price = "-1" }), wantErr: "price cannot be negative"},
+		{name: "negative stock", payload: with(func(p *mytypes.CreateProductPayload) { p.StockQuantity = -1 }), wantErr: "stock_quantity cannot be negative"},
+		{name: "negative threshold", payload: with(func(p *mytypes.CreateProductPayload) { p.LowStockThreshold = -1 }), wantErr: "low_stock_threshold cannot be negative"},
+		{name: "negative weight", payload: with(func(p *mytypes.CreateProductPayload) { p.WeightGrams = -1 }), wantErr: "weight_grams cannot be negative"},
+		{name: "bad tax class", payload: with(func(p *mytypes.CreateProductPayload) { p.TaxClass = "Reduced!" }), wantErr: "tax_class may only contain lowercase letters, digits and underscores"},
+		{name: "bad category", payload: with(func(p *mytypes.CreateProductPayload) { p.Category = "Home & Garden" }), wantErr: "category may only contain lowercase letters, digits, dashes and underscores"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := validateRow(tt.payload)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("validateRow() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateRow() error = %v", err)
+			}
+			if !price.Equal(decimal.RequireFromString(tt.wantPrice)) {
+				t.Errorf("price = %s, want %s", price, tt.wantPrice)
+			}
+		})
+	}
+}
+
+// memoryCatalog upserts products by SKU in memory, like upsertProduct does in the
+// seller's catalog.
+type memoryCatalog struct {
+	products map[string]mytypes.CreateProductPayload
+	fail     map[string]bool // SKUs whose save fails
+}
+
+func (c *memoryCatalog) upsert(payload mytypes.CreateProductPayload, price decimal.Decimal) (bool, error) {
+	if c.fail[payload.SKU] {
+		return false, errors.New("failed to save product")
+	}
+	_, exists := c.products[payload.SKU]
+	c.products[payload.SKU] = payload
+	return !exists, nil
+}
+
+func TestApplyRows(t *testing.T) {
+	row := func(line int, sku, name, price string) importRow {
+		return importRow{Line: line, Payload: mytypes.CreateProductPayload{SKU: sku, Name: name, Price: price}}
+	}
+
+	tests := []struct {
+		name       string
+		existing   []string // SKUs already in the catalog
+		fail       []string
+		rows       []importRow
+		want       importProgress
+		wantNames  map[string]string // Name of each SKU in the catalog afterwards
+		wantReport int
+	}{
+		{
+			name:      "new and existing skus",
+			existing:  []string{"L-1"},
+			rows:      []importRow{row(2, "L-1", "Lamp v2", "20"), row(3, "M-1", "Mug", "4")},
+			want:      importProgress{Processed: 2, Created: 1, Updated: 1, Errors: []mytypes.ImportRowError{}},
+			wantNames: map[string]string{"L-1": "Lamp v2", "M-1": "Mug"},
+		},
+		{
+			name: "duplicate sku keeps the first row",
+			rows: []importRow{row(2, "L-1", "Lamp", "20"), row(3, "M-1", "Mug", "4"), row(4, "L-1", "Other lamp", "25")},
+			want: importProgress{Processed: 3, Created: 2, Errors: []mytypes.ImportRowError{
+				{Row: 4, SKU: "L-1", Error: "duplicate sku, first used on row 2"},
+			}},
+			wantNames: map[string]string{"L-1": "Lamp", "M-1": "Mug"},
+		},
+		{
+			name: "an invalid row doesn't claim its sku",
+			rows: []importRow{row(2, "L-1", "", "20"), row(3, "L-1", "Lamp", "20")},
+			want: importProgress{Processed: 2, Created: 1, Errors: []mytypes.ImportRowError{
+				{Row: 2, SKU: "L-1", Error: "name is required"},
+			}},
+			wantNames: map[string]string{"L-1": "Lamp"},
+		},
+		{
+			name: "parse errors, bad rows and failed saves are reported per row",
+			fail: []string{"X-1"},
+			rows: []importRow{
+				{Line: 2, Payload: mytypes.CreateProductPayload{SKU: "A-1", Name: "A", Price: "1"}, Err: "invalid stock_quantity"},
+				row(3, "B-1", "B", "cheap"),
+				row(4, "", "C", "1"),
+				row(5, "X-1", "X", "1"),
+				row(6, "D-1", "D", "1"),
+			},
+			want: importProgress{Processed: 5, Created: 1, Errors: []mytypes.ImportRowError{
+				{Row: 2, SKU: "A-1", Error: "invalid stock_quantity"},
+				{Row: 3, SKU: "B-1", Error: "invalid price format"},
+				{Row: 4, SKU: "", Error: "sku is required"},
+				{Row: 5, SKU: "X-1", Error: "failed to save product"},
+			}},
+			wantNames: map[string]string{"D-1": "D"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := &memoryCatalog{products: map[string]mytypes.CreateProductPayload{}, fail: map[string]bool{}}
+			for _, sku := range tt.existing {
+				catalog.products[sku] = mytypes.CreateProductPayload{SKU: sku, Name: "old"}
+			}
+			for _, sku := range tt.fail {
+				catalog.fail[sku] = true
+			}
+
+			var reports []importProgress
+			err := applyRows(tt.rows, catalog.upsert, func(p importProgress) error {
+				reports = append(reports, p)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("applyRows() error = %v", err)
+			}
+
+			// Progress is reported after every row, the last report is the result
+			if len(reports) != len(tt.rows) {
+				t.Fatalf("%d progress reports for %d rows", len(reports), len(tt.rows))
+			}
+			for i, p := range reports {
+				if p.Processed != int32(i+1) {
+					t.Errorf("report %d has %d rows processed", i+1, p.Processed)
+				}
+			}
+			if got := reports[len(reports)-1]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("progress =\n%+v\nwant\n%+v", got, tt.want)
+			}
+
+			names := map[string]string{}
+			for sku, p := range catalog.products {
+				if p.Name != "old" || !slices.Contains(tt.existing, sku) {
+					names[sku] = p.Name
+				}
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("catalog = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+
+	// Running the same file again updates what the first run created
+	catalog := &memoryCatalog{products: map[string]mytypes.CreateProductPayload{}}
+	rows := []importRow{row(2, "L-1", "Lamp", "20"), row(3, "M-1", "Mug", "4")}
+	var last importProgress
+	report := func(p importProgress) error { last = p; return nil }
+	for run := 1; run <= 2; run++ {
+		if err := applyRows(rows, catalog.upsert, report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if last.Created != 0 || last.Updated != 2 || len(catalog.products) != 2 {
+		t.Errorf("second run created %d and updated %d, catalog has %d products", last.Created, last.Updated, len(catalog.products))
+	}
+
+	// Failing to save progress stops the import
+	calls := 0
+	err := applyRows(rows, catalog.upsert, func(importProgress) error {
+		calls++
+		return errors.New("database is gone")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("applyRows() went on after failing to report: error = %v after %d reports", err, calls)
+	}
+}
+
+// multipartBody builds a multipart upload with the file in field.
+func multipartBody(t *testing.T, field, filename string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+	return &body, writer.FormDataContentType()
+}
+
+func TestReadUpload(t *testing.T) {
+	csvFile := []byte("sku,name,price\nL-1,Lamp,20\n")
+	jsonFile := []byte(`[{"sku":"L-1","name":"Lamp","price":"20"}]`)
+	// A valid CSV padded past the limit with a long description
+	huge := append([]byte("sku,name,price,description\nL-1,Lamp,20,"), bytes.Repeat([]byte("x"), maxImportSize)...)
+
+	type upload struct {
+		query       string
+		contentType string
+		body        []byte
+		multipart   string // File name, to send the body as a multipart upload
+		field       string // Multipart field, "file" when empty
+	}
+	tests := []struct {
+		name         string
+		upload       upload
+		wantFormat   string
+		wantRows     int
+		wantTooLarge bool
+		wantErr      string
+	}{
+		{name: "raw csv", upload: upload{contentType: "text/csv", body: csvFile}, wantFormat: "csv", wantRows: 1},
+		{name: "raw without a content type is csv", upload: upload{body: csvFile}, wantFormat: "csv", wantRows: 1},
+		{name: "raw json", upload: upload{contentType: "application/json", body: jsonFile}, wantFormat: "json", wantRows: 1},
+		{name: "query picks the format", upload: upload{query: "?format=json", contentType: "text/plain", body: jsonFile}, wantFormat: "json", wantRows: 1},
+		{name: "unknown format", upload: upload{query: "?format=xlsx", body: csvFile}, wantErr: "format must be csv or json"},
+		{name: "raw without products", upload: upload{body: []byte("sku,name,price\n")}, wantErr: "file has no products"},
+		{name: "raw json that isn't an array", upload: upload{contentType: "application/json", body: []byte(`{}`)}, wantErr: "invalid json: expected an array of products"},
+		{name: "raw over the limit", upload: upload{contentType: "text/csv", body: huge}, wantTooLarge: true},
+		{name: "raw json over the limit", upload: upload{contentType: "application/json", body: append([]byte(`[{"sku":"`), bytes.Repeat([]byte("x"), maxImportSize)...)}, wantTooLarge: true},
+		{name: "multipart csv", upload: upload{multipart: "catalog.csv", body: csvFile}, wantFormat: "csv", wantRows: 1},
+		{name: "multipart json by extension", upload: upload{multipart: "Catalog.JSON", body: jsonFile}, wantFormat: "json", wantRows: 1},
+		{name: "multipart query wins over the extension", upload: upload{query: "?format=json", multipart: "catalog.txt", body: jsonFile}, wantFormat: "json", wantRows: 1},
+		{name: "multipart unknown extension", upload: upload{multipart: "catalog.xlsx", body: csvFile}, wantErr: "format must be csv or json"},
+		{name: "multipart in another field", upload: upload{multipart: "catalog.csv", field: "upload", body: csvFile}, wantErr: "invalid file"},
+		{name: "multipart over the limit", upload: upload{multipart: "catalog.csv", body: huge}, wantTooLarge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := bytes.NewBuffer(tt.upload.body), tt.upload.contentType
+			if tt.upload.multipart != "" {
+				field := tt.upload.field
+				if field == "" {
+					field = "file"
+				}
+				body, contentType = multipartBody(t, field, tt.upload.multipart, tt.upload.body)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/import"+tt.upload.query, body)
+			if contentType != "" {
+				r.Header.Set("Content-Type", contentType)
+			}
+
+			format, rows, err := readUpload(httptest.NewRecorder(), r)
+			var tooLarge *http.MaxBytesError
+			if tt.wantTooLarge {
+				if !errors.As(err, &tooLarge) {
+					t.Fatalf("readUpload() error = %v, want a *http.MaxBytesError", err)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("readUpload() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readUpload() error = %v", err)
+			}
+			if format != tt.wantFormat || len(rows) != tt.wantRows {
+				t.Errorf("readUpload() = %s with %d rows, want %s with %d", format, len(rows), tt.wantFormat, tt.wantRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package catalog
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the seller bulk import and export endpoints.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Group(func(seller chi.Router) {
+		seller.Use(utils.AuthMiddleware)
+
+		// Rows are applied in the background, poll the job for progress
+		seller.Post("/import", func(w http.ResponseWriter, r *http.Request) {
+			handleImportCatalog(w, r, db)
+		})
+
+		seller.Get("/import/{jobID}", func(w http.ResponseWriter, r *http.Request) {
+			handleGetImportJob(w, r, q)
+		})
+
+		seller.Get("/export", func(w http.ResponseWriter, r *http.Request) {
+			handleExportCatalog(w, r, q)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/background"
+)
+
+const (
+	pollInterval = time.Second
+	batchSize    = 50
+	// lease must outlast handling a batch, or another dispatcher could pick it up again
+	leaseSeconds = 60
+	// Processed events are kept this long for debugging, then deleted
+	retention     = 7 * 24 * time.Hour
+	pruneInterval = time.Hour
+)
+
+// Dispatcher hands committed outbox events to the bus's subscribers. Delivery is at
+// least once: an event stays pending until every subscriber that wants it has handled
+// it, and one claimed by a dispatcher that dies is picked up again when its lease ends.
+// Several dispatchers can run against the same database.
+type Dispatcher struct {
+	db  *sql.DB
+	q   *database.Queries
+	bus *Bus
+}
+
+func NewDispatcher(db *sql.DB, bus *Bus) *Dispatcher {
+	return &Dispatcher{db: db, q: database.New(db), bus: bus}
+}
+
+// Run dispatches due events until ctx is cancelled, pruning old processed events
+// whenever it is caught up.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var pruned time.Time
+	background.Poller{
+		Name:      "event dispatch",
+		Interval:  pollInterval,
+		BatchSize: batchSize,
+		Poll:      d.dispatch,
+		Idle: func(ctx context.Context) {
+			if time.Since(pruned) <= pruneInterval {
+				return
+			}
+			if _, err := d.q.DeleteProcessedOutboxEvents(ctx, sql.NullTime{Time: time.Now().Add(-retention), Valid: true}); err != nil {
+				log.Printf("⚠️  outbox cleanup failed: %v", err)
+			}
+			pruned = time.Now()
+		},
+	}.Run(ctx)
+}
+
+// dispatch claims one batch of due events and handles them in the order they were
+// recorded.
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	events, err := d.q.ClaimDueOutboxEvents(ctx, database.ClaimDueOutboxEventsParams{
+		LeaseSeconds: leaseSeconds,
+		BatchSize:    batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	// UPDATE ... RETURNING doesn't keep the order the rows were picked in
+	slices.SortFunc(events, func(a, b database.OutboxEvent) int { return cmp.Compare(a.Seq, b.Seq) })
+
+	for _, event := range events {
+		if err := d.process(ctx, event); err != nil {
+			log.Printf("⚠️  event %s (%s): %v", event.ID, event.EventType, err)
+		}
+	}
+	return len(events), nil
+}
+
+// process gives the event to every subscriber that wants it and hasn't handled it yet,
+// then marks it processed or schedules a retry for the ones that failed.
+func (d *Dispatcher) process(ctx context.Context, row database.OutboxEvent) error {
+	handled, err := d.q.ListOutboxReceipts(ctx, row.ID)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(handled))
+	for _, name := range handled {
+		done[name] = true
+	}
+
+	event := NewEvent(row)
+
+	var failures []string
+	for _, s := range d.bus.subscribers {
+		if done[s.name] || !s.wants(event.Type) {
+			continue
+		}
+		if err := d.handle(ctx, s, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return d.q.MarkOutboxEventProcessed(ctx, row.ID)
+	}
+
+	attempt := row.Attempts + 1
+	status := database.OutboxEventStatusPending
+	if attempt >= MaxAttempts {
+		status = database.OutboxEventStatusFailed
+	}
+	lastError := strings.Join(failures, "; ")
+	if err := d.q.RecordOutboxEventFailure(ctx, database.RecordOutboxEventFailureParams{
+		ID:            row.ID,
+		Status:        status,
+		Attempts:      attempt,
+		NextAttemptAt: time.Now().Add(retryBackoff.Delay(attempt)),
+		LastError:     lastError,
+	}); err != nil {
+		return err
+	}
+	return fmt.Errorf("attempt %d: %s", attempt, lastError)
+}
+
+// handle runs one subscriber and records its receipt in the same transaction. A panic
+// counts as a failure rather than stopping the dispatcher.
+func (d *Dispatcher) handle(ctx context.Context, s subscriber, event Event) (err error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	qtx := database.New(tx)
+	if err := s.handler(ctx, qtx, event); err != nil {
+		return err
+	}
+	if err := qtx.CreateOutboxReceipt(ctx, database.CreateOutboxReceiptParams{
+		EventID:    event.ID,
+		Subscriber: s.name,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/background"
+	"github.com/google/uuid"
+)
+
+// Domain event types. Order events carry mytypes.OrderEventData or OrderStatusEventData,
+// seller order events a SellerOrderResponse with items or an OrderStatusEventData,
+// shipment.created a ShipmentEventData, and product and stock events a ProductEventData.
+const (
+	OrderCreated             = "order.created"
+	OrderStatusChanged       = "order.status_changed"
+	SellerOrderCreated       = "seller_order.created"
+	SellerOrderStatusChanged = "seller_order.status_changed"
+	ShipmentCreated          = "shipment.created"
+	ProductUpdated           = "product.updated"
+	StockLow                 = "stock.low"
+	StockOut                 = "stock.out"
+)
+
+// Retry schedule: an event a subscriber fails on is retried after 10s, 20s, 40s, ... and
+// given up after MaxAttempts, about three and a half hours after it was recorded.
+const MaxAttempts = 12
+
+var retryBackoff = background.Backoff{Base: 10 * time.Second, Max: time.Hour}
+
+// Event is a recorded domain event as subscribers receive it.
+type Event struct {
+	ID          uuid.UUID
+	Type        string
+	AggregateID uuid.UUID
+	Payload     json.RawMessage
+	OccurredAt  time.Time
+}
+
+// NewEvent turns an outbox row into the event subscribers see.
+func NewEvent(row database.OutboxEvent) Event {
+	return Event{
+		ID:          row.ID,
+		Type:        row.EventType,
+		AggregateID: row.AggregateID,
+		Payload:     row.Payload,
+		OccurredAt:  row.CreatedAt,
+	}
+}
+
+// Decode unmarshals the event's payload into v.
+func (e Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decode %s event %s: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
+
+// Record writes an event to the outbox. Call it on the transaction that made the
+// change, so the event exists exactly when the change does; subscribers get it once
+// the transaction commits.
+func Record(ctx context.Context, qtx *database.Queries, eventType string, aggregateID uuid.UUID, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return qtx.CreateOutboxEvent(ctx, database.CreateOutboxEventParams{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     payload,
+	})
+}
+
+// Handler reacts to an event. qtx is a transaction that also records that the
+// subscriber handled the event, so database writes made through it happen exactly once.
+// Anything else, like sending a request, can happen more than once: an event is
+// retried until every subscriber has returned nil.
+type Handler func(ctx context.Context, qtx *database.Queries, e Event) error
+
+type subscriber struct {
+	name    string
+	types   map[string]bool // nil for every type
+	handler Handler
+}
+
+func (s subscriber) wants(eventType string) bool {
+	return s.types == nil || s.types[eventType]
+}
+
+// Bus routes outbox events to the subscribers registered in this process.
+type Bus struct {
+	subscribers []subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for the given event types, or for every event when none
+// are given. The name is stored with each event the subscriber handles, so it must stay
+// the same across releases. Register every subscriber before starting the dispatcher.
+func (b *Bus) Subscribe(name string, handler Handler, eventTypes ...string) {
+	for _, s := range b.subscribers {
+		if s.name == name {
+			panic(fmt.Sprintf("events: subscriber %q registered twice", name))
+		}
+	}
+	s := subscriber{name: name, handler: handler}
+	if len(eventTypes) > 0 {
+		s.types = make(map[string]bool, len(eventTypes))
+		for _, t := range eventTypes {
+			s.types[t] = true
+		}
+	}
+	b.subscribers = append(b.subscribers, s)
+}
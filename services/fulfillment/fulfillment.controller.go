@@ -0,0 +1,438 @@
+package fulfillment
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	"github.com/ARCoder181105/ecom/services/orderstatus"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// handleListSellerOrders lists the sub-orders containing the seller's products, newest
+// first.
+func handleListSellerOrders(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	if claims.Role != "seller" && claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not a seller"))
+		return
+	}
+
+	sellerID, err := sellerScope(claims, r.URL.Query().Get("seller_id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page := 1
+	limit := 10
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	subs, err := q.ListSellerOrdersBySeller(r.Context(), database.ListSellerOrdersBySellerParams{
+		SellerID: sellerID,
+		Limit:    int32(limit),
+		Offset:   int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list orders"))
+		return
+	}
+
+	total, err := q.CountSellerOrdersBySeller(r.Context(), sellerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to count orders"))
+		return
+	}
+
+	resp := make([]mytypes.SellerOrderResponse, 0, len(subs))
+	for _, sub := range subs {
+		items, err := q.ListSellerOrderItems(r.Context(), uuid.NullUUID{UUID: sub.ID, Valid: true})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		entry := mytypes.SellerOrderResponse{
+			ID:              sub.ID.String(),
+			OrderID:         sub.OrderID.String(),
+			Status:          string(sub.Status),
+			Subtotal:        sub.Subtotal.String(),
+			DiscountTotal:   sub.DiscountTotal.String(),
+			TaxTotal:        sub.TaxTotal.String(),
+			Total:           sub.Total.String(),
+			ShippingAddress: sub.ShippingAddress,
+			ShippingMethod:  sub.ShippingMethod,
+			Items:           make([]mytypes.SellerOrderItemResponse, 0, len(items)),
+			CreatedAt:       sub.CreatedAt,
+		}
+		for _, item := range items {
+			entry.Items = append(entry.Items, mytypes.NewSellerOrderItemResponse(item))
+		}
+		resp = append(resp, entry)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"orders":      resp,
+		"page":        page,
+		"limit":       limit,
+		"total_items": total,
+		"total_pages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// handleGetSellerOrder shows one sub-order with its items, where to ship them and the
+// shipments sent so far.
+func handleGetSellerOrder(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	subID, err := uuid.Parse(chi.URLParam(r, "sellerOrderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller order id"))
+		return
+	}
+
+	sub, err := q.GetSellerOrder(r.Context(), subID)
+	if err == sql.ErrNoRows || (err == nil && !canManage(claims, sub.SellerID)) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("seller order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shipTo, err := q.GetSellerOrderShipping(r.Context(), sub.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err := q.ListSellerOrderItems(r.Context(), uuid.NullUUID{UUID: sub.ID, Valid: true})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := mytypes.NewSellerOrderResponse(sub)
+	resp.ShippingAddress = shipTo.ShippingAddress
+	resp.ShippingMethod = shipTo.ShippingMethod
+	resp.Items = make([]mytypes.SellerOrderItemResponse, 0, len(items))
+	for _, item := range items {
+		resp.Items = append(resp.Items, mytypes.NewSellerOrderItemResponse(item))
+	}
+
+	shipments, err := q.ListShipmentsBySellerOrder(r.Context(), sub.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp.Shipments, err = ShipmentResponses(r.Context(), q, shipments)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleUpdateSellerOrderStatus lets the seller mark their part of an order shipped or
+// delivered. Cancellations and refunds go through the whole order.
+func handleUpdateSellerOrderStatus(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	actorID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	subID, err := uuid.Parse(chi.URLParam(r, "sellerOrderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller order id"))
+		return
+	}
+
+	var payload mytypes.UpdateSellerOrderStatusPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	status, err := orderstatus.Parse(payload.Status)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	if status != database.OrderStatusShipped && status != database.OrderStatusDelivered {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("status must be shipped or delivered"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	sub, err := qtx.GetSellerOrder(r.Context(), subID)
+	if err == sql.ErrNoRows || (err == nil && !canManage(claims, sub.SellerID)) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("seller order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The parent order is locked before the sub-order, the same order Transition uses
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), sub.OrderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sub, err = qtx.GetSellerOrderForUpdate(r.Context(), subID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sub, err = orderstatus.TransitionSellerOrder(r.Context(), qtx, order, sub, status, uuid.NullUUID{UUID: actorID, Valid: true}, strings.TrimSpace(payload.Note))
+	var transitionErr *orderstatus.TransitionError
+	if errors.As(err, &transitionErr) {
+		utils.RespondWithError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := notifications.Notify(r.Context(), qtx, order.UserID, notifications.TypeOrderUpdate,
+		fmt.Sprintf("Part of your order has been %s", status),
+		fmt.Sprintf("Items in order %s have been %s by the seller.", order.ID, status)); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewSellerOrderResponse(sub))
+}
+
+// handleCreateShipment records a parcel the seller sent with some or all of the
+// sub-order's items.
+func handleCreateShipment(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	actorID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	subID, err := uuid.Parse(chi.URLParam(r, "sellerOrderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller order id"))
+		return
+	}
+
+	var payload mytypes.CreateShipmentPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	carrier, err := ParseCarrier(payload.Carrier)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	trackingNumber := strings.TrimSpace(payload.TrackingNumber)
+	if trackingNumber == "" || len(trackingNumber) > 100 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("tracking_number is required and at most 100 characters"))
+		return
+	}
+	trackingURL := strings.TrimSpace(payload.TrackingURL)
+	if trackingURL != "" && !strings.HasPrefix(trackingURL, "https://") && !strings.HasPrefix(trackingURL, "http://") {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("tracking_url must be an http(s) URL"))
+		return
+	}
+
+	lines := make([]ShipmentLine, 0, len(payload.Items))
+	for _, item := range payload.Items {
+		itemID, err := uuid.Parse(item.OrderItemID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order item id"))
+			return
+		}
+		lines = append(lines, ShipmentLine{OrderItemID: itemID, Quantity: item.Quantity})
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	sub, err := qtx.GetSellerOrder(r.Context(), subID)
+	if err == sql.ErrNoRows || (err == nil && !canManage(claims, sub.SellerID)) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("seller order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), sub.OrderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sub, err = qtx.GetSellerOrderForUpdate(r.Context(), subID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shipment, _, err := CreateShipment(r.Context(), qtx, order, sub, carrier, trackingNumber, trackingURL, lines, uuid.NullUUID{UUID: actorID, Valid: true})
+	var shipmentErr *ShipmentError
+	if errors.As(err, &shipmentErr) {
+		utils.RespondWithError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := ShipmentResponses(r.Context(), qtx, []database.Shipment{shipment})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, resp[0])
+}
+
+// handleRecordShipmentEvent lets the seller post a tracking update for carriers that
+// don't send webhooks, such as marking a parcel delivered.
+func handleRecordShipmentEvent(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	actorID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	shipmentID, err := uuid.Parse(chi.URLParam(r, "shipmentID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid shipment id"))
+		return
+	}
+
+	var payload mytypes.ShipmentEventPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	status, err := ParseShipmentStatus(payload.Status)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	occurredAt := time.Now()
+	if payload.OccurredAt != nil {
+		occurredAt = *payload.OccurredAt
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	shipment, err := qtx.GetShipment(r.Context(), shipmentID)
+	if err == sql.ErrNoRows || (err == nil && !canManage(claims, shipment.SellerID)) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("shipment not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shipment, _, err = RecordEvent(r.Context(), qtx, shipment, status, strings.TrimSpace(payload.Description), strings.TrimSpace(payload.Location), occurredAt, uuid.NullUUID{UUID: actorID, Valid: true})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := ShipmentResponses(r.Context(), qtx, []database.Shipment{shipment})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp[0])
+}
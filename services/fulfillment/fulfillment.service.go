@@ -0,0 +1,333 @@
+package fulfillment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	"github.com/ARCoder181105/ecom/services/orderstatus"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// sellerScope returns the seller whose sub-orders the caller lists. Sellers only see
+// their own; admins pick a seller with ?seller_id=.
+func sellerScope(claims *utils.Claims, sellerParam string) (uuid.UUID, error) {
+	if claims.Role == "admin" {
+		if sellerParam == "" {
+			return uuid.Nil, fmt.Errorf("seller_id is required")
+		}
+		sellerID, err := uuid.Parse(sellerParam)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("invalid seller id")
+		}
+		return sellerID, nil
+	}
+	sellerID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user id")
+	}
+	return sellerID, nil
+}
+
+// canManage reports whether the caller may view and update a sub-order: the seller it
+// belongs to, or an admin.
+func canManage(claims *utils.Claims, sellerID uuid.UUID) bool {
+	if claims.Role == "admin" {
+		return true
+	}
+	return claims.Role == "seller" && claims.UserID == sellerID.String()
+}
+
+// ShipmentError is a shipment the seller can fix, such as shipping more units than
+// were ordered.
+type ShipmentError struct {
+	Message string
+}
+
+func (e *ShipmentError) Error() string {
+	return e.Message
+}
+
+var carrierPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// trackingPages are the public tracking pages of carriers we know; %s is the tracking
+// number.
+var trackingPages = map[string]string{
+	"dhl":   "https://www.dhl.com/global-en/home/tracking.html?tracking-id=%s",
+	"fedex": "https://www.fedex.com/fedextrack/?trknbr=%s",
+	"ups":   "https://www.ups.com/track?tracknum=%s",
+	"usps":  "https://tools.usps.com/go/TrackConfirmAction?tLabels=%s",
+}
+
+// ParseCarrier normalises a carrier code such as "UPS" to "ups".
+func ParseCarrier(s string) (string, error) {
+	carrier := strings.ToLower(strings.TrimSpace(s))
+	if !carrierPattern.MatchString(carrier) {
+		return "", fmt.Errorf("carrier must be 1-50 lowercase letters, digits, '-' or '_'")
+	}
+	return carrier, nil
+}
+
+// ParseShipmentStatus validates a tracking status name.
+func ParseShipmentStatus(s string) (database.ShipmentStatus, error) {
+	status := database.ShipmentStatus(strings.ToLower(strings.TrimSpace(s)))
+	switch status {
+	case database.ShipmentStatusShipped, database.ShipmentStatusInTransit, database.ShipmentStatusOutForDelivery,
+		database.ShipmentStatusDelivered, database.ShipmentStatusException:
+		return status, nil
+	}
+	return "", fmt.Errorf("status must be one of shipped, in_transit, out_for_delivery, delivered, exception")
+}
+
+// ShipmentLine is a number of units of one order item put in a shipment.
+type ShipmentLine struct {
+	OrderItemID uuid.UUID
+	Quantity    int32
+}
+
+// CreateShipment records a parcel the seller sent with some or, when lines is empty,
+// all of the sub-order's unshipped items. Once nothing is left to ship the sub-order
+// becomes shipped. The caller locks the order, then the sub-order.
+func CreateShipment(ctx context.Context, qtx *database.Queries, order database.Order, sub database.SellerOrder, carrier, trackingNumber, trackingURL string, lines []ShipmentLine, actor uuid.NullUUID) (database.Shipment, database.SellerOrder, error) {
+	if sub.Status != database.OrderStatusPaid {
+		return database.Shipment{}, sub, &ShipmentError{Message: fmt.Sprintf("a %s order can't be shipped", sub.Status)}
+	}
+
+	items, err := qtx.ListSellerOrderItems(ctx, uuid.NullUUID{UUID: sub.ID, Valid: true})
+	if err != nil {
+		return database.Shipment{}, sub, err
+	}
+	remaining := make(map[uuid.UUID]int32, len(items))
+	for _, item := range items {
+		remaining[item.ID] = item.Quantity - item.ShippedQuantity
+	}
+
+	if len(lines) == 0 {
+		for _, item := range items {
+			if remaining[item.ID] > 0 {
+				lines = append(lines, ShipmentLine{OrderItemID: item.ID, Quantity: remaining[item.ID]})
+			}
+		}
+	}
+
+	shipping := make(map[uuid.UUID]int32, len(lines))
+	var itemOrder []uuid.UUID
+	for _, line := range lines {
+		left, ok := remaining[line.OrderItemID]
+		if !ok {
+			return database.Shipment{}, sub, &ShipmentError{Message: fmt.Sprintf("order item %s is not part of this order", line.OrderItemID)}
+		}
+		if line.Quantity < 1 {
+			return database.Shipment{}, sub, &ShipmentError{Message: "quantity must be at least 1"}
+		}
+		if _, seen := shipping[line.OrderItemID]; !seen {
+			itemOrder = append(itemOrder, line.OrderItemID)
+		}
+		shipping[line.OrderItemID] += line.Quantity
+		if shipping[line.OrderItemID] > left {
+			return database.Shipment{}, sub, &ShipmentError{Message: fmt.Sprintf("only %d units of order item %s are left to ship", left, line.OrderItemID)}
+		}
+	}
+	if len(shipping) == 0 {
+		return database.Shipment{}, sub, &ShipmentError{Message: "nothing left to ship"}
+	}
+
+	if trackingURL == "" {
+		if page, ok := trackingPages[carrier]; ok {
+			trackingURL = fmt.Sprintf(page, trackingNumber)
+		}
+	}
+
+	shipment, err := qtx.CreateShipment(ctx, database.CreateShipmentParams{
+		OrderID:        order.ID,
+		SellerOrderID:  sub.ID,
+		SellerID:       sub.SellerID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		TrackingUrl:    trackingURL,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return database.Shipment{}, sub, &ShipmentError{Message: "tracking number is already used for another shipment"}
+		}
+		return database.Shipment{}, sub, err
+	}
+
+	for _, itemID := range itemOrder {
+		if err := qtx.CreateShipmentItem(ctx, database.CreateShipmentItemParams{
+			ShipmentID:  shipment.ID,
+			OrderItemID: itemID,
+			Quantity:    shipping[itemID],
+		}); err != nil {
+			return database.Shipment{}, sub, err
+		}
+		remaining[itemID] -= shipping[itemID]
+	}
+
+	if _, err := qtx.CreateShipmentEvent(ctx, database.CreateShipmentEventParams{
+		ShipmentID:  shipment.ID,
+		Status:      database.ShipmentStatusShipped,
+		Description: "Handed to " + carrier,
+		OccurredAt:  shipment.ShippedAt,
+	}); err != nil {
+		return database.Shipment{}, sub, err
+	}
+
+	complete := true
+	for _, left := range remaining {
+		if left > 0 {
+			complete = false
+		}
+	}
+	if complete {
+		sub, err = orderstatus.TransitionSellerOrder(ctx, qtx, order, sub, database.OrderStatusShipped, actor,
+			fmt.Sprintf("shipped with %s %s", carrier, trackingNumber))
+		if err != nil {
+			return database.Shipment{}, sub, err
+		}
+	}
+
+	body := fmt.Sprintf("Items in order %s are on their way with %s, tracking number %s.", order.ID, carrier, trackingNumber)
+	if trackingURL != "" {
+		body += " Track it at " + trackingURL
+	}
+	if err := notifications.Notify(ctx, qtx, order.UserID, notifications.TypeShipmentUpdate, "Your order has shipped", body); err != nil {
+		return database.Shipment{}, sub, err
+	}
+	if err := events.Record(ctx, qtx, events.ShipmentCreated, shipment.ID, mytypes.NewShipmentEventData(shipment, order)); err != nil {
+		return database.Shipment{}, sub, err
+	}
+
+	return shipment, sub, nil
+}
+
+// RecordEvent adds a tracking update to a shipment, from the seller or the carrier.
+// Events the shipment already has are ignored and reported with recorded false. Once
+// every shipment of a fully shipped sub-order is delivered, the sub-order is delivered.
+func RecordEvent(ctx context.Context, qtx *database.Queries, shipment database.Shipment, status database.ShipmentStatus, description, location string, occurredAt time.Time, actor uuid.NullUUID) (updated database.Shipment, recorded bool, err error) {
+	// Same lock order as everywhere else: the order, its sub-order, then the shipment
+	order, err := qtx.GetOrderByIDForUpdate(ctx, shipment.OrderID)
+	if err != nil {
+		return shipment, false, err
+	}
+	sub, err := qtx.GetSellerOrderForUpdate(ctx, shipment.SellerOrderID)
+	if err != nil {
+		return shipment, false, err
+	}
+	shipment, err = qtx.GetShipmentForUpdate(ctx, shipment.ID)
+	if err != nil {
+		return shipment, false, err
+	}
+
+	if _, err := qtx.CreateShipmentEvent(ctx, database.CreateShipmentEventParams{
+		ShipmentID:  shipment.ID,
+		Status:      status,
+		Description: description,
+		Location:    location,
+		OccurredAt:  occurredAt,
+	}); err != nil {
+		if err == sql.ErrNoRows {
+			return shipment, false, nil
+		}
+		return shipment, false, err
+	}
+
+	// A delivered parcel stays delivered whatever arrives late
+	if shipment.Status == database.ShipmentStatusDelivered {
+		return shipment, true, nil
+	}
+
+	shipment, err = qtx.UpdateShipmentStatus(ctx, database.UpdateShipmentStatusParams{
+		ID:          shipment.ID,
+		Status:      status,
+		DeliveredAt: occurredAt,
+	})
+	if err != nil {
+		return shipment, false, err
+	}
+
+	switch status {
+	case database.ShipmentStatusDelivered:
+		if err := notifications.Notify(ctx, qtx, order.UserID, notifications.TypeShipmentUpdate, "Your parcel was delivered",
+			fmt.Sprintf("The %s parcel %s for order %s was delivered.", shipment.Carrier, shipment.TrackingNumber, order.ID)); err != nil {
+			return shipment, false, err
+		}
+
+		undelivered, err := qtx.CountUndeliveredShipments(ctx, sub.ID)
+		if err != nil {
+			return shipment, false, err
+		}
+		if undelivered == 0 && sub.Status == database.OrderStatusShipped {
+			if _, err := orderstatus.TransitionSellerOrder(ctx, qtx, order, sub, database.OrderStatusDelivered, actor, "all parcels delivered"); err != nil {
+				return shipment, false, err
+			}
+		}
+	case database.ShipmentStatusException:
+		if err := notifications.Notify(ctx, qtx, order.UserID, notifications.TypeShipmentUpdate, "Delivery problem",
+			fmt.Sprintf("The %s parcel %s for order %s has a delivery problem: %s", shipment.Carrier, shipment.TrackingNumber, order.ID, description)); err != nil {
+			return shipment, false, err
+		}
+	}
+
+	return shipment, true, nil
+}
+
+// ShipmentResponses loads the items and tracking history of shipments.
+func ShipmentResponses(ctx context.Context, q *database.Queries, shipments []database.Shipment) ([]mytypes.ShipmentResponse, error) {
+	resp := make([]mytypes.ShipmentResponse, 0, len(shipments))
+	for _, s := range shipments {
+		items, err := q.ListShipmentItems(ctx, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		events, err := q.ListShipmentEvents(ctx, s.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := mytypes.ShipmentResponse{
+			ID:             s.ID.String(),
+			OrderID:        s.OrderID.String(),
+			SellerOrderID:  s.SellerOrderID.String(),
+			Carrier:        s.Carrier,
+			TrackingNumber: s.TrackingNumber,
+			TrackingURL:    s.TrackingUrl,
+			Status:         string(s.Status),
+			ShippedAt:      s.ShippedAt,
+			Items:          make([]mytypes.ShipmentItemResponse, 0, len(items)),
+			Events:         make([]mytypes.ShipmentEventResponse, 0, len(events)),
+		}
+		if s.DeliveredAt.Valid {
+			entry.DeliveredAt = &s.DeliveredAt.Time
+		}
+		for _, item := range items {
+			entry.Items = append(entry.Items, mytypes.ShipmentItemResponse{
+				OrderItemID: item.OrderItemID.String(),
+				ProductID:   item.ProductID.String(),
+				ProductName: item.ProductName,
+				Quantity:    item.Quantity,
+			})
+		}
+		for _, e := range events {
+			entry.Events = append(entry.Events, mytypes.ShipmentEventResponse{
+				Status:      string(e.Status),
+				Description: e.Description,
+				Location:    e.Location,
+				OccurredAt:  e.OccurredAt,
+			})
+		}
+		resp = append(resp, entry)
+	}
+	return resp, nil
+}
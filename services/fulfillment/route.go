@@ -0,0 +1,41 @@
+package fulfillment
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the seller's side of orders: the sub-orders containing their products
+// and the shipments and status updates they make while fulfilling them.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Use(utils.AuthMiddleware)
+
+	r.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		handleListSellerOrders(w, r, q)
+	})
+
+	r.Get("/orders/{sellerOrderID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetSellerOrder(w, r, q)
+	})
+
+	r.Post("/orders/{sellerOrderID}/status", func(w http.ResponseWriter, r *http.Request) {
+		handleUpdateSellerOrderStatus(w, r, db)
+	})
+
+	r.Post("/orders/{sellerOrderID}/shipments", func(w http.ResponseWriter, r *http.Request) {
+		handleCreateShipment(w, r, db)
+	})
+
+	r.Post("/shipments/{shipmentID}/events", func(w http.ResponseWriter, r *http.Request) {
+		handleRecordShipmentEvent(w, r, db)
+	})
+
+	return r
+}
@@ -0,0 +1,263 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func handleRestock(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	var payload mytypes.StockMovementPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if payload.Quantity <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("quantity must be greater than zero"))
+		return
+	}
+
+	recordSellerMovement(w, r, db, database.InventoryMovementTypeRestock, payload)
+}
+
+func handleAdjustStock(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	var payload mytypes.StockMovementPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if payload.Quantity == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("quantity must not be zero"))
+		return
+	}
+
+	if payload.Reason == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("reason is required for adjustments"))
+		return
+	}
+
+	movementType := database.InventoryMovementTypeAdjustment
+	switch payload.Type {
+	case "", string(database.InventoryMovementTypeAdjustment):
+	case string(database.InventoryMovementTypeReturn):
+		if payload.Quantity < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("returns must add stock"))
+			return
+		}
+		movementType = database.InventoryMovementTypeReturn
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("type must be adjustment or return"))
+		return
+	}
+
+	recordSellerMovement(w, r, db, movementType, payload)
+}
+
+// recordSellerMovement checks that the caller owns the product and writes the movement
+// in a single transaction.
+func recordSellerMovement(w http.ResponseWriter, r *http.Request, db *sql.DB, movementType database.InventoryMovementType, payload mytypes.StockMovementPayload) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	if status, err := checkProductOwner(r.Context(), qtx, productID, userID, claims.Role); err != nil {
+		utils.RespondWithError(w, status, err)
+		return
+	}
+
+	movement, err := RecordMovement(r.Context(), qtx, Movement{
+		ProductID: productID,
+		Type:      movementType,
+		Quantity:  int32(payload.Quantity),
+		ActorID:   uuid.NullUUID{UUID: userID, Valid: true},
+		Reason:    payload.Reason,
+	})
+	if errors.Is(err, ErrInsufficientStock) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("adjustment would make stock negative"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record stock movement"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, toMovementResponse(movement))
+}
+
+func handleGetInventoryHistory(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	if status, err := checkProductOwner(r.Context(), q, productID, userID, claims.Role); err != nil {
+		utils.RespondWithError(w, status, err)
+		return
+	}
+
+	page := 1
+	limit := 20
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	movements, err := q.ListInventoryMovementsByProduct(r.Context(), database.ListInventoryMovementsByProductParams{
+		ProductID: productID,
+		Limit:     int32(limit),
+		Offset:    int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list stock movements"))
+		return
+	}
+
+	totalCount, err := q.CountInventoryMovementsByProduct(r.Context(), productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("error counting stock movements"))
+		return
+	}
+
+	product, err := q.GetProductByID(r.Context(), productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ledgerStock, err := q.GetLedgerStockByProduct(r.Context(), productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responseMovements := []mytypes.InventoryMovementResponse{}
+	for _, m := range movements {
+		responseMovements = append(responseMovements, toMovementResponse(m))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data":           responseMovements,
+		"stock_quantity": product.StockQuantity,
+		"ledger_balance": ledgerStock,
+		"reconciled":     ledgerStock == product.StockQuantity,
+		"page":           page,
+		"limit":          limit,
+		"total_items":    totalCount,
+		"total_pages":    (int(totalCount) + limit - 1) / limit,
+	})
+}
+
+// handleListLowStock lists the caller's products at or below their low-stock threshold,
+// including sold out products.
+func handleListLowStock(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "seller" && claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("only sellers have stock alerts"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	products, err := q.ListLowStockProductsBySeller(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list low stock products"))
+		return
+	}
+
+	responseProducts := []mytypes.ProductResponse{}
+	for _, p := range products {
+		responseProducts = append(responseProducts, mytypes.NewProductResponse(p))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, responseProducts)
+}
+
+// checkProductOwner returns the HTTP status and error to respond with when the caller
+// may not manage the product's stock. Admins may manage any product.
+func checkProductOwner(ctx context.Context, q *database.Queries, productID, userID uuid.UUID, role string) (int, error) {
+	product, err := q.GetProductByID(ctx, productID)
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, fmt.Errorf("product not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if role != "admin" && product.UserID != userID {
+		return http.StatusForbidden, fmt.Errorf("you do not own this product")
+	}
+
+	return http.StatusOK, nil
+}
+
+func toMovementResponse(m database.InventoryMovement) mytypes.InventoryMovementResponse {
+	resp := mytypes.InventoryMovementResponse{
+		ID:           m.ID.String(),
+		ProductID:    m.ProductID.String(),
+		Type:         string(m.MovementType),
+		Quantity:     int(m.Quantity),
+		BalanceAfter: int(m.BalanceAfter),
+		Reason:       m.Reason,
+		CreatedAt:    m.CreatedAt,
+	}
+	if m.ActorID.Valid {
+		resp.ActorID = m.ActorID.UUID.String()
+	}
+	if m.OrderID.Valid {
+		resp.OrderID = m.OrderID.UUID.String()
+	}
+	return resp
+}
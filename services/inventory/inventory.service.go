@@ -0,0 +1,112 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrInsufficientStock is returned when a movement would take stock below zero.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// Movement describes a single stock change to be appended to the ledger.
+type Movement struct {
+	ProductID uuid.UUID
+	Type      database.InventoryMovementType
+	Quantity  int32 // Signed delta: negative for sales and write-downs
+	ActorID   uuid.NullUUID
+	OrderID   uuid.NullUUID
+	Reason    string
+}
+
+// RecordMovement applies the movement to products.stock_quantity and appends it to
+// inventory_movements. Pass a transaction-bound Queries so both writes commit together.
+func RecordMovement(ctx context.Context, qtx *database.Queries, m Movement) (database.InventoryMovement, error) {
+	product, err := qtx.AdjustProductStock(ctx, database.AdjustProductStockParams{
+		Delta: m.Quantity,
+		ID:    m.ProductID,
+	})
+	if err != nil {
+		// products.stock_quantity has a CHECK (stock_quantity >= 0)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23514" {
+			return database.InventoryMovement{}, ErrInsufficientStock
+		}
+		return database.InventoryMovement{}, err
+	}
+
+	movement, err := qtx.CreateInventoryMovement(ctx, database.CreateInventoryMovementParams{
+		ProductID:    m.ProductID,
+		MovementType: m.Type,
+		Quantity:     m.Quantity,
+		BalanceAfter: product.StockQuantity,
+		ActorID:      m.ActorID,
+		OrderID:      m.OrderID,
+		Reason:       m.Reason,
+	})
+	if err != nil {
+		return database.InventoryMovement{}, err
+	}
+
+	if err := checkStockAlerts(ctx, qtx, m.ProductID, product.StockQuantity-m.Quantity, product); err != nil {
+		return database.InventoryMovement{}, err
+	}
+
+	return movement, nil
+}
+
+// checkStockAlerts notifies the seller, and records stock.low or stock.out, when stock
+// crosses the low-stock threshold or sells out, and notifies waiting customers when a
+// sold out product is restocked.
+func checkStockAlerts(ctx context.Context, qtx *database.Queries, productID uuid.UUID, previous int32, product database.AdjustProductStockRow) error {
+	event := mytypes.ProductEventData{
+		ID:                productID.String(),
+		SellerID:          product.UserID.String(),
+		Name:              product.Name,
+		StockQuantity:     product.StockQuantity,
+		LowStockThreshold: product.LowStockThreshold,
+	}
+
+	switch {
+	case previous > 0 && product.StockQuantity == 0:
+		if err := events.Record(ctx, qtx, events.StockOut, productID, event); err != nil {
+			return err
+		}
+		return notifications.Notify(ctx, qtx, product.UserID, notifications.TypeOutOfStock,
+			fmt.Sprintf("%s is sold out", product.Name),
+			"This product has no stock left and is flagged as sold out in listings. Restock it to resume sales.")
+
+	case previous > product.LowStockThreshold && product.StockQuantity <= product.LowStockThreshold:
+		if err := events.Record(ctx, qtx, events.StockLow, productID, event); err != nil {
+			return err
+		}
+		return notifications.Notify(ctx, qtx, product.UserID, notifications.TypeLowStock,
+			fmt.Sprintf("%s is running low", product.Name),
+			fmt.Sprintf("Only %d left in stock (threshold %d).", product.StockQuantity, product.LowStockThreshold))
+
+	case previous == 0 && product.StockQuantity > 0:
+		subscriptions, err := qtx.ListPendingStockSubscriptions(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range subscriptions {
+			if err := notifications.Notify(ctx, qtx, sub.UserID, notifications.TypeBackInStock,
+				fmt.Sprintf("%s is back in stock", product.Name),
+				"A product you asked to be notified about is available again."); err != nil {
+				return err
+			}
+		}
+
+		return qtx.MarkStockSubscriptionsNotified(ctx, productID)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package inventory
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the seller-facing stock ledger endpoints.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Group(func(seller chi.Router) {
+		seller.Use(utils.AuthMiddleware)
+
+		seller.Get("/alerts", func(w http.ResponseWriter, r *http.Request) {
+			handleListLowStock(w, r, q)
+		})
+
+		// Need Database transaction
+		seller.Post("/{productID}/restock", func(w http.ResponseWriter, r *http.Request) {
+			handleRestock(w, r, db)
+		})
+
+		seller.Post("/{productID}/adjust", func(w http.ResponseWriter, r *http.Request) {
+			handleAdjustStock(w, r, db)
+		})
+
+		seller.Get("/{productID}/history", func(w http.ResponseWriter, r *http.Request) {
+			handleGetInventoryHistory(w, r, q)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,280 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 14px; color: #222; margin: 40px; }
+h1 { margin: 0 0 4px; }
+.parties { display: flex; justify-content: space-between; margin: 24px 0; }
+.parties div { width: 45%; }
+table { width: 100%; border-collapse: collapse; margin-top: 16px; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+td.num, th.num { text-align: right; }
+.totals { width: 40%; margin-left: auto; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>{{.Title}} {{.Number}}</h1>
+<div class="muted">Issued {{.IssuedAt.Format "2006-01-02"}} &middot; Order {{.OrderID}}</div>
+{{if .CreditedNumber}}<div class="muted">Credits invoice {{.CreditedNumber}}</div>{{end}}
+<div class="parties">
+<div><strong>From</strong><br>{{.Issuer.Name}}{{range .Issuer.Address}}<br>{{.}}{{end}}{{if .Issuer.TaxID}}<br>Tax ID: {{.Issuer.TaxID}}{{end}}</div>
+<div><strong>Bill to</strong><br>{{.Buyer.Name}}{{if .Buyer.Email}}<br>{{.Buyer.Email}}{{end}}{{range .Buyer.Address}}<br>{{.}}{{end}}</div>
+</div>
+<table>
+<thead><tr><th>Item</th><th>Seller</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Discount</th><th class="num">Tax</th><th class="num">Total</th></tr></thead>
+<tbody>
+{{range .Lines}}<tr><td>{{.Description}}{{if .SKU}}<br><span class="muted">{{.SKU}}</span>{{end}}</td><td>{{.Seller}}</td><td class="num">{{.Quantity}}</td><td class="num">{{.UnitPrice.StringFixed 2}}</td><td class="num">{{.Discount.StringFixed 2}}</td><td class="num">{{.TaxAmount.StringFixed 2}}</td><td class="num">{{.Total.StringFixed 2}}</td></tr>
+{{end}}</tbody>
+</table>
+{{if .TaxLines}}<table>
+<thead><tr><th>Tax</th><th class="num">Rate</th><th class="num">Taxable</th><th class="num">Amount</th></tr></thead>
+<tbody>
+{{range .TaxLines}}<tr><td>{{.Name}}</td><td class="num">{{.Rate.String}}</td><td class="num">{{.TaxableAmount.StringFixed 2}}</td><td class="num">{{.Amount.StringFixed 2}}</td></tr>
+{{end}}</tbody>
+</table>{{end}}
+<table class="totals">
+<tr><td>Subtotal</td><td class="num">{{.Subtotal.StringFixed 2}}</td></tr>
+<tr><td>Discount</td><td class="num">-{{.DiscountTotal.StringFixed 2}}</td></tr>
+<tr><td>Shipping</td><td class="num">{{.ShippingCost.StringFixed 2}}</td></tr>
+<tr><td>Tax{{if .PricesIncludeTax}} (included){{end}}</td><td class="num">{{.TaxTotal.StringFixed 2}}</td></tr>
+<tr><td><strong>Total</strong></td><td class="num"><strong>{{.Total.StringFixed 2}}</strong></td></tr>
+</table>
+</body>
+</html>
+`))
+
+// RenderHTML writes the document as a standalone HTML page.
+func RenderHTML(w io.Writer, doc Document) error {
+	return htmlTemplate.Execute(w, doc)
+}
+
+// Page layout for the PDF, in points on an A4 page.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 50
+	marginTop    = 60
+	marginBottom = 60
+	lineHeight   = 14
+)
+
+// pdfText is one line of text placed on a page.
+type pdfText struct {
+	x, y int
+	size int
+	bold bool
+	text string
+}
+
+// pdfLayout lays out text top to bottom, starting a new page when one fills up.
+type pdfLayout struct {
+	pages [][]pdfText
+	y     int
+}
+
+func newPDFLayout() *pdfLayout {
+	l := &pdfLayout{}
+	l.newPage()
+	return l
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, nil)
+	l.y = pageHeight - marginTop
+}
+
+// row writes texts at the given x offsets on the current line and moves down.
+func (l *pdfLayout) row(size int, bold bool, cols ...pdfText) {
+	if l.y < marginBottom {
+		l.newPage()
+	}
+	page := len(l.pages) - 1
+	for _, c := range cols {
+		c.y, c.size, c.bold = l.y, size, bold
+		l.pages[page] = append(l.pages[page], c)
+	}
+	l.y -= size + (lineHeight - 10)
+}
+
+func (l *pdfLayout) gap() {
+	l.y -= lineHeight / 2
+}
+
+// at places text with its left edge at x.
+func at(x int, text string) pdfText {
+	return pdfText{x: x, text: text}
+}
+
+// right places text so it ends near edge, estimating its width from Helvetica's average
+// glyph width. Good enough for the numeric columns it is used for.
+func right(edge, size int, text string) pdfText {
+	return pdfText{x: edge - len(text)*size/2, text: text}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "~"
+}
+
+// RenderPDF renders the document as a PDF using the built-in Helvetica fonts, so no font
+// files need to be embedded. Text outside Latin-1 is replaced with "?".
+func RenderPDF(doc Document) []byte {
+	l := newPDFLayout()
+	const size = 9
+	edge := pageWidth - marginLeft
+
+	l.row(18, true, at(marginLeft, doc.Title()+" "+doc.Number))
+	l.row(size, false, at(marginLeft, "Issued "+doc.IssuedAt.Format("2006-01-02")+"  -  Order "+doc.OrderID))
+	if doc.CreditedNumber != "" {
+		l.row(size, false, at(marginLeft, "Credits invoice "+doc.CreditedNumber))
+	}
+	l.gap()
+
+	from := append([]string{doc.Issuer.Name}, doc.Issuer.Address...)
+	if doc.Issuer.TaxID != "" {
+		from = append(from, "Tax ID: "+doc.Issuer.TaxID)
+	}
+	to := []string{doc.Buyer.Name}
+	if doc.Buyer.Email != "" {
+		to = append(to, doc.Buyer.Email)
+	}
+	to = append(to, doc.Buyer.Address...)
+
+	l.row(size, true, at(marginLeft, "From"), at(320, "Bill to"))
+	for i := 0; i < len(from) || i < len(to); i++ {
+		var cols []pdfText
+		if i < len(from) {
+			cols = append(cols, at(marginLeft, from[i]))
+		}
+		if i < len(to) {
+			cols = append(cols, at(320, to[i]))
+		}
+		l.row(size, false, cols...)
+	}
+	l.gap()
+
+	l.row(size, true,
+		at(marginLeft, "Item"), at(230, "Seller"), right(350, size, "Qty"), right(405, size, "Unit"),
+		right(450, size, "Disc."), right(495, size, "Tax"), right(edge, size, "Total"))
+	for _, line := range doc.Lines {
+		l.row(size, false,
+			at(marginLeft, truncate(line.Description, 34)),
+			at(230, truncate(line.Seller, 16)),
+			right(350, size, fmt.Sprint(line.Quantity)),
+			right(405, size, line.UnitPrice.StringFixed(2)),
+			right(450, size, line.Discount.StringFixed(2)),
+			right(495, size, line.TaxAmount.StringFixed(2)),
+			right(edge, size, line.Total.StringFixed(2)))
+		if line.SKU != "" {
+			l.row(7, false, at(marginLeft, line.SKU))
+		}
+	}
+	l.gap()
+
+	if len(doc.TaxLines) > 0 {
+		l.row(size, true, at(marginLeft, "Tax"), right(350, size, "Rate"), right(450, size, "Taxable"), right(edge, size, "Amount"))
+		for _, t := range doc.TaxLines {
+			l.row(size, false,
+				at(marginLeft, truncate(t.Name, 40)),
+				right(350, size, t.Rate.String()),
+				right(450, size, t.TaxableAmount.StringFixed(2)),
+				right(edge, size, t.Amount.StringFixed(2)))
+		}
+		l.gap()
+	}
+
+	taxLabel := "Tax"
+	if doc.PricesIncludeTax {
+		taxLabel = "Tax (included)"
+	}
+	for _, total := range []struct{ label, value string }{
+		{"Subtotal", doc.Subtotal.StringFixed(2)},
+		{"Discount", "-" + doc.DiscountTotal.StringFixed(2)},
+		{"Shipping", doc.ShippingCost.StringFixed(2)},
+		{taxLabel, doc.TaxTotal.StringFixed(2)},
+	} {
+		l.row(size, false, at(380, total.label), right(edge, size, total.value))
+	}
+	l.row(11, true, at(380, "Total"), right(edge, 11, doc.Total.StringFixed(2)))
+
+	return writePDF(l.pages)
+}
+
+// pdfEscape encodes text for a PDF string literal in WinAnsiEncoding.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// writePDF serialises the laid-out pages. Objects 1-4 are the catalog, the page tree and
+// the two fonts; each page then takes two objects, the page and its content stream.
+func writePDF(pages [][]pdfText) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		var content strings.Builder
+		for _, t := range page {
+			font := "F1"
+			if t.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, t.size, t.x, t.y, pdfEscape(t.text))
+		}
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,164 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+func testDocument(lines int) Document {
+	doc := Document{
+		Kind:     string(database.InvoiceKindInvoice),
+		Number:   "INV-2026-000042",
+		OrderID:  "2f1c7d0e-0000-4000-8000-000000000000",
+		Issuer:   Party{Name: "E-Commerce Marketplace", Address: []string{"1 Market Street", "Springfield 12345"}, TaxID: "US123"},
+		Buyer:    Party{Name: "Zoë (Customer)", Email: "zoe@example.com", Address: []string{"5 Elm Road"}},
+		Subtotal: decimal.NewFromInt(int64(lines) * 10),
+		Total:    decimal.NewFromInt(int64(lines) * 10),
+		IssuedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for i := 0; i < lines; i++ {
+		doc.Lines = append(doc.Lines, Line{
+			Description: fmt.Sprintf("Product %d", i+1),
+			SKU:         fmt.Sprintf("SKU-%03d", i+1),
+			Seller:      "Seller",
+			Quantity:    1,
+			UnitPrice:   decimal.NewFromInt(10),
+			Total:       decimal.NewFromInt(10),
+		})
+	}
+	return doc
+}
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	xrefHeader       = regexp.MustCompile(`^xref\n0 (\d+)\n`)
+	sizePattern      = regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R >>`)
+	countPattern     = regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`)
+	lengthPattern    = regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`)
+)
+
+func TestRenderPDFMultiPage(t *testing.T) {
+	pdf := RenderPDF(testDocument(60))
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", pdf[:16])
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("missing EOF marker: %q", pdf[len(pdf)-16:])
+	}
+
+	// startxref points at the cross-reference table
+	m := startxrefPattern.FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref before the EOF marker")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref <= 0 || xref >= len(pdf) {
+		t.Fatalf("startxref %d is outside the file", xref)
+	}
+	table := pdf[xref:]
+	h := xrefHeader.FindSubmatch(table)
+	if h == nil {
+		t.Fatalf("startxref %d does not point at an xref table: %q", xref, table[:min(20, len(table))])
+	}
+	size, _ := strconv.Atoi(string(h[1]))
+
+	if s := sizePattern.FindSubmatch(pdf); s == nil || string(s[1]) != strconv.Itoa(size) {
+		t.Errorf("trailer /Size does not match the %d xref entries", size)
+	}
+
+	// Each entry is 20 bytes; entry 0 is the free list head and entry n must point at
+	// "n 0 obj"
+	entries := table[len(h[0]):]
+	if !bytes.HasPrefix(entries, []byte("0000000000 65535 f \n")) {
+		t.Errorf("xref entry 0 = %q", entries[:20])
+	}
+	for n := 1; n < size; n++ {
+		entry := string(entries[n*20 : (n+1)*20])
+		if !strings.HasSuffix(entry, " 00000 n \n") {
+			t.Fatalf("xref entry %d is malformed: %q", n, entry)
+		}
+		off, err := strconv.Atoi(entry[:10])
+		if err != nil {
+			t.Fatalf("xref entry %d offset: %v", n, err)
+		}
+		want := fmt.Sprintf("%d 0 obj\n", n)
+		if !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", n, pdf[off:off+len(want)], want)
+		}
+	}
+
+	// Four shared objects, then a page and its content stream per page
+	c := countPattern.FindSubmatch(pdf)
+	if c == nil {
+		t.Fatal("no page tree")
+	}
+	pages, _ := strconv.Atoi(string(c[1]))
+	if pages < 2 {
+		t.Fatalf("60 lines rendered on %d page, want several", pages)
+	}
+	if size != 1+4+2*pages {
+		t.Errorf("%d xref entries for %d pages, want %d", size, pages, 1+4+2*pages)
+	}
+	if got := bytes.Count(pdf, []byte("/Type /Page /Parent 2 0 R")); got != pages {
+		t.Errorf("%d page objects, page tree counts %d", got, pages)
+	}
+
+	// Stream lengths cover exactly the content up to endstream
+	streams := lengthPattern.FindAllSubmatchIndex(pdf, -1)
+	if len(streams) != pages {
+		t.Fatalf("%d content streams for %d pages", len(streams), pages)
+	}
+	for _, s := range streams {
+		length, _ := strconv.Atoi(string(pdf[s[2]:s[3]]))
+		if !bytes.HasPrefix(pdf[s[1]+length:], []byte("endstream")) {
+			t.Errorf("stream at %d: /Length %d does not end at endstream", s[0], length)
+		}
+	}
+
+	// Every line made it into the document, the last one on the last page
+	for _, want := range []string{"(Product 1)", "(Product 60)", "(SKU-060)", "(Total)"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF is missing %s", want)
+		}
+	}
+	lastPage := pdf[streams[len(streams)-1][1]:]
+	if !bytes.Contains(lastPage, []byte("(Product 60)")) {
+		t.Error("the last line is not on the last page")
+	}
+}
+
+func TestRenderPDFSinglePage(t *testing.T) {
+	pdf := RenderPDF(testDocument(2))
+	if c := countPattern.FindSubmatch(pdf); c == nil || string(c[1]) != "1" {
+		t.Errorf("short invoice should fit one page")
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Error("missing EOF marker")
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{`a (b) \c`, `a \(b\) \\c`},
+		{"Zoë", `Zo\353`},
+		{"日本", "??"},
+		{"tab\there", "tab?here"},
+	}
+	for _, tt := range tests {
+		if got := pdfEscape(tt.in); got != tt.want {
+			t.Errorf("pdfEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package invoices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Number series. Each has its own gap-free sequence in invoice_sequences.
+const (
+	SeriesInvoice    = "INV"
+	SeriesCreditNote = "CN"
+)
+
+// Party is the issuer or the buyer as printed on the document.
+type Party struct {
+	Name    string   `json:"name"`
+	Email   string   `json:"email,omitempty"`
+	TaxID   string   `json:"tax_id,omitempty"`
+	Address []string `json:"address,omitempty"`
+}
+
+// Line is one invoice line. Total is what the customer paid for it: the line price less
+// its discount, plus tax when tax is charged on top.
+type Line struct {
+	Description string          `json:"description"`
+	SKU         string          `json:"sku,omitempty"`
+	Seller      string          `json:"seller,omitempty"`
+	Quantity    int32           `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Discount    decimal.Decimal `json:"discount"`
+	TaxRate     decimal.Decimal `json:"tax_rate"`
+	TaxAmount   decimal.Decimal `json:"tax_amount"`
+	Total       decimal.Decimal `json:"total"`
+}
+
+// TaxLine sums the tax charged at one rate.
+type TaxLine struct {
+	Name          string          `json:"name"`
+	Rate          decimal.Decimal `json:"rate"`
+	TaxableAmount decimal.Decimal `json:"taxable_amount"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+// Document is an issued invoice or credit note, ready to render.
+type Document struct {
+	ID               string          `json:"id"`
+	Kind             string          `json:"kind"`
+	Number           string          `json:"number"`
+	OrderID          string          `json:"order_id"`
+	CreditedNumber   string          `json:"credited_number,omitempty"` // The invoice a credit note corrects
+	Issuer           Party           `json:"issuer"`
+	Buyer            Party           `json:"buyer"`
+	Lines            []Line          `json:"lines"`
+	TaxLines         []TaxLine       `json:"tax_lines"`
+	Subtotal         decimal.Decimal `json:"subtotal"`
+	DiscountTotal    decimal.Decimal `json:"discount_total"`
+	ShippingCost     decimal.Decimal `json:"shipping_cost"`
+	TaxTotal         decimal.Decimal `json:"tax_total"`
+	Total            decimal.Decimal `json:"total"`
+	PricesIncludeTax bool            `json:"prices_include_tax"`
+	IssuedAt         time.Time       `json:"issued_at"`
+}
+
+// Title is the document's heading.
+func (d Document) Title() string {
+	if d.Kind == string(database.InvoiceKindCreditNote) {
+		return "Credit note"
+	}
+	return "Invoice"
+}
+
+// Issuer is the marketplace as it appears on every document, configured with
+// INVOICE_ISSUER_NAME, INVOICE_ISSUER_ADDRESS (lines separated by "|") and
+// INVOICE_ISSUER_TAX_ID.
+func Issuer() Party {
+	p := Party{
+		Name:  os.Getenv("INVOICE_ISSUER_NAME"),
+		TaxID: os.Getenv("INVOICE_ISSUER_TAX_ID"),
+	}
+	if p.Name == "" {
+		p.Name = "E-Commerce Marketplace"
+	}
+	for _, line := range strings.Split(os.Getenv("INVOICE_ISSUER_ADDRESS"), "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			p.Address = append(p.Address, line)
+		}
+	}
+	return p
+}
+
+// addressLines formats a postal address snapshot for printing.
+func addressLines(a mytypes.PostalAddress) []string {
+	var lines []string
+	for _, line := range []string{
+		a.FullName,
+		a.Line1,
+		a.Line2,
+		strings.TrimSpace(strings.Join([]string{a.PostalCode, a.City}, " ")),
+		strings.TrimSpace(strings.Join([]string{a.State, a.Country}, " ")),
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func nextNumber(ctx context.Context, qtx *database.Queries, series string) (string, error) {
+	n, err := qtx.NextInvoiceNumber(ctx, series)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%06d", series, n), nil
+}
+
+// Issue creates the invoice for a paid order, copying the buyer, address, lines and
+// taxes as they are now. An order is only invoiced once. Call it on the transaction
+// that marks the order paid, so a rollback also gives the number back.
+func Issue(ctx context.Context, qtx *database.Queries, order database.Order) (database.Invoice, error) {
+	existing, err := qtx.GetOrderInvoice(ctx, order.ID)
+	if err == nil {
+		return existing, nil
+	}
+
+	user, err := qtx.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		return database.Invoice{}, err
+	}
+	buyer := Party{
+		Name:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Email: user.Email,
+	}
+	var address mytypes.PostalAddress
+	if err := json.Unmarshal(order.ShippingAddress, &address); err == nil {
+		buyer.Address = addressLines(address)
+	}
+
+	sources, err := qtx.ListInvoiceLineSources(ctx, order.ID)
+	if err != nil {
+		return database.Invoice{}, err
+	}
+	lines := make([]Line, 0, len(sources))
+	for _, s := range sources {
+		total := s.Price.Mul(decimal.NewFromInt(int64(s.Quantity))).Sub(s.DiscountAmount)
+		if !order.PricesIncludeTax {
+			total = total.Add(s.TaxAmount)
+		}
+		lines = append(lines, Line{
+			Description: s.ProductName,
+			SKU:         s.ProductSku.String,
+			Seller:      s.SellerName,
+			Quantity:    s.Quantity,
+			UnitPrice:   s.Price,
+			Discount:    s.DiscountAmount,
+			TaxRate:     s.TaxRate,
+			TaxAmount:   s.TaxAmount,
+			Total:       total,
+		})
+	}
+
+	taxes, err := qtx.ListOrderTaxLines(ctx, order.ID)
+	if err != nil {
+		return database.Invoice{}, err
+	}
+	taxLines := make([]TaxLine, 0, len(taxes))
+	for _, t := range taxes {
+		taxLines = append(taxLines, TaxLine{
+			Name:          t.Name,
+			Rate:          t.Rate,
+			TaxableAmount: t.TaxableAmount,
+			Amount:        t.Amount,
+		})
+	}
+
+	number, err := nextNumber(ctx, qtx, SeriesInvoice)
+	if err != nil {
+		return database.Invoice{}, err
+	}
+
+	return create(ctx, qtx, database.CreateInvoiceParams{
+		Kind:             database.InvoiceKindInvoice,
+		Number:           number,
+		OrderID:          order.ID,
+		UserID:           order.UserID,
+		Subtotal:         order.Subtotal,
+		DiscountTotal:    order.DiscountTotal,
+		ShippingCost:     order.ShippingCost,
+		TaxTotal:         order.TaxTotal,
+		Total:            order.TotalPrice,
+		PricesIncludeTax: order.PricesIncludeTax,
+	}, Issuer(), buyer, lines, taxLines)
+}
+
+// IssueCreditNote records a refund against the order's invoice. The tax on the credit
+// note is the invoice's tax in proportion to the refunded amount. Orders that were never
+// invoiced get no credit note.
+func IssueCreditNote(ctx context.Context, qtx *database.Queries, order database.Order, amount decimal.Decimal, paymentID uuid.NullUUID) (*database.Invoice, error) {
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	invoice, err := qtx.GetOrderInvoice(ctx, order.ID)
+	if err != nil {
+		return nil, nil
+	}
+	doc, err := Load(invoice, "")
+	if err != nil {
+		return nil, err
+	}
+
+	share := decimal.NewFromInt(0)
+	if invoice.Total.IsPositive() {
+		share = decimal.Min(amount.Div(invoice.Total), decimal.NewFromInt(1))
+	}
+
+	taxTotal := decimal.NewFromInt(0)
+	taxLines := make([]TaxLine, 0, len(doc.TaxLines))
+	for _, t := range doc.TaxLines {
+		line := TaxLine{
+			Name:          t.Name,
+			Rate:          t.Rate,
+			TaxableAmount: t.TaxableAmount.Mul(share).Round(2),
+			Amount:        t.Amount.Mul(share).Round(2),
+		}
+		taxTotal = taxTotal.Add(line.Amount)
+		taxLines = append(taxLines, line)
+	}
+
+	subtotal := amount
+	if !invoice.PricesIncludeTax {
+		subtotal = amount.Sub(taxTotal)
+	}
+	lines := []Line{{
+		Description: fmt.Sprintf("Refund on invoice %s", invoice.Number),
+		Quantity:    1,
+		UnitPrice:   subtotal,
+		Discount:    decimal.NewFromInt(0),
+		TaxAmount:   taxTotal,
+		Total:       amount,
+	}}
+
+	number, err := nextNumber(ctx, qtx, SeriesCreditNote)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := create(ctx, qtx, database.CreateInvoiceParams{
+		Kind:              database.InvoiceKindCreditNote,
+		Number:            number,
+		OrderID:           order.ID,
+		UserID:            order.UserID,
+		PaymentID:         paymentID,
+		CreditedInvoiceID: uuid.NullUUID{UUID: invoice.ID, Valid: true},
+		Subtotal:          subtotal,
+		DiscountTotal:     decimal.NewFromInt(0),
+		ShippingCost:      decimal.NewFromInt(0),
+		TaxTotal:          taxTotal,
+		Total:             amount,
+		PricesIncludeTax:  invoice.PricesIncludeTax,
+	}, doc.Issuer, doc.Buyer, lines, taxLines)
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func create(ctx context.Context, qtx *database.Queries, params database.CreateInvoiceParams, issuer, buyer Party, lines []Line, taxLines []TaxLine) (database.Invoice, error) {
+	var err error
+	if params.Issuer, err = json.Marshal(issuer); err != nil {
+		return database.Invoice{}, err
+	}
+	if params.Buyer, err = json.Marshal(buyer); err != nil {
+		return database.Invoice{}, err
+	}
+	if params.Lines, err = json.Marshal(lines); err != nil {
+		return database.Invoice{}, err
+	}
+	if params.TaxLines, err = json.Marshal(taxLines); err != nil {
+		return database.Invoice{}, err
+	}
+	return qtx.CreateInvoice(ctx, params)
+}
+
+// Load turns a stored invoice back into a document. creditedNumber is the number of the
+// invoice a credit note corrects.
+func Load(inv database.Invoice, creditedNumber string) (Document, error) {
+	doc := Document{
+		ID:               inv.ID.String(),
+		Kind:             string(inv.Kind),
+		Number:           inv.Number,
+		OrderID:          inv.OrderID.String(),
+		CreditedNumber:   creditedNumber,
+		Subtotal:         inv.Subtotal,
+		DiscountTotal:    inv.DiscountTotal,
+		ShippingCost:     inv.ShippingCost,
+		TaxTotal:         inv.TaxTotal,
+		Total:            inv.Total,
+		PricesIncludeTax: inv.PricesIncludeTax,
+		IssuedAt:         inv.IssuedAt,
+	}
+	if err := json.Unmarshal(inv.Issuer, &doc.Issuer); err != nil {
+		return Document{}, err
+	}
+	if err := json.Unmarshal(inv.Buyer, &doc.Buyer); err != nil {
+		return Document{}, err
+	}
+	if err := json.Unmarshal(inv.Lines, &doc.Lines); err != nil {
+		return Document{}, err
+	}
+	if err := json.Unmarshal(inv.TaxLines, &doc.TaxLines); err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var statuses = []database.JobStatus{
+	database.JobStatusQueued,
+	database.JobStatusRunning,
+	database.JobStatusSucceeded,
+	database.JobStatusDead,
+}
+
+// requireAdmin responds with an error unless the caller is an admin.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return false
+	}
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return false
+	}
+	return true
+}
+
+// handleListJobs lists jobs, newest first, optionally filtered by status and kind.
+func handleListJobs(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status")))
+	if status != "" {
+		known := false
+		for _, s := range statuses {
+			if status == string(s) {
+				known = true
+			}
+		}
+		if !known {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("status must be one of queued, running, succeeded, dead"))
+			return
+		}
+	}
+	kind := strings.TrimSpace(r.URL.Query().Get("kind"))
+
+	page := 1
+	limit := 10
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	jobs, err := q.ListJobs(r.Context(), database.ListJobsParams{
+		Status: status,
+		Kind:   kind,
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	total, err := q.CountJobs(r.Context(), database.CountJobsParams{Status: status, Kind: kind})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.JobResponse, 0, len(jobs))
+	for _, j := range jobs {
+		resp = append(resp, mytypes.NewJobResponse(j))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":        resp,
+		"page":        page,
+		"limit":       limit,
+		"total_items": total,
+		"total_pages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// handleJobStats counts jobs per kind and status, to spot a backlog or dead jobs.
+func handleJobStats(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	rows, err := q.CountJobsByStatus(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	kinds := make(map[string]map[string]int64)
+	for _, row := range rows {
+		if kinds[row.Kind] == nil {
+			kinds[row.Kind] = make(map[string]int64, len(statuses))
+			for _, s := range statuses {
+				kinds[row.Kind][string(s)] = 0
+			}
+		}
+		kinds[row.Kind][string(row.Status)] = row.Count
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"kinds": kinds,
+	})
+}
+
+// loadJob fetches the job named in the URL.
+func loadJob(w http.ResponseWriter, r *http.Request, q *database.Queries) (database.Job, bool) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid job id"))
+		return database.Job{}, false
+	}
+
+	job, err := q.GetJob(r.Context(), jobID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("job not found"))
+		return database.Job{}, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.Job{}, false
+	}
+	return job, true
+}
+
+// handleGetJob shows a job with its payload.
+func handleGetJob(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	job, ok := loadJob(w, r, q)
+	if !ok {
+		return
+	}
+
+	resp := mytypes.NewJobResponse(job)
+	resp.Payload = job.Payload
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleRetryJob queues a dead job again with a fresh set of attempts.
+func handleRetryJob(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	job, ok := loadJob(w, r, q)
+	if !ok {
+		return
+	}
+
+	requeued, err := q.RequeueDeadJob(r.Context(), job.ID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("only dead jobs can be retried, this one is %s", job.Status))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewJobResponse(requeued))
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/background"
+)
+
+// DefaultMaxAttempts is how often a job is tried before it is dead, unless its Type
+// says otherwise.
+const DefaultMaxAttempts = 5
+
+// Retry schedule: a failed job is retried after 15s, 30s, 1m, ... capped at an hour.
+var retryBackoff = background.Backoff{Base: 15 * time.Second, Max: time.Hour}
+
+// Type ties a job kind to its payload, so enqueuing and handling a kind agree on the
+// payload's shape:
+//
+//	var SendReport = jobs.Type[ReportPayload]{Kind: "reports.send"}
+//	SendReport.Enqueue(ctx, qtx, ReportPayload{...})
+//	jobs.Handle(pool, SendReport, func(ctx context.Context, db *sql.DB, p ReportPayload) error { ... })
+//
+// The kind is stored with every job, so it must stay the same across releases.
+type Type[T any] struct {
+	Kind        string
+	MaxAttempts int32 // DefaultMaxAttempts when zero
+}
+
+// Enqueue adds a job to run as soon as a worker is free. Pass a transaction-bound
+// Queries so the job only exists if the work that asked for it commits.
+func (t Type[T]) Enqueue(ctx context.Context, q *database.Queries, payload T) (database.Job, error) {
+	return t.Schedule(ctx, q, payload, time.Now())
+}
+
+// Schedule adds a job that doesn't run before runAt.
+func (t Type[T]) Schedule(ctx context.Context, q *database.Queries, payload T, runAt time.Time) (database.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return database.Job{}, err
+	}
+	maxAttempts := t.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return q.CreateJob(ctx, database.CreateJobParams{
+		Kind:        t.Kind,
+		Payload:     data,
+		RunAt:       runAt,
+		MaxAttempts: maxAttempts,
+	})
+}
+
+// permanentError marks a failure that retrying can't fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps a handler error so the job goes straight to dead instead of being
+// retried, for example when its payload refers to something that no longer exists.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,183 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+)
+
+const (
+	pollInterval = 2 * time.Second
+	// A running job's lease is renewed every leaseSeconds/3 for as long as its handler
+	// runs, so only a worker that died loses its jobs
+	leaseSeconds = 60
+	// Succeeded jobs are kept this long for inspection, then deleted; dead ones stay
+	retention     = 7 * 24 * time.Hour
+	pruneInterval = time.Hour
+)
+
+type handlerFunc func(ctx context.Context, job database.Job) error
+
+// Pool runs jobs on a fixed number of workers. Several pools, in one process or many,
+// can share the jobs table; each only claims the kinds it has handlers for.
+type Pool struct {
+	db       *sql.DB
+	q        *database.Queries
+	workers  int
+	handlers map[string]handlerFunc
+}
+
+func NewPool(db *sql.DB, workers int) *Pool {
+	return &Pool{
+		db:       db,
+		q:        database.New(db),
+		workers:  max(workers, 1),
+		handlers: make(map[string]handlerFunc),
+	}
+}
+
+// Handle registers the handler for a job type. Register every type before calling Run.
+func Handle[T any](p *Pool, t Type[T], handle func(ctx context.Context, db *sql.DB, payload T) error) {
+	if _, dup := p.handlers[t.Kind]; dup {
+		panic(fmt.Sprintf("jobs: kind %q registered twice", t.Kind))
+	}
+	p.handlers[t.Kind] = func(ctx context.Context, job database.Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return handle(ctx, p.db, payload)
+	}
+}
+
+// Run starts the workers and blocks until ctx is cancelled and they have finished their
+// current jobs.
+func (p *Pool) Run(ctx context.Context) {
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, kinds)
+		}()
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.q.DeleteFinishedJobs(ctx, sql.NullTime{Time: time.Now().Add(-retention), Valid: true}); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  job cleanup failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// work claims and runs jobs one at a time, sleeping while there are none.
+func (p *Pool) work(ctx context.Context, kinds []string) {
+	for ctx.Err() == nil {
+		job, err := p.q.ClaimJob(ctx, database.ClaimJobParams{
+			LeaseSeconds: leaseSeconds,
+			Kinds:        kinds,
+		})
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+				log.Printf("⚠️  job claim failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		if err := p.run(ctx, job); err != nil {
+			log.Printf("⚠️  job %s (%s): %v", job.ID, job.Kind, err)
+		}
+	}
+}
+
+// run calls the job's handler while keeping its lease alive, then records the outcome:
+// succeeded, queued again after a backoff, or dead once it is out of attempts.
+func (p *Pool) run(ctx context.Context, job database.Job) error {
+	if job.Attempts > job.MaxAttempts {
+		// Claimed again after its lease ran out on the last attempt
+		return p.q.KillJob(ctx, database.KillJobParams{
+			ID:        job.ID,
+			LastError: "worker stopped responding on the last attempt",
+		})
+	}
+
+	stop := make(chan struct{})
+	go p.heartbeat(ctx, job, stop)
+	err := p.call(ctx, job)
+	close(stop)
+
+	switch {
+	case err == nil:
+		return p.q.CompleteJob(ctx, job.ID)
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		if killErr := p.q.KillJob(ctx, database.KillJobParams{ID: job.ID, LastError: err.Error()}); killErr != nil {
+			return killErr
+		}
+		return fmt.Errorf("dead after attempt %d: %w", job.Attempts, err)
+	default:
+		if retryErr := p.q.RetryJobLater(ctx, database.RetryJobLaterParams{
+			ID:        job.ID,
+			RunAt:     time.Now().Add(retryBackoff.Delay(job.Attempts)),
+			LastError: err.Error(),
+		}); retryErr != nil {
+			return retryErr
+		}
+		return fmt.Errorf("attempt %d: %w", job.Attempts, err)
+	}
+}
+
+// call runs the handler. A panic counts as a failed attempt rather than stopping the
+// worker.
+func (p *Pool) call(ctx context.Context, job database.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return p.handlers[job.Kind](ctx, job)
+}
+
+func (p *Pool) heartbeat(ctx context.Context, job database.Job, stop <-chan struct{}) {
+	ticker := time.NewTicker(leaseSeconds * time.Second / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.q.ExtendJobLease(ctx, database.ExtendJobLeaseParams{
+				LeaseSeconds: leaseSeconds,
+				ID:           job.ID,
+			}); err != nil {
+				log.Printf("⚠️  job %s: failed to extend lease: %v", job.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the admin view of the job queue, with manual retries of dead jobs.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Use(utils.AuthMiddleware)
+
+	// admin routes
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		handleListJobs(w, r, q)
+	})
+
+	r.Get("/stats", func(w http.ResponseWriter, r *http.Request) {
+		handleJobStats(w, r, q)
+	})
+
+	r.Get("/{jobID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetJob(w, r, q)
+	})
+
+	r.Post("/{jobID}/retry", func(w http.ResponseWriter, r *http.Request) {
+		handleRetryJob(w, r, q)
+	})
+
+	return r
+}
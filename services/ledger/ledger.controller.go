@@ -0,0 +1,433 @@
+package ledger
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/products"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func toCommissionRateResponse(c database.CommissionRate) mytypes.CommissionRateResponse {
+	resp := mytypes.CommissionRateResponse{
+		ID:        c.ID.String(),
+		Category:  c.Category,
+		Rate:      c.Rate.String(),
+		UpdatedAt: c.UpdatedAt,
+	}
+	if c.SellerID.Valid {
+		resp.SellerID = c.SellerID.UUID.String()
+	}
+	return resp
+}
+
+func toPayoutBatchResponse(b database.PayoutBatch, payouts []database.Payout) mytypes.PayoutBatchResponse {
+	resp := mytypes.PayoutBatchResponse{
+		ID:          b.ID.String(),
+		SellerCount: b.SellerCount,
+		Total:       b.Total.String(),
+		CreatedAt:   b.CreatedAt,
+	}
+	for _, p := range payouts {
+		resp.Payouts = append(resp.Payouts, mytypes.NewPayoutResponse(p))
+	}
+	return resp
+}
+
+// pagination reads ?page= and ?limit=, defaulting to the first 10 results.
+func pagination(r *http.Request) (int, int) {
+	page := 1
+	limit := 10
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return page, limit
+}
+
+// handleGetBalance shows what the platform owes the seller since their last payout, how
+// it adds up, their ledger entries and past payouts. Admins pass ?seller_id=.
+func handleGetBalance(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	var sellerID uuid.UUID
+	switch claims.Role {
+	case "seller":
+		sellerID, err = uuid.Parse(claims.UserID)
+	case "admin":
+		sellerID, err = uuid.Parse(r.URL.Query().Get("seller_id"))
+	default:
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not a seller"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller id"))
+		return
+	}
+	sellerRef := uuid.NullUUID{UUID: sellerID, Valid: true}
+
+	balance, err := q.GetSellerBalance(r.Context(), sellerRef)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page, limit := pagination(r)
+	entries, err := q.ListSellerLedgerEntries(r.Context(), database.ListSellerLedgerEntriesParams{
+		SellerID: sellerRef,
+		Limit:    int32(limit),
+		Offset:   int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	total, err := q.CountSellerLedgerEntries(r.Context(), sellerRef)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payouts, err := q.ListPayoutsBySeller(r.Context(), sellerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Entries are shown from the seller's side: credits to their account are positive
+	entryResp := make([]mytypes.LedgerEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		entry := mytypes.LedgerEntryResponse{
+			ID:          e.ID.String(),
+			Kind:        string(e.Kind),
+			Amount:      e.Amount.Neg().String(),
+			Description: e.Description,
+			CreatedAt:   e.CreatedAt,
+		}
+		if e.OrderID.Valid {
+			entry.OrderID = e.OrderID.UUID.String()
+		}
+		if e.SellerOrderID.Valid {
+			entry.SellerOrderID = e.SellerOrderID.UUID.String()
+		}
+		if e.PayoutID.Valid {
+			entry.PayoutID = e.PayoutID.UUID.String()
+		}
+		entryResp = append(entryResp, entry)
+	}
+
+	payoutResp := make([]mytypes.PayoutResponse, 0, len(payouts))
+	for _, p := range payouts {
+		payoutResp = append(payoutResp, mytypes.NewPayoutResponse(p))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"seller_id":   sellerID,
+		"balance":     balance.Balance.StringFixed(2),
+		"sales":       balance.Sales.StringFixed(2),
+		"commission":  balance.Commission.StringFixed(2),
+		"refunds":     balance.Refunds.StringFixed(2),
+		"payouts":     payoutResp,
+		"entries":     entryResp,
+		"page":        page,
+		"limit":       limit,
+		"total_items": total,
+		"total_pages": (int(total) + limit - 1) / limit,
+	})
+}
+
+func handleListCommissionRates(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	rates, err := q.ListCommissionRates(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.CommissionRateResponse, 0, len(rates))
+	for _, c := range rates {
+		resp = append(resp, toCommissionRateResponse(c))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleUpsertCommissionRate sets the commission for a seller, a category, both, or the
+// platform default when neither is given.
+func handleUpsertCommissionRate(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	var payload mytypes.CommissionRatePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	var sellerID uuid.NullUUID
+	if payload.SellerID != "" {
+		id, err := uuid.Parse(payload.SellerID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller id"))
+			return
+		}
+		sellerID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	category, err := products.ParseCategory(payload.Category)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rate, err := decimal.NewFromString(payload.Rate)
+	if err != nil || rate.IsNegative() || rate.GreaterThan(decimal.NewFromInt(1)) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("rate must be a fraction between 0 and 1"))
+		return
+	}
+
+	commissionRate, err := q.UpsertCommissionRate(r.Context(), database.UpsertCommissionRateParams{
+		SellerID: sellerID,
+		Category: category,
+		Rate:     rate,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, toCommissionRateResponse(commissionRate))
+}
+
+func handleDeleteCommissionRate(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	rateID, err := uuid.Parse(chi.URLParam(r, "rateID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid commission rate id"))
+		return
+	}
+
+	rows, err := q.DeleteCommissionRate(r.Context(), rateID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("commission rate not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "commission rate deleted"})
+}
+
+// handleRunPayouts pays out every seller's balance in one batch and returns the
+// settlement report.
+func handleRunPayouts(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+	adminID, _ := uuid.Parse(claims.UserID)
+
+	var payload mytypes.RunPayoutsPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	minAmount := decimal.NewFromInt(0)
+	if payload.MinAmount != "" {
+		minAmount, err = decimal.NewFromString(payload.MinAmount)
+		if err != nil || minAmount.IsNegative() {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid min_amount"))
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	batch, payouts, err := RunPayouts(r.Context(), qtx, adminID, minAmount)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, toPayoutBatchResponse(batch, payouts))
+}
+
+func handleListPayoutBatches(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	page, limit := pagination(r)
+	batches, err := q.ListPayoutBatches(r.Context(), database.ListPayoutBatchesParams{
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	total, err := q.CountPayoutBatches(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.PayoutBatchResponse, 0, len(batches))
+	for _, b := range batches {
+		resp = append(resp, toPayoutBatchResponse(b, nil))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"batches":     resp,
+		"page":        page,
+		"limit":       limit,
+		"total_items": total,
+		"total_pages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// handleGetPayoutBatch returns a batch's settlement report, as JSON or with ?format=csv
+// one row per seller.
+func handleGetPayoutBatch(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	batchID, err := uuid.Parse(chi.URLParam(r, "batchID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid batch id"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "csv" && format != "json" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("format must be csv or json"))
+		return
+	}
+
+	batch, err := q.GetPayoutBatch(r.Context(), batchID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("payout batch not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payouts, err := q.ListPayoutsByBatch(r.Context(), batch.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if format == "json" {
+		utils.RespondWithJSON(w, http.StatusOK, toPayoutBatchResponse(batch, payouts))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=settlement-%s.csv", batch.ID))
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"seller_id", "sales", "commission", "refunds", "amount"})
+	for _, p := range payouts {
+		writer.Write([]string{
+			p.SellerID.String(),
+			p.Sales.StringFixed(2),
+			p.Commission.StringFixed(2),
+			p.Refunds.StringFixed(2),
+			p.Amount.StringFixed(2),
+		})
+	}
+	writer.Flush()
+}
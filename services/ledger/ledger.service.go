@@ -0,0 +1,416 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Posting is one line of a ledger transaction. Debits are positive and credits negative,
+// so the postings of a transaction add up to zero.
+//
+// platform_cash is the money the platform holds, seller_payable what it owes each seller
+// and platform_revenue what it keeps: commission and shipping.
+type Posting struct {
+	Account  database.LedgerAccount
+	SellerID uuid.UUID // seller_payable only
+	Amount   decimal.Decimal
+}
+
+// Post records a balanced transaction. Postings of zero are left out and nothing is
+// recorded when all of them are zero.
+func Post(ctx context.Context, qtx *database.Queries, params database.CreateLedgerTransactionParams, postings ...Posting) error {
+	sum := decimal.NewFromInt(0)
+	nonZero := 0
+	for _, p := range postings {
+		sum = sum.Add(p.Amount)
+		if !p.Amount.IsZero() {
+			nonZero++
+		}
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("ledger transaction is unbalanced by %s", sum.String())
+	}
+	if nonZero == 0 {
+		return nil
+	}
+
+	if err := qtx.LockLedgerShared(ctx); err != nil {
+		return err
+	}
+
+	t, err := qtx.CreateLedgerTransaction(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range postings {
+		if p.Amount.IsZero() {
+			continue
+		}
+		seller := uuid.NullUUID{}
+		if p.Account == database.LedgerAccountSellerPayable {
+			seller = uuid.NullUUID{UUID: p.SellerID, Valid: true}
+		}
+		if err := qtx.CreateLedgerEntry(ctx, database.CreateLedgerEntryParams{
+			TransactionID: t.ID,
+			Account:       p.Account,
+			SellerID:      seller,
+			Amount:        p.Amount,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CommissionRate returns the platform's cut of a seller's sales in a category: the most
+// specific rule in commission_rates, or nothing when no rule matches.
+func CommissionRate(ctx context.Context, q *database.Queries, sellerID uuid.UUID, category string) (decimal.Decimal, error) {
+	rule, err := q.FindCommissionRate(ctx, database.FindCommissionRateParams{
+		SellerID: uuid.NullUUID{UUID: sellerID, Valid: true},
+		Category: category,
+	})
+	if err == sql.ErrNoRows {
+		return decimal.NewFromInt(0), nil
+	}
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return rule.Rate, nil
+}
+
+// RecordSale books a paid order: every seller is owed their sub-order's total less the
+// platform commission, and shipping is platform revenue. Orders are only booked once,
+// and orders placed before they were split by seller are skipped.
+func RecordSale(ctx context.Context, qtx *database.Queries, order database.Order) error {
+	orderRef := uuid.NullUUID{UUID: order.ID, Valid: true}
+	booked, err := qtx.CountOrderSales(ctx, orderRef)
+	if err != nil {
+		return err
+	}
+	if booked > 0 {
+		return nil
+	}
+
+	subs, err := qtx.ListSellerOrdersByOrder(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	sellersTotal := decimal.NewFromInt(0)
+	for _, sub := range subs {
+		subRef := uuid.NullUUID{UUID: sub.ID, Valid: true}
+		sellersTotal = sellersTotal.Add(sub.Total)
+
+		if err := Post(ctx, qtx, database.CreateLedgerTransactionParams{
+			Kind:          database.LedgerTransactionKindSale,
+			OrderID:       orderRef,
+			SellerOrderID: subRef,
+			Description:   "sale",
+		},
+			Posting{Account: database.LedgerAccountPlatformCash, Amount: sub.Total},
+			Posting{Account: database.LedgerAccountSellerPayable, SellerID: sub.SellerID, Amount: sub.Total.Neg()},
+		); err != nil {
+			return err
+		}
+
+		fee, err := commission(ctx, qtx, order, sub)
+		if err != nil {
+			return err
+		}
+		if err := Post(ctx, qtx, database.CreateLedgerTransactionParams{
+			Kind:          database.LedgerTransactionKindCommission,
+			OrderID:       orderRef,
+			SellerOrderID: subRef,
+			Description:   "platform commission",
+		},
+			Posting{Account: database.LedgerAccountSellerPayable, SellerID: sub.SellerID, Amount: fee},
+			Posting{Account: database.LedgerAccountPlatformRevenue, Amount: fee.Neg()},
+		); err != nil {
+			return err
+		}
+	}
+
+	// Whatever the customer paid on top of the sellers' items is shipping
+	shipping := order.TotalPrice.Sub(sellersTotal)
+	if shipping.IsPositive() {
+		if err := Post(ctx, qtx, database.CreateLedgerTransactionParams{
+			Kind:        database.LedgerTransactionKindSale,
+			OrderID:     orderRef,
+			Description: "shipping",
+		},
+			Posting{Account: database.LedgerAccountPlatformCash, Amount: shipping},
+			Posting{Account: database.LedgerAccountPlatformRevenue, Amount: shipping.Neg()},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commission works out the platform's cut of a sub-order. Each line is charged the rate
+// for its product's category on what the customer paid for it, tax excluded.
+func commission(ctx context.Context, qtx *database.Queries, order database.Order, sub database.SellerOrder) (decimal.Decimal, error) {
+	items, err := qtx.ListSellerOrderItems(ctx, uuid.NullUUID{UUID: sub.ID, Valid: true})
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	rates := make(map[string]decimal.Decimal)
+	for _, item := range items {
+		if _, ok := rates[item.ProductCategory]; ok {
+			continue
+		}
+		rate, err := CommissionRate(ctx, qtx, sub.SellerID, item.ProductCategory)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		rates[item.ProductCategory] = rate
+	}
+	return commissionFee(items, rates, order.PricesIncludeTax), nil
+}
+
+// commissionFee charges each line the rate of its category, rounded once for the whole
+// sub-order.
+func commissionFee(items []database.ListSellerOrderItemsRow, rates map[string]decimal.Decimal, pricesIncludeTax bool) decimal.Decimal {
+	fee := decimal.NewFromInt(0)
+	for _, item := range items {
+		base := item.Price.Mul(decimal.NewFromInt(int64(item.Quantity))).Sub(item.DiscountAmount)
+		if pricesIncludeTax {
+			base = base.Sub(item.TaxAmount)
+		}
+		fee = fee.Add(base.Mul(rates[item.ProductCategory]))
+	}
+	return fee.Round(2)
+}
+
+// RecordRefund books money refunded to the customer against the sellers who sold the
+// items. sellerShares says how to split the refund between sellers, e.g. the value of
+// the returned items per seller; when nil it is split in proportion to what is still
+// unrefunded of each sub-order and of shipping. Sellers get back the matching part of
+// the commission. Refunds beyond what was booked come out of platform revenue.
+func RecordRefund(ctx context.Context, qtx *database.Queries, order database.Order, amount decimal.Decimal, sellerShares map[uuid.UUID]decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	orderRef := uuid.NullUUID{UUID: order.ID, Valid: true}
+	booked, err := qtx.CountOrderSales(ctx, orderRef)
+	if err != nil {
+		return err
+	}
+	if booked == 0 {
+		return nil
+	}
+
+	subs, err := qtx.ListSellerOrdersByOrder(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	refundedRows, err := qtx.ListOrderRefundedAmounts(ctx, orderRef)
+	if err != nil {
+		return err
+	}
+	refunded := make(map[uuid.UUID]decimal.Decimal, len(refundedRows))
+	for _, row := range refundedRows {
+		refunded[row.SellerOrderID.UUID] = row.Refunded // uuid.Nil for shipping
+	}
+
+	commissionRows, err := qtx.ListOrderCommissions(ctx, orderRef)
+	if err != nil {
+		return err
+	}
+	commissions := make(map[uuid.UUID]decimal.Decimal, len(commissionRows))
+	for _, row := range commissionRows {
+		commissions[row.SellerOrderID.UUID] = row.Commission
+	}
+
+	shares, remaining, excess := refundShares(order, subs, refunded, amount, sellerShares)
+	last := len(subs)
+
+	for i, sub := range subs {
+		share := shares[i]
+		if !share.IsPositive() {
+			continue
+		}
+		subRef := uuid.NullUUID{UUID: sub.ID, Valid: true}
+
+		if err := Post(ctx, qtx, database.CreateLedgerTransactionParams{
+			Kind:          database.LedgerTransactionKindRefund,
+			OrderID:       orderRef,
+			SellerOrderID: subRef,
+			Description:   "refund",
+		},
+			Posting{Account: database.LedgerAccountSellerPayable, SellerID: sub.SellerID, Amount: share},
+			Posting{Account: database.LedgerAccountPlatformCash, Amount: share.Neg()},
+		); err != nil {
+			return err
+		}
+
+		// The commission still held on the sub-order is given back in proportion
+		fee := commissions[sub.ID].Mul(share).Div(remaining[i]).Round(2)
+		if err := Post(ctx, qtx, database.CreateLedgerTransactionParams{
+			Kind:          database.LedgerTransactionKindCommission,
+			OrderID:       orderRef,
+			SellerOrderID: subRef,
+			Description:   "commission refunded",
+		},
+			Posting{Account: database.LedgerAccountPlatformRevenue, Amount: fee},
+			Posting{Account: database.LedgerAccountSellerPayable, SellerID: sub.SellerID, Amount: fee.Neg()},
+		); err != nil {
+			return err
+		}
+	}
+
+	platformShare := shares[last].Add(excess)
+	return Post(ctx, qtx, database.CreateLedgerTransactionParams{
+		Kind:        database.LedgerTransactionKindRefund,
+		OrderID:     orderRef,
+		Description: "shipping refund",
+	},
+		Posting{Account: database.LedgerAccountPlatformRevenue, Amount: platformShare},
+		Posting{Account: database.LedgerAccountPlatformCash, Amount: platformShare.Neg()},
+	)
+}
+
+// refundShares works out how much of a refund each sub-order takes, with shipping in the
+// last slot, given what was already refunded of each (keyed by sub-order id, uuid.Nil for
+// shipping). It also returns what is still refundable per slot and the excess that fits
+// nowhere. See RecordRefund for sellerShares.
+func refundShares(order database.Order, subs []database.SellerOrder, refunded map[uuid.UUID]decimal.Decimal, amount decimal.Decimal, sellerShares map[uuid.UUID]decimal.Decimal) (shares, remaining []decimal.Decimal, excess decimal.Decimal) {
+	remaining = make([]decimal.Decimal, len(subs)+1)
+	weights := make([]decimal.Decimal, len(subs)+1)
+	shipping := order.TotalPrice
+	for i, sub := range subs {
+		shipping = shipping.Sub(sub.Total)
+		remaining[i] = decimal.Max(sub.Total.Sub(refunded[sub.ID]), decimal.NewFromInt(0))
+		weights[i] = remaining[i]
+		if sellerShares != nil {
+			weights[i] = decimal.Min(sellerShares[sub.SellerID], remaining[i])
+		}
+	}
+	last := len(subs)
+	remaining[last] = decimal.Max(shipping.Sub(refunded[uuid.Nil]), decimal.NewFromInt(0))
+	weights[last] = remaining[last]
+	if sellerShares != nil {
+		weights[last] = decimal.NewFromInt(0)
+	}
+
+	shares = split(amount, weights, remaining)
+	excess = amount
+	for _, share := range shares {
+		excess = excess.Sub(share)
+	}
+	return shares, remaining, excess
+}
+
+// split divides amount in proportion to the weights, never giving a slot more than its
+// limit. Rounding leftovers go to the last slot with room, and whatever doesn't fit is
+// left over.
+func split(amount decimal.Decimal, weights, limits []decimal.Decimal) []decimal.Decimal {
+	parts := make([]decimal.Decimal, len(weights))
+	total := decimal.NewFromInt(0)
+	for i := range weights {
+		parts[i] = decimal.NewFromInt(0)
+		total = total.Add(weights[i])
+	}
+	if !total.IsPositive() {
+		return parts
+	}
+
+	amount = decimal.Min(amount, total)
+	left := amount
+	for i, w := range weights {
+		if !w.IsPositive() {
+			continue
+		}
+		parts[i] = decimal.Min(amount.Mul(w).Div(total).Round(2), limits[i])
+		left = left.Sub(parts[i])
+	}
+	for i := len(parts) - 1; i >= 0 && !left.IsZero(); i-- {
+		if !weights[i].IsPositive() {
+			continue
+		}
+		adjusted := decimal.Max(decimal.Min(parts[i].Add(left), limits[i]), decimal.NewFromInt(0))
+		left = left.Sub(adjusted.Sub(parts[i]))
+		parts[i] = adjusted
+	}
+	return parts
+}
+
+// RunPayouts pays every seller owed at least minAmount: each balance is moved out of
+// seller_payable, the settled entries are marked with the payout and the batch records
+// the totals. Posting waits while a run holds the ledger lock, so no entry can be settled
+// without being paid.
+func RunPayouts(ctx context.Context, qtx *database.Queries, adminID uuid.UUID, minAmount decimal.Decimal) (database.PayoutBatch, []database.Payout, error) {
+	if err := qtx.LockLedger(ctx); err != nil {
+		return database.PayoutBatch{}, nil, err
+	}
+
+	sellers, err := qtx.ListPayableSellers(ctx, minAmount)
+	if err != nil {
+		return database.PayoutBatch{}, nil, err
+	}
+
+	batch, err := qtx.CreatePayoutBatch(ctx, uuid.NullUUID{UUID: adminID, Valid: true})
+	if err != nil {
+		return database.PayoutBatch{}, nil, err
+	}
+
+	paid := make([]database.Payout, 0, len(sellers))
+	total := decimal.NewFromInt(0)
+	for _, s := range sellers {
+		payout, err := qtx.CreatePayout(ctx, database.CreatePayoutParams{
+			BatchID:    batch.ID,
+			SellerID:   s.SellerID.UUID,
+			Sales:      s.Sales,
+			Commission: s.Commission,
+			Refunds:    s.Refunds,
+			Amount:     s.Balance,
+		})
+		if err != nil {
+			return database.PayoutBatch{}, nil, err
+		}
+		payoutRef := uuid.NullUUID{UUID: payout.ID, Valid: true}
+
+		if err := Post(ctx, qtx, database.CreateLedgerTransactionParams{
+			Kind:        database.LedgerTransactionKindPayout,
+			PayoutID:    payoutRef,
+			Description: fmt.Sprintf("payout batch %s", batch.ID),
+		},
+			Posting{Account: database.LedgerAccountSellerPayable, SellerID: payout.SellerID, Amount: payout.Amount},
+			Posting{Account: database.LedgerAccountPlatformCash, Amount: payout.Amount.Neg()},
+		); err != nil {
+			return database.PayoutBatch{}, nil, err
+		}
+
+		if _, err := qtx.SettleSellerEntries(ctx, database.SettleSellerEntriesParams{
+			SellerID: s.SellerID,
+			PayoutID: payoutRef,
+		}); err != nil {
+			return database.PayoutBatch{}, nil, err
+		}
+
+		paid = append(paid, payout)
+		total = total.Add(payout.Amount)
+	}
+
+	batch, err = qtx.UpdatePayoutBatchTotals(ctx, database.UpdatePayoutBatchTotalsParams{
+		ID:          batch.ID,
+		SellerCount: int32(len(paid)),
+		Total:       total,
+	})
+	if err != nil {
+		return database.PayoutBatch{}, nil, err
+	}
+	return batch, paid, nil
+}
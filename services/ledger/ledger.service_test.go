@@ -0,0 +1,209 @@
+package ledger
+
+import (
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func decs(values ...string) []decimal.Decimal {
+	out := make([]decimal.Decimal, len(values))
+	for i, v := range values {
+		out[i] = dec(v)
+	}
+	return out
+}
+
+func equalDecs(a, b []decimal.Decimal) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		weights []decimal.Decimal
+		limits  []decimal.Decimal
+		want    []decimal.Decimal
+	}{
+		{"proportional", "100", decs("60", "40"), decs("60", "40"), decs("60", "40")},
+		{"partial", "50", decs("60", "40"), decs("60", "40"), decs("30", "20")},
+		{"rounding leftover goes last", "10", decs("10", "10", "10"), decs("10", "10", "10"), decs("3.33", "3.33", "3.34")},
+		{"limit moves the rest to a slot with room", "50", decs("30", "30"), decs("10", "100"), decs("10", "40")},
+		{"never more than the weights", "200", decs("60", "40"), decs("60", "40"), decs("60", "40")},
+		{"zero weight gets nothing", "20", decs("0", "50"), decs("100", "50"), decs("0", "20")},
+		{"no weights", "20", decs("0", "0"), decs("10", "10"), decs("0", "0")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := split(dec(tt.amount), tt.weights, tt.limits)
+			if !equalDecs(got, tt.want) {
+				t.Errorf("split(%s, %v, %v) = %v, want %v", tt.amount, tt.weights, tt.limits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommissionFee(t *testing.T) {
+	rates := map[string]decimal.Decimal{
+		"":       dec("0.10"),
+		"books":  dec("0.15"),
+		"phones": dec("0.05"),
+	}
+	item := func(category, price string, quantity int32, discount, tax string) database.ListSellerOrderItemsRow {
+		return database.ListSellerOrderItemsRow{
+			ProductCategory: category,
+			Price:           dec(price),
+			Quantity:        quantity,
+			DiscountAmount:  dec(discount),
+			TaxAmount:       dec(tax),
+		}
+	}
+
+	tests := []struct {
+		name             string
+		items            []database.ListSellerOrderItemsRow
+		pricesIncludeTax bool
+		want             string
+	}{
+		{"charged after discounts", []database.ListSellerOrderItemsRow{item("", "100", 2, "20", "36")}, false, "18"},
+		{"tax excluded when included in prices", []database.ListSellerOrderItemsRow{item("", "120", 1, "0", "20")}, true, "10"},
+		{"rate per category", []database.ListSellerOrderItemsRow{
+			item("books", "20", 1, "0", "0"),
+			item("phones", "500", 1, "0", "0"),
+			item("", "10", 3, "0", "0"),
+		}, false, "31"},
+		{"category without a rate", []database.ListSellerOrderItemsRow{item("garden", "50", 1, "0", "0")}, false, "0"},
+		{"rounded once per sub-order", []database.ListSellerOrderItemsRow{
+			item("", "3.33", 1, "0", "0"),
+			item("", "3.33", 1, "0", "0"),
+			item("", "3.33", 1, "0", "0"),
+		}, false, "1"},
+		{"no items", nil, false, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commissionFee(tt.items, rates, tt.pricesIncludeTax)
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("commissionFee() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefundShares(t *testing.T) {
+	sellerA, sellerB := uuid.New(), uuid.New()
+	subA := database.SellerOrder{ID: uuid.New(), SellerID: sellerA, Total: dec("60")}
+	subB := database.SellerOrder{ID: uuid.New(), SellerID: sellerB, Total: dec("45")}
+	subs := []database.SellerOrder{subA, subB}
+	// 60 + 45 from the sellers and 10 shipping
+	order := database.Order{ID: uuid.New(), TotalPrice: dec("115")}
+
+	tests := []struct {
+		name          string
+		amount        string
+		refunded      map[uuid.UUID]decimal.Decimal
+		sellerShares  map[uuid.UUID]decimal.Decimal
+		wantShares    []decimal.Decimal // subA, subB, shipping
+		wantRemaining []decimal.Decimal
+		wantExcess    string
+	}{
+		{
+			name:          "one seller's return",
+			amount:        "30",
+			sellerShares:  map[uuid.UUID]decimal.Decimal{sellerA: dec("30")},
+			wantShares:    decs("30", "0", "0"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "0",
+		},
+		{
+			name:          "return from both sellers",
+			amount:        "40",
+			sellerShares:  map[uuid.UUID]decimal.Decimal{sellerA: dec("30"), sellerB: dec("10")},
+			wantShares:    decs("30", "10", "0"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "0",
+		},
+		{
+			name:          "lower amount split by the shares",
+			amount:        "20",
+			sellerShares:  map[uuid.UUID]decimal.Decimal{sellerA: dec("30"), sellerB: dec("10")},
+			wantShares:    decs("15", "5", "0"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "0",
+		},
+		{
+			name:          "share capped by earlier refunds",
+			amount:        "30",
+			refunded:      map[uuid.UUID]decimal.Decimal{subA.ID: dec("50")},
+			sellerShares:  map[uuid.UUID]decimal.Decimal{sellerA: dec("30")},
+			wantShares:    decs("10", "0", "0"),
+			wantRemaining: decs("10", "45", "10"),
+			wantExcess:    "20",
+		},
+		{
+			name:          "shipping is not refunded with seller shares",
+			amount:        "115",
+			sellerShares:  map[uuid.UUID]decimal.Decimal{sellerA: dec("60"), sellerB: dec("45")},
+			wantShares:    decs("60", "45", "0"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "10",
+		},
+		{
+			name:          "without shares in proportion including shipping",
+			amount:        "23",
+			wantShares:    decs("12", "9", "2"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "0",
+		},
+		{
+			name:          "full refund",
+			amount:        "115",
+			wantShares:    decs("60", "45", "10"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "0",
+		},
+		{
+			name:          "more than the order",
+			amount:        "130",
+			wantShares:    decs("60", "45", "10"),
+			wantRemaining: decs("60", "45", "10"),
+			wantExcess:    "15",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refunded := tt.refunded
+			if refunded == nil {
+				refunded = map[uuid.UUID]decimal.Decimal{}
+			}
+			shares, remaining, excess := refundShares(order, subs, refunded, dec(tt.amount), tt.sellerShares)
+			if !equalDecs(shares, tt.wantShares) {
+				t.Errorf("shares = %v, want %v", shares, tt.wantShares)
+			}
+			if !equalDecs(remaining, tt.wantRemaining) {
+				t.Errorf("remaining = %v, want %v", remaining, tt.wantRemaining)
+			}
+			if !excess.Equal(dec(tt.wantExcess)) {
+				t.Errorf("excess = %s, want %s", excess, tt.wantExcess)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package ledger
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the marketplace ledger: sellers check what they are owed, admins set
+// commission rates and pay sellers out in batches.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Use(utils.AuthMiddleware)
+
+	r.Get("/balance", func(w http.ResponseWriter, r *http.Request) {
+		handleGetBalance(w, r, q)
+	})
+
+	// admin routes
+	r.Get("/commissions", func(w http.ResponseWriter, r *http.Request) {
+		handleListCommissionRates(w, r, q)
+	})
+
+	r.Put("/commissions", func(w http.ResponseWriter, r *http.Request) {
+		handleUpsertCommissionRate(w, r, q)
+	})
+
+	r.Delete("/commissions/{rateID}", func(w http.ResponseWriter, r *http.Request) {
+		handleDeleteCommissionRate(w, r, q)
+	})
+
+	r.Post("/payouts", func(w http.ResponseWriter, r *http.Request) {
+		handleRunPayouts(w, r, db)
+	})
+
+	r.Get("/payouts", func(w http.ResponseWriter, r *http.Request) {
+		handleListPayoutBatches(w, r, q)
+	})
+
+	r.Get("/payouts/{batchID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetPayoutBatch(w, r, q)
+	})
+
+	return r
+}
@@ -0,0 +1,231 @@
+package notifications
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func handleListNotifications(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	page := 1
+	limit := 20
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	rows, err := q.ListNotificationsByUser(r.Context(), database.ListNotificationsByUserParams{
+		UserID: userID,
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list notifications"))
+		return
+	}
+
+	unread, err := q.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("error counting notifications"))
+		return
+	}
+
+	responseNotifications := []mytypes.NotificationResponse{}
+	for _, n := range rows {
+		resp := mytypes.NotificationResponse{
+			ID:        n.ID.String(),
+			Type:      n.Type,
+			Title:     n.Title,
+			Body:      n.Body,
+			CreatedAt: n.CreatedAt,
+		}
+		if n.ReadAt.Valid {
+			resp.ReadAt = &n.ReadAt.Time
+		}
+		responseNotifications = append(responseNotifications, resp)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data":         responseNotifications,
+		"unread_count": unread,
+		"page":         page,
+		"limit":        limit,
+	})
+}
+
+func handleMarkNotificationRead(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	notificationID, err := uuid.Parse(chi.URLParam(r, "notificationID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid notification id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	affected, err := q.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("notification not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "notification marked as read",
+	})
+}
+
+func handleGetPreferences(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	prefs, err := Preferences(r.Context(), q, userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to load notification preferences"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func handleUpdatePreferences(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	var payload mytypes.UpdateNotificationPreferencesPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(payload.Preferences) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("preferences are required"))
+		return
+	}
+
+	var updates []database.UpsertNotificationPreferenceParams
+	for _, p := range payload.Preferences {
+		category, err := ParseCategory(p.Category)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+		for channel, enabled := range map[string]*bool{ChannelEmail: p.Email, ChannelInApp: p.InApp} {
+			if enabled != nil {
+				updates = append(updates, database.UpsertNotificationPreferenceParams{
+					UserID:   userID,
+					Category: category,
+					Channel:  channel,
+					Enabled:  *enabled,
+				})
+			}
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+	for _, u := range updates {
+		if err := qtx.UpsertNotificationPreference(r.Context(), u); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update notification preferences"))
+			return
+		}
+	}
+	prefs, err := Preferences(r.Context(), qtx, userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to load notification preferences"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+// handleUnsubscribe serves the link in the footer of every email. It needs no login:
+// the signed token says who and what to unsubscribe. Mail clients POST to it for
+// one-click unsubscribe; people opening the link GET it and see a short confirmation.
+func handleUnsubscribe(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, category, err := ParseUnsubscribeToken(r.URL.Query().Get("token"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	if category != "" {
+		if category, err = ParseCategory(category); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid unsubscribe token"))
+			return
+		}
+	}
+
+	if err := Unsubscribe(r.Context(), q, userID, category); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to unsubscribe"))
+		return
+	}
+
+	what := "emails"
+	if category != "" {
+		what = category + " emails"
+	}
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "<!DOCTYPE html><html><body style=\"font-family: sans-serif;\"><p>You won't get %s from us anymore. You can turn them back on in your notification settings.</p></body></html>", what)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "unsubscribed from " + what,
+	})
+}
@@ -0,0 +1,318 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"os"
+	"strings"
+	texttemplate "text/template"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/jobs"
+	"github.com/google/uuid"
+)
+
+// Email templates
+const (
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplateShipment          = "shipment"
+	TemplatePasswordReset     = "password_reset"
+	TemplatePriceDrop         = "price_drop"
+)
+
+// EmailItem is a line in an order or shipment email.
+type EmailItem struct {
+	Name     string
+	Quantity int32
+	Total    string // Empty when the email doesn't show prices
+}
+
+// OrderConfirmationData fills TemplateOrderConfirmation.
+type OrderConfirmationData struct {
+	Name     string
+	OrderID  string
+	Items    []EmailItem
+	Subtotal string
+	Discount string // Empty without a discount
+	Shipping string
+	Tax      string
+	Total    string
+	OrderURL string
+}
+
+// ShipmentData fills TemplateShipment.
+type ShipmentData struct {
+	Name           string
+	OrderID        string
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+	Items          []EmailItem
+}
+
+// PriceDropData fills TemplatePriceDrop.
+type PriceDropData struct {
+	Name       string
+	Product    string
+	Wishlist   string
+	OldPrice   string
+	NewPrice   string
+	ProductURL string
+}
+
+// PasswordResetData fills TemplatePasswordReset.
+type PasswordResetData struct {
+	Name     string
+	ResetURL string
+}
+
+type emailTemplate struct {
+	category string
+	data     func() any // A new value of the template's data type, to decode queued data into
+	subject  *texttemplate.Template
+	text     *texttemplate.Template
+	html     *htmltemplate.Template
+}
+
+// emailView is what the bodies render: the template's data, plus the unsubscribe link
+// for the footer.
+type emailView struct {
+	Data        any
+	Unsubscribe string
+}
+
+var emailLayout = htmltemplate.Must(htmltemplate.New("layout").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"></head>
+<body style="font-family: Helvetica, Arial, sans-serif; font-size: 14px; color: #222; margin: 0; padding: 24px; background: #f6f6f6;">
+<div style="max-width: 560px; margin: 0 auto; background: #fff; padding: 24px;">
+{{template "content" .Data}}
+</div>
+{{if .Unsubscribe}}<p style="max-width: 560px; margin: 16px auto; font-size: 12px; color: #777;">You get this email because of your notification settings. <a href="{{.Unsubscribe}}" style="color: #777;">Unsubscribe</a>.</p>{{end}}
+</body>
+</html>
+`))
+
+// textFooter follows every plain text body.
+const textFooter = `{{if .Unsubscribe}}
+--
+Unsubscribe: {{.Unsubscribe}}
+{{end}}`
+
+// newEmailTemplate parses a template's parts. The subject gets the data; both bodies get
+// an emailView, with the data as dot inside the body.
+func newEmailTemplate[T any](category, subject, text, html string) emailTemplate {
+	return emailTemplate{
+		category: category,
+		data:     func() any { return new(T) },
+		subject:  texttemplate.Must(texttemplate.New("subject").Parse(subject)),
+		text:     texttemplate.Must(texttemplate.New("text").Parse(`{{with .Data}}` + text + `{{end}}` + textFooter)),
+		html:     htmltemplate.Must(htmltemplate.Must(emailLayout.Clone()).Parse(`{{define "content"}}` + html + `{{end}}`)),
+	}
+}
+
+var emailTemplates = map[string]emailTemplate{
+	TemplateOrderConfirmation: newEmailTemplate[OrderConfirmationData](CategoryOrders,
+		`We received your order {{.OrderID}}`,
+		`Hi {{.Name}},
+
+Thanks for your order. We'll let you know when it ships.
+
+Order {{.OrderID}}{{range .Items}}
+  {{.Quantity}} x {{.Name}}  {{.Total}}{{end}}
+
+Subtotal  {{.Subtotal}}{{if .Discount}}
+Discount  -{{.Discount}}{{end}}
+Shipping  {{.Shipping}}
+Tax       {{.Tax}}
+Total     {{.Total}}
+{{if .OrderURL}}
+View your order: {{.OrderURL}}
+{{end}}`,
+		`<h2 style="margin-top: 0;">Thanks for your order, {{.Name}}</h2>
+<p>We received order <strong>{{.OrderID}}</strong> and will let you know when it ships.</p>
+<table style="width: 100%; border-collapse: collapse;">
+{{range .Items}}<tr><td style="padding: 4px 0;">{{.Quantity}} &times; {{.Name}}</td><td style="padding: 4px 0; text-align: right;">{{.Total}}</td></tr>
+{{end}}<tr><td style="padding-top: 12px;">Subtotal</td><td style="padding-top: 12px; text-align: right;">{{.Subtotal}}</td></tr>
+{{if .Discount}}<tr><td>Discount</td><td style="text-align: right;">-{{.Discount}}</td></tr>
+{{end}}<tr><td>Shipping</td><td style="text-align: right;">{{.Shipping}}</td></tr>
+<tr><td>Tax</td><td style="text-align: right;">{{.Tax}}</td></tr>
+<tr><td><strong>Total</strong></td><td style="text-align: right;"><strong>{{.Total}}</strong></td></tr>
+</table>
+{{if .OrderURL}}<p><a href="{{.OrderURL}}">View your order</a></p>{{end}}`),
+
+	TemplateShipment: newEmailTemplate[ShipmentData](CategoryShipping,
+		`Your order {{.OrderID}} has shipped`,
+		`Hi {{.Name}},
+
+Items from order {{.OrderID}} are on their way with {{.Carrier}}.{{range .Items}}
+  {{.Quantity}} x {{.Name}}{{end}}
+
+Tracking number: {{.TrackingNumber}}{{if .TrackingURL}}
+Track it: {{.TrackingURL}}{{end}}
+`,
+		`<h2 style="margin-top: 0;">Your order is on its way, {{.Name}}</h2>
+<p>Items from order <strong>{{.OrderID}}</strong> have shipped with {{.Carrier}}.</p>
+<ul>
+{{range .Items}}<li>{{.Quantity}} &times; {{.Name}}</li>
+{{end}}</ul>
+<p>Tracking number: <strong>{{.TrackingNumber}}</strong></p>
+{{if .TrackingURL}}<p><a href="{{.TrackingURL}}">Track your parcel</a></p>{{end}}`),
+
+	TemplatePriceDrop: newEmailTemplate[PriceDropData](CategoryWishlist,
+		`{{.Product}} is now {{.NewPrice}}`,
+		`Hi {{.Name}},
+
+{{.Product}} from your wishlist "{{.Wishlist}}" dropped from {{.OldPrice}} to {{.NewPrice}}.
+
+See it: {{.ProductURL}}
+`,
+		`<h2 style="margin-top: 0;">A price dropped on your wishlist</h2>
+<p>Hi {{.Name}}, <strong>{{.Product}}</strong> from your wishlist &ldquo;{{.Wishlist}}&rdquo; dropped from <s>{{.OldPrice}}</s> to <strong>{{.NewPrice}}</strong>.</p>
+<p><a href="{{.ProductURL}}" style="display: inline-block; padding: 10px 16px; background: #222; color: #fff; text-decoration: none;">See it</a></p>`),
+
+	TemplatePasswordReset: newEmailTemplate[PasswordResetData](CategoryAccount,
+		`Reset your password`,
+		`Hi {{.Name}},
+
+Someone asked to reset the password of your account. If it was you, open this link
+within an hour to choose a new one:
+
+{{.ResetURL}}
+
+If it wasn't you, ignore this email; your password stays the same.
+`,
+		`<h2 style="margin-top: 0;">Reset your password</h2>
+<p>Hi {{.Name}}, someone asked to reset the password of your account. If it was you, use the link within an hour to choose a new one.</p>
+<p><a href="{{.ResetURL}}" style="display: inline-block; padding: 10px 16px; background: #222; color: #fff; text-decoration: none;">Choose a new password</a></p>
+<p style="color: #777;">If it wasn't you, ignore this email; your password stays the same.</p>`),
+}
+
+// emailPayload is what an email job stores: the template, who it is for and the data
+// to fill it with. The email is rendered when the job runs, so jobs never hold the
+// finished text. Secrets such as password reset links must not be in Data; send those
+// with Send.
+type emailPayload struct {
+	Template string          `json:"template"`
+	UserID   uuid.UUID       `json:"user_id"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// emailJob renders and sends an email. Emails go out through the job queue so a slow
+// or unreachable mail server only delays them.
+var emailJob = jobs.Type[emailPayload]{Kind: "notifications.email"}
+
+// RegisterJobs adds the email sender to the job pool.
+func RegisterJobs(pool *jobs.Pool, mailer Mailer) {
+	jobs.Handle(pool, emailJob, func(ctx context.Context, db *sql.DB, p emailPayload) error {
+		t, ok := emailTemplates[p.Template]
+		if !ok {
+			return jobs.Permanent(fmt.Errorf("unknown email template %q", p.Template))
+		}
+		data := t.data()
+		if err := json.Unmarshal(p.Data, data); err != nil {
+			return jobs.Permanent(fmt.Errorf("decode %s data: %w", p.Template, err))
+		}
+		user, err := database.New(db).GetUserByID(ctx, p.UserID)
+		if err == sql.ErrNoRows {
+			return jobs.Permanent(fmt.Errorf("user %s no longer exists", p.UserID))
+		}
+		if err != nil {
+			return err
+		}
+		return Send(ctx, mailer, user, p.Template, data)
+	})
+}
+
+// apiURL is where the API is reachable from a mail client, API_BASE_URL or
+// http://localhost:8080.
+func apiURL() string {
+	if u := os.Getenv("API_BASE_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:8080"
+}
+
+// FrontendURL builds a link into the storefront at FRONTEND_URL, or
+// http://localhost:5173 during development.
+func FrontendURL(path string) string {
+	if u := os.Getenv("FRONTEND_URL"); u != "" {
+		return strings.TrimRight(u, "/") + path
+	}
+	return "http://localhost:5173" + path
+}
+
+// Email queues a template for the user, unless they turned emails of its category off.
+// Pass a transaction-bound Queries so the email is only sent if the change it is about
+// commits. Account emails can't be queued, as they carry secrets; use Send.
+func Email(ctx context.Context, q *database.Queries, user database.User, template string, data any) error {
+	t, ok := emailTemplates[template]
+	if !ok {
+		return fmt.Errorf("unknown email template %q", template)
+	}
+	if t.category == CategoryAccount {
+		return fmt.Errorf("%s emails are sent with Send, not queued", template)
+	}
+
+	enabled, err := Enabled(ctx, q, user.ID, t.category, ChannelEmail)
+	if err != nil || !enabled {
+		return err
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = emailJob.Enqueue(ctx, q, emailPayload{Template: template, UserID: user.ID, Data: raw})
+	return err
+}
+
+// Send renders a template for the user and hands it to the mailer straight away,
+// without storing it. Preferences are not checked; Email does that before queuing.
+func Send(ctx context.Context, mailer Mailer, user database.User, template string, data any) error {
+	msg, err := Render(user, template, data)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(ctx, msg)
+}
+
+// Render builds the email a template makes for the user, with an unsubscribe link for
+// every category but account.
+func Render(user database.User, template string, data any) (Message, error) {
+	t, ok := emailTemplates[template]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", template)
+	}
+
+	unsubscribe := ""
+	if t.category != CategoryAccount {
+		unsubscribe = apiURL() + "/api/v1/user/notifications/unsubscribe?token=" +
+			url.QueryEscape(UnsubscribeToken(user.ID, t.category))
+	}
+
+	var subject, text, html bytes.Buffer
+	view := emailView{Data: data, Unsubscribe: unsubscribe}
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.text.Execute(&text, view); err != nil {
+		return Message{}, err
+	}
+	if err := t.html.Execute(&html, view); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:          user.Email,
+		Subject:     subject.String(),
+		Text:        text.String(),
+		HTML:        html.String(),
+		Unsubscribe: unsubscribe,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+)
+
+func TestRender(t *testing.T) {
+	t.Setenv("API_BASE_URL", "https://api.example.com/")
+	t.Setenv("JWT_SECRET", "test-secret")
+	user := database.User{ID: uuid.New(), FirstName: "Ada", Email: "ada@example.com"}
+
+	tests := []struct {
+		name        string
+		template    string
+		data        any
+		wantSubject string
+		wantText    []string
+		wantHTML    []string
+		notInHTML   []string
+		category    string // Empty when the email has no unsubscribe link
+	}{
+		{
+			name:     "order confirmation",
+			template: TemplateOrderConfirmation,
+			data: OrderConfirmationData{
+				Name:     "Ada",
+				OrderID:  "ord-1",
+				Items:    []EmailItem{{Name: "Lamp", Quantity: 2, Total: "40.00"}},
+				Subtotal: "40.00",
+				Shipping: "5.00",
+				Tax:      "0.00",
+				Total:    "45.00",
+			},
+			wantSubject: "We received your order ord-1",
+			wantText:    []string{"Hi Ada,", "2 x Lamp  40.00", "Total     45.00"},
+			wantHTML:    []string{"Thanks for your order, Ada", "2 &times; Lamp"},
+			notInHTML:   []string{"Discount", "View your order"},
+			category:    CategoryOrders,
+		},
+		{
+			name:     "order confirmation with a discount",
+			template: TemplateOrderConfirmation,
+			data: OrderConfirmationData{
+				Name: "Ada", OrderID: "ord-2", Subtotal: "40.00", Discount: "4.00",
+				Shipping: "0.00", Tax: "0.00", Total: "36.00", OrderURL: "https://shop.example.com/orders/ord-2",
+			},
+			wantSubject: "We received your order ord-2",
+			wantText:    []string{"Discount  -4.00", "View your order: https://shop.example.com/orders/ord-2"},
+			wantHTML:    []string{"-4.00", `href="https://shop.example.com/orders/ord-2"`},
+			category:    CategoryOrders,
+		},
+		{
+			name:     "shipment",
+			template: TemplateShipment,
+			data: ShipmentData{
+				Name: "Ada", OrderID: "ord-1", Carrier: "UPS", TrackingNumber: "1Z999",
+				Items: []EmailItem{{Name: "Lamp", Quantity: 1}},
+			},
+			wantSubject: "Your order ord-1 has shipped",
+			wantText:    []string{"with UPS.", "1 x Lamp", "Tracking number: 1Z999"},
+			wantHTML:    []string{"<strong>1Z999</strong>"},
+			notInHTML:   []string{"Track your parcel"},
+			category:    CategoryShipping,
+		},
+		{
+			name:     "price drop escapes names in HTML",
+			template: TemplatePriceDrop,
+			data: PriceDropData{
+				Name: "Ada", Product: "<Lamp & Co>", Wishlist: "Home", OldPrice: "50.00",
+				NewPrice: "40.00", ProductURL: "https://shop.example.com/p/lamp",
+			},
+			wantSubject: "<Lamp & Co> is now 40.00",
+			wantText:    []string{"<Lamp & Co> from your wishlist \"Home\" dropped from 50.00 to 40.00"},
+			wantHTML:    []string{"&lt;Lamp &amp; Co&gt;", "<s>50.00</s>"},
+			notInHTML:   []string{"<Lamp & Co>"},
+			category:    CategoryWishlist,
+		},
+		{
+			name:        "password reset has no unsubscribe link",
+			template:    TemplatePasswordReset,
+			data:        PasswordResetData{Name: "Ada", ResetURL: "https://shop.example.com/reset-password?token=abc"},
+			wantSubject: "Reset your password",
+			wantText:    []string{"https://shop.example.com/reset-password?token=abc"},
+			wantHTML:    []string{`href="https://shop.example.com/reset-password?token=abc"`},
+			notInHTML:   []string{"Unsubscribe"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Render(user, tt.template, tt.data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if msg.To != user.Email {
+				t.Errorf("To = %q, want %q", msg.To, user.Email)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(msg.Text, want) {
+					t.Errorf("text is missing %q:\n%s", want, msg.Text)
+				}
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(msg.HTML, want) {
+					t.Errorf("HTML is missing %q:\n%s", want, msg.HTML)
+				}
+			}
+			for _, unwanted := range tt.notInHTML {
+				if strings.Contains(msg.HTML, unwanted) {
+					t.Errorf("HTML should not contain %q:\n%s", unwanted, msg.HTML)
+				}
+			}
+
+			if tt.category == "" {
+				if msg.Unsubscribe != "" || strings.Contains(msg.Text, "Unsubscribe") {
+					t.Errorf("unexpected unsubscribe link %q", msg.Unsubscribe)
+				}
+				return
+			}
+			u, err := url.Parse(msg.Unsubscribe)
+			if err != nil || !strings.HasPrefix(msg.Unsubscribe, "https://api.example.com/api/v1/user/notifications/unsubscribe?") {
+				t.Fatalf("Unsubscribe = %q", msg.Unsubscribe)
+			}
+			userID, category, err := ParseUnsubscribeToken(u.Query().Get("token"))
+			if err != nil || userID != user.ID || category != tt.category {
+				t.Errorf("unsubscribe token is for %s/%q (%v), want %s/%q", userID, category, err, user.ID, tt.category)
+			}
+			if !strings.Contains(msg.Text, "Unsubscribe: "+msg.Unsubscribe) {
+				t.Errorf("text footer is missing the unsubscribe link:\n%s", msg.Text)
+			}
+		})
+	}
+
+	if _, err := Render(user, "nope", nil); err == nil {
+		t.Error("Render() of an unknown template should fail")
+	}
+}
+
+// Queued data is decoded into the template's type before rendering, so a job renders
+// the same email as the data it was queued with.
+func TestRenderQueuedData(t *testing.T) {
+	user := database.User{ID: uuid.New(), Email: "ada@example.com"}
+	data := ShipmentData{Name: "Ada", OrderID: "ord-1", Carrier: "UPS", TrackingNumber: "1Z999", Items: []EmailItem{{Name: "Lamp", Quantity: 3}}}
+
+	want, err := Render(user, TemplateShipment, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := emailTemplates[TemplateShipment].data()
+	if err := json.Unmarshal(raw, decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Render(user, TemplateShipment, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("rendered from queued data:\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestEveryTemplateHasData(t *testing.T) {
+	for name, tmpl := range emailTemplates {
+		if tmpl.data == nil || tmpl.data() == nil {
+			t.Errorf("template %s has no data type", name)
+		}
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	userID := uuid.New()
+	other := uuid.New()
+
+	valid := UnsubscribeToken(userID, CategoryOrders)
+	claim, sig, _ := strings.Cut(valid, ".")
+	otherClaim, _, _ := strings.Cut(UnsubscribeToken(other, CategoryOrders), ".")
+	tamperedSig := []byte(sig)
+	tamperedSig[0] ^= 1
+
+	tests := []struct {
+		name         string
+		token        string
+		wantUser     uuid.UUID
+		wantCategory string
+		wantErr      bool
+	}{
+		{"category", valid, userID, CategoryOrders, false},
+		{"every category", UnsubscribeToken(userID, ""), userID, "", false},
+		{"other user's claim with this signature", otherClaim + "." + sig, uuid.Nil, "", true},
+		{"tampered signature", claim + "." + string(tamperedSig), uuid.Nil, "", true},
+		{"tampered claim", claim + "x." + sig, uuid.Nil, "", true},
+		{"no signature", claim, uuid.Nil, "", true},
+		{"empty", "", uuid.Nil, "", true},
+		{"garbage", "not.a-token", uuid.Nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUser, gotCategory, err := ParseUnsubscribeToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUnsubscribeToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotUser != tt.wantUser || gotCategory != tt.wantCategory {
+				t.Errorf("ParseUnsubscribeToken() = %s, %q, want %s, %q", gotUser, gotCategory, tt.wantUser, tt.wantCategory)
+			}
+		})
+	}
+
+	// A token signed with another secret doesn't verify
+	t.Setenv("JWT_SECRET", "rotated-secret")
+	if _, _, err := ParseUnsubscribeToken(valid); err == nil {
+		t.Error("token signed with the old secret should not verify")
+	}
+}
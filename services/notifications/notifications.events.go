@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// HandleEvent is the bus subscriber that tells customers about their orders: an in-app
+// notification and a confirmation email when one is placed, and an email when a parcel
+// ships. Shipments already notify in-app where they are created.
+func HandleEvent(ctx context.Context, qtx *database.Queries, e events.Event) error {
+	switch e.Type {
+	case events.OrderCreated:
+		var data mytypes.OrderEventData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		user, err := eventUser(ctx, qtx, data.UserID)
+		if err != nil {
+			return err
+		}
+		items, err := qtx.GetOrderItems(ctx, e.AggregateID)
+		if err != nil {
+			return err
+		}
+
+		if err := Notify(ctx, qtx, user.ID, TypeOrderUpdate, "Order placed",
+			fmt.Sprintf("We received your order %s and will let you know when it ships.", data.ID)); err != nil {
+			return err
+		}
+
+		email := OrderConfirmationData{
+			Name:     user.FirstName,
+			OrderID:  data.ID,
+			Items:    make([]EmailItem, 0, len(items)),
+			Subtotal: money(data.Subtotal),
+			Shipping: money(data.ShippingCost),
+			Tax:      money(data.TaxTotal),
+			Total:    money(data.Total),
+			OrderURL: FrontendURL("/orders/" + data.ID),
+		}
+		if discount, err := decimal.NewFromString(data.DiscountTotal); err == nil && discount.IsPositive() {
+			email.Discount = discount.StringFixed(2)
+		}
+		for _, item := range items {
+			email.Items = append(email.Items, EmailItem{
+				Name:     item.ProductName,
+				Quantity: item.Quantity,
+				Total:    item.Price.Mul(decimal.NewFromInt32(item.Quantity)).StringFixed(2),
+			})
+		}
+		return Email(ctx, qtx, user, TemplateOrderConfirmation, email)
+
+	case events.ShipmentCreated:
+		var data mytypes.ShipmentEventData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		user, err := eventUser(ctx, qtx, data.UserID)
+		if err != nil {
+			return err
+		}
+		items, err := qtx.ListShipmentItems(ctx, e.AggregateID)
+		if err != nil {
+			return err
+		}
+
+		email := ShipmentData{
+			Name:           user.FirstName,
+			OrderID:        data.OrderID,
+			Carrier:        data.Carrier,
+			TrackingNumber: data.TrackingNumber,
+			TrackingURL:    data.TrackingURL,
+			Items:          make([]EmailItem, 0, len(items)),
+		}
+		for _, item := range items {
+			email.Items = append(email.Items, EmailItem{Name: item.ProductName, Quantity: item.Quantity})
+		}
+		return Email(ctx, qtx, user, TemplateShipment, email)
+	}
+	return nil
+}
+
+// SubscribedEvents are the domain events HandleEvent reacts to.
+var SubscribedEvents = []string{
+	events.OrderCreated,
+	events.ShipmentCreated,
+}
+
+func eventUser(ctx context.Context, qtx *database.Queries, id string) (database.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return database.User{}, fmt.Errorf("event without a user: %w", err)
+	}
+	return qtx.GetUserByID(ctx, userID)
+}
+
+// money formats an amount from an event payload with two decimals.
+func money(amount string) string {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return amount
+	}
+	return d.StringFixed(2)
+}
@@ -0,0 +1,189 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is one rendered email.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+	// Unsubscribe is the one-click unsubscribe URL, sent as List-Unsubscribe
+	Unsubscribe string `json:"unsubscribe,omitempty"`
+}
+
+// Mailer sends emails. Implementations only deliver; rendering, preferences and
+// retries are handled before a message gets here.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer returns the transport named by MAIL_TRANSPORT:
+//
+//   - file (the default) writes each email as an .eml file to MAIL_DIR (tmp/mail), so
+//     emails can be read without a mail server
+//   - smtp sends through SMTP_HOST:SMTP_PORT, with SMTP_USERNAME and SMTP_PASSWORD when
+//     set; point it at a capture server such as Mailpit to see emails in a browser
+//
+// MAIL_FROM is the sender for both.
+func NewMailer() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "E-Commerce Marketplace <no-reply@localhost>"
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+
+	switch name := os.Getenv("MAIL_TRANSPORT"); name {
+	case "", "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = filepath.Join("tmp", "mail")
+		}
+		return &FileMailer{Dir: dir, From: sender}, nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail transport")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPMailer{
+			Addr:     net.JoinHostPort(host, port),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     sender,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport: %s", name)
+	}
+}
+
+// FileMailer writes emails to a directory instead of sending them.
+type FileMailer struct {
+	Dir  string
+	From *mail.Address
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := compose(m.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), randomID())
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o644)
+}
+
+// SMTPMailer sends emails through an SMTP server, upgrading to TLS when the server
+// offers it.
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     *mail.Address
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := compose(m.From, msg)
+	if err != nil {
+		return err
+	}
+	to, _ := mail.ParseAddress(msg.To)
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, _ := net.SplitHostPort(m.Addr)
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From.Address, []string{to.Address}, raw)
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// compose builds a multipart/alternative message with the text and HTML bodies.
+func compose(from *mail.Address, msg Message) ([]byte, error) {
+	// Parsing also keeps line breaks out of the headers
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@%s>", randomID(), domain),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+	}
+	if msg.Unsubscribe != "" {
+		headers = append(headers,
+			"List-Unsubscribe: <"+msg.Unsubscribe+">",
+			"List-Unsubscribe-Post: List-Unsubscribe=One-Click")
+	}
+
+	var out bytes.Buffer
+	out.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
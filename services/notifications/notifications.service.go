@@ -0,0 +1,208 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+)
+
+// Notification types stored in notifications.type
+const (
+	TypeLowStock    = "low_stock"
+	TypeOutOfStock  = "out_of_stock"
+	TypeBackInStock = "back_in_stock"
+
+	TypePaymentReceived = "payment_received"
+	TypePaymentFailed   = "payment_failed"
+	TypeRefunded        = "refunded"
+
+	TypeReturnUpdate = "return_update"
+	TypeOrderUpdate  = "order_update"
+
+	TypeShipmentUpdate = "shipment_update"
+
+	TypePriceDrop = "price_drop"
+)
+
+// Categories users can turn channels on and off for. Account messages, such as password
+// resets, are always sent and have no preference.
+const (
+	CategoryOrders   = "orders"
+	CategoryShipping = "shipping"
+	CategoryPayments = "payments"
+	CategoryReturns  = "returns"
+	CategoryStock    = "stock"
+	CategoryWishlist = "wishlist"
+	CategoryAccount  = "account"
+)
+
+var Categories = []string{CategoryOrders, CategoryShipping, CategoryPayments, CategoryReturns, CategoryStock, CategoryWishlist}
+
+// Channels a notification can go out on
+const (
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+// categoryOf maps an in-app notification type to the category its preference is under.
+func categoryOf(notificationType string) string {
+	switch notificationType {
+	case TypeLowStock, TypeOutOfStock, TypeBackInStock:
+		return CategoryStock
+	case TypePaymentReceived, TypePaymentFailed, TypeRefunded:
+		return CategoryPayments
+	case TypeReturnUpdate:
+		return CategoryReturns
+	case TypeShipmentUpdate:
+		return CategoryShipping
+	case TypePriceDrop:
+		return CategoryWishlist
+	default:
+		return CategoryOrders
+	}
+}
+
+// ParseCategory validates a category name.
+func ParseCategory(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, c := range Categories {
+		if s == c {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("category must be one of %s", strings.Join(Categories, ", "))
+}
+
+// Enabled reports whether the user wants notifications of the category on the channel.
+// Everything is on until the user turns it off; account messages can't be turned off.
+func Enabled(ctx context.Context, q *database.Queries, userID uuid.UUID, category, channel string) (bool, error) {
+	if category == CategoryAccount {
+		return true, nil
+	}
+	enabled, err := q.GetNotificationPreference(ctx, database.GetNotificationPreferenceParams{
+		UserID:   userID,
+		Category: category,
+		Channel:  channel,
+	})
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return enabled, err
+}
+
+// Preferences lists every category with the state of its channels for the user.
+func Preferences(ctx context.Context, q *database.Queries, userID uuid.UUID) ([]mytypes.NotificationPreferenceResponse, error) {
+	rows, err := q.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := make(map[string]*mytypes.NotificationPreferenceResponse, len(Categories))
+	prefs := make([]mytypes.NotificationPreferenceResponse, len(Categories))
+	for i, c := range Categories {
+		prefs[i] = mytypes.NotificationPreferenceResponse{Category: c, Email: true, InApp: true}
+		byCategory[c] = &prefs[i]
+	}
+	for _, row := range rows {
+		pref, ok := byCategory[row.Category]
+		if !ok {
+			continue
+		}
+		switch row.Channel {
+		case ChannelEmail:
+			pref.Email = row.Enabled
+		case ChannelInApp:
+			pref.InApp = row.Enabled
+		}
+	}
+	return prefs, nil
+}
+
+// Notify stores an in-app notification for the user, unless they turned in-app
+// notifications off for its category. Pass a transaction-bound Queries when the
+// notification must only exist if the triggering change commits.
+func Notify(ctx context.Context, q *database.Queries, userID uuid.UUID, notificationType, title, body string) error {
+	enabled, err := Enabled(ctx, q, userID, categoryOf(notificationType), ChannelInApp)
+	if err != nil || !enabled {
+		return err
+	}
+
+	_, err = q.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+	})
+	return err
+}
+
+// unsubscribeKey signs unsubscribe links. It is derived from the JWT secret so there is
+// nothing else to configure.
+func unsubscribeKey() []byte {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte("unsubscribe"))
+	return mac.Sum(nil)
+}
+
+// UnsubscribeToken is the token in an email's unsubscribe link. It turns off emails of
+// the category for the user, or every email when category is empty. Tokens don't
+// expire, so an old email's link keeps working.
+func UnsubscribeToken(userID uuid.UUID, category string) string {
+	claim := base64.RawURLEncoding.EncodeToString([]byte(userID.String() + ":" + category))
+	mac := hmac.New(sha256.New, unsubscribeKey())
+	mac.Write([]byte(claim))
+	return claim + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseUnsubscribeToken checks a token from UnsubscribeToken and returns what it is for.
+func ParseUnsubscribeToken(token string) (uuid.UUID, string, error) {
+	claim, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, "", fmt.Errorf("invalid unsubscribe token")
+	}
+	mac := hmac.New(sha256.New, unsubscribeKey())
+	mac.Write([]byte(claim))
+	want := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return uuid.Nil, "", fmt.Errorf("invalid unsubscribe token")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(claim)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("invalid unsubscribe token")
+	}
+	id, category, _ := strings.Cut(string(raw), ":")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("invalid unsubscribe token")
+	}
+	return userID, category, nil
+}
+
+// Unsubscribe turns emails of the category off for the user, or all of them when
+// category is empty.
+func Unsubscribe(ctx context.Context, q *database.Queries, userID uuid.UUID, category string) error {
+	categories := Categories
+	if category != "" {
+		categories = []string{category}
+	}
+	for _, c := range categories {
+		if err := q.UpsertNotificationPreference(ctx, database.UpsertNotificationPreferenceParams{
+			UserID:   userID,
+			Category: c,
+			Channel:  ChannelEmail,
+			Enabled:  false,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
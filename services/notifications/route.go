@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the notification endpoints. Unsubscribing works from an email's link
+// without logging in; everything else needs the user's token.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Get("/unsubscribe", func(w http.ResponseWriter, r *http.Request) {
+		handleUnsubscribe(w, r, q)
+	})
+
+	r.Post("/unsubscribe", func(w http.ResponseWriter, r *http.Request) {
+		handleUnsubscribe(w, r, q)
+	})
+
+	r.Group(func(pr chi.Router) {
+		pr.Use(utils.AuthMiddleware)
+
+		pr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			handleListNotifications(w, r, q)
+		})
+
+		pr.Post("/{notificationID}/read", func(w http.ResponseWriter, r *http.Request) {
+			handleMarkNotificationRead(w, r, q)
+		})
+
+		pr.Get("/preferences", func(w http.ResponseWriter, r *http.Request) {
+			handleGetPreferences(w, r, q)
+		})
+
+		pr.Put("/preferences", func(w http.ResponseWriter, r *http.Request) {
+			handleUpdatePreferences(w, r, db)
+		})
+	})
+
+	return r
+}
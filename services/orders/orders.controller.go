@@ -1,17 +1,23 @@
 package orders
 
 import (
-	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/fulfillment"
+	"github.com/ARCoder181105/ecom/services/invoices"
+	"github.com/ARCoder181105/ecom/services/orderstatus"
+	"github.com/ARCoder181105/ecom/services/returns"
+	"github.com/ARCoder181105/ecom/services/tax"
 	mytypes "github.com/ARCoder181105/ecom/types"
 	"github.com/ARCoder181105/ecom/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/shopspring/decimal"
 )
 
 func handleUserOrdersList(w http.ResponseWriter, r *http.Request, q *database.Queries) {
@@ -63,6 +69,12 @@ func handlePlaceOrder(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		return
 	}
 
+	addressID, err := ParseAddressID(r.URL.Query().Get("address_id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// If any operation is undone, Everything is undone
 	tx, err := db.Begin()
 	if err != nil {
@@ -74,88 +86,61 @@ func handlePlaceOrder(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 
 	qtx := database.New(db).WithTx(tx)
 
-	var totalPrice = decimal.NewFromInt(0)
-
-	productCache := make(map[uuid.UUID]database.Product)
-
-	for _, item := range cartItems {
-		prodID, err := uuid.Parse(item.ProductID)
-		if err != nil {
-			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id: %s", item.ProductID))
-			return
-		}
-
-		product, err := qtx.GetProductByID(context.Background(), prodID)
-		if err != nil {
-			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("product not found: %s", item.ProductID))
-			return
-		}
-
-		if int(product.StockQuantity) < item.Quantity {
-			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("out of stock: %s", product.Name))
-			return
-		}
-
-		itemTotal := product.Price.Mul(decimal.NewFromInt(int64(item.Quantity))) //price * quantity
-		totalPrice = totalPrice.Add(itemTotal)                                   // total+=price
-
-		productCache[prodID] = product
-	}
-
-	order, err := qtx.CreateOrder(context.Background(), database.CreateOrderParams{
+	order, err := PlaceOrder(r.Context(), qtx, CheckoutRequest{
 		UserID:     userID,
-		TotalPrice: totalPrice,
-		Status:     "pending",
+		Items:      cartItems,
+		CouponCode: r.URL.Query().Get("coupon"),
+		TaxRegion: tax.Region{
+			Country: r.URL.Query().Get("country"),
+			State:   r.URL.Query().Get("state"),
+		},
+		AddressID:      addressID,
+		ShippingMethod: r.URL.Query().Get("shipping_method"),
 	})
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to create order"))
+		RespondWithOrderError(w, err)
 		return
 	}
 
-	for _, item := range cartItems {
-		prodID, _ := uuid.Parse(item.ProductID)
-		product := productCache[prodID]
-
-		_, err := qtx.CreateOrderItem(context.Background(), database.CreateOrderItemParams{
-			OrderID:   order.ID,
-			ProductID: prodID,
-			Quantity:  int32(item.Quantity),
-			Price:     product.Price,
-		})
-		if err != nil {
-			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to create order item"))
-			return
-		}
-
-		newStock := product.StockQuantity - int32(item.Quantity)
-
-		_, err = qtx.UpdateProduct(context.Background(), database.UpdateProductParams{
-			ID:            product.ID,
-			Name:          product.Name,
-			Description:   product.Description,
-			Image:         product.Image,
-			Price:         product.Price,
-			StockQuantity: newStock,
-			UserID:        product.UserID,
-		})
-
-		if err != nil {
-			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update stock"))
-			return
-		}
-	}
-
 	if err := tx.Commit(); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"message":  "Order placed successfully",
-		"order_id": order.ID,
+		"message":        "Order placed successfully",
+		"order_id":       order.ID,
+		"subtotal":       order.Subtotal,
+		"discount_total": order.DiscountTotal,
+		"shipping_cost":  order.ShippingCost,
+		"tax_total":      order.TaxTotal,
+		"total_price":    order.TotalPrice,
 	})
 }
 
+// ParseAddressID reads the optional address to ship an order to.
+func ParseAddressID(s string) (uuid.NullUUID, error) {
+	if s == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("invalid address id")
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+// RespondWithOrderError reports a PlaceOrder failure: problems with the items are the
+// customer's to fix, anything else is a server error.
+func RespondWithOrderError(w http.ResponseWriter, err error) {
+	var orderErr *OrderError
+	if errors.As(err, &orderErr) {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	utils.RespondWithError(w, http.StatusInternalServerError, err)
+}
+
 func handleGetOrderById(w http.ResponseWriter, r *http.Request, q *database.Queries) {
 	orderIDStr := chi.URLParam(r, "orderID")
 	if orderIDStr == "" {
@@ -207,18 +192,113 @@ func handleGetOrderById(w http.ResponseWriter, r *http.Request, q *database.Quer
 		}
 	}
 
+	discounts, err := q.ListOrderDiscounts(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	discountLines := make([]mytypes.OrderDiscountResponse, 0, len(discounts))
+	for _, d := range discounts {
+		discountLines = append(discountLines, mytypes.OrderDiscountResponse{
+			Code:        d.Code,
+			Description: d.Description,
+			Amount:      d.Amount.String(),
+		})
+	}
+
+	history, err := q.ListOrderStatusHistory(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	statusHistory := make([]mytypes.OrderStatusHistoryResponse, 0, len(history))
+	for _, h := range history {
+		entry := mytypes.OrderStatusHistoryResponse{
+			FromStatus: string(h.FromStatus.OrderStatus),
+			ToStatus:   string(h.ToStatus),
+			Note:       h.Note,
+			CreatedAt:  h.CreatedAt,
+		}
+		if h.ActorID.Valid {
+			entry.ActorID = h.ActorID.UUID.String()
+		}
+		if h.SellerOrderID.Valid {
+			entry.SellerOrderID = h.SellerOrderID.UUID.String()
+		}
+		statusHistory = append(statusHistory, entry)
+	}
+
+	subs, err := q.ListSellerOrdersByOrder(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sellerOrders := make([]mytypes.SellerOrderResponse, 0, len(subs))
+	for _, sub := range subs {
+		sellerOrders = append(sellerOrders, mytypes.NewSellerOrderResponse(sub))
+	}
+
+	shipmentRows, err := q.ListShipmentsByOrder(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	shipments, err := fulfillment.ShipmentResponses(r.Context(), q, shipmentRows)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	orderReturns, err := returns.ListForOrder(r.Context(), q, orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	taxes, err := q.ListOrderTaxLines(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	taxLines := make([]mytypes.OrderTaxLineResponse, 0, len(taxes))
+	for _, t := range taxes {
+		taxLines = append(taxLines, mytypes.OrderTaxLineResponse{
+			Name:          t.Name,
+			Rate:          t.Rate.String(),
+			TaxableAmount: t.TaxableAmount.String(),
+			Amount:        t.Amount.String(),
+		})
+	}
+
 	response := map[string]interface{}{
-		"order_id":    order.ID,
-		"status":      order.Status,
-		"total_price": order.TotalPrice,
-		"created_at":  order.CreatedAt,
-		"items":       items,
+		"order_id":           order.ID,
+		"status":             order.Status,
+		"status_history":     statusHistory,
+		"subtotal":           order.Subtotal,
+		"discount_total":     order.DiscountTotal,
+		"discounts":          discountLines,
+		"shipping_address":   order.ShippingAddress,
+		"shipping_method":    order.ShippingMethod,
+		"shipping_cost":      order.ShippingCost,
+		"tax_total":          order.TaxTotal,
+		"prices_include_tax": order.PricesIncludeTax,
+		"taxes":              taxLines,
+		"total_price":        order.TotalPrice,
+		"created_at":         order.CreatedAt,
+		"items":              items,
+		"seller_orders":      sellerOrders,
+		"shipments":          shipments,
+		"returns":            orderReturns,
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
-func handleAdminUpdateStatus(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+func handleAdminUpdateStatus(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	claims, err := utils.GetClaims(r)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
@@ -229,6 +309,7 @@ func handleAdminUpdateStatus(w http.ResponseWriter, r *http.Request, q *database
 		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
 		return
 	}
+	adminID, _ := uuid.Parse(claims.UserID)
 
 	var statusPayload mytypes.AdminUpdateStatusPayload
 	if err := utils.ParseJson(r, &statusPayload); err != nil {
@@ -247,11 +328,195 @@ func handleAdminUpdateStatus(w http.ResponseWriter, r *http.Request, q *database
 		return
 	}
 
-	// Attempt to update the order status and handle possible errors.
-	if err := q.UpdateOrderStatus(r.Context(), database.UpdateOrderStatusParams{
-		ID:     orderIdUUID,
-		Status: statusPayload.Status,
-	}); err != nil {
+	status, err := orderstatus.Parse(statusPayload.Status)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Paid and refunded follow the money: setting them here would skip the capture or
+	// the refund, the ledger entries and the invoice or credit note that go with them
+	switch status {
+	case database.OrderStatusPaid:
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("orders become paid when their payment is captured, use POST /api/v1/payments/{paymentID}/capture"))
+		return
+	case database.OrderStatusRefunded:
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("orders become refunded when their payments are refunded, use POST /api/v1/payments/{paymentID}/refund"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), orderIdUUID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Only moves allowed by the order lifecycle are accepted. Cancelling also returns
+	// the stock and the customer's money.
+	actor := uuid.NullUUID{UUID: adminID, Valid: true}
+	note := strings.TrimSpace(statusPayload.Note)
+	if status == database.OrderStatusCancelled {
+		order, err = CancelOrder(r.Context(), qtx, order, actor, note)
+	} else {
+		order, err = orderstatus.Transition(r.Context(), qtx, order, status, actor, note)
+	}
+	var transitionErr *orderstatus.TransitionError
+	if errors.As(err, &transitionErr) || errors.Is(err, ErrPartlyShipped) {
+		utils.RespondWithError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "order status updated successfully",
+		"order_id": orderIdUUID,
+		"status":   order.Status,
+	})
+}
+
+// handleCancelOrder lets customers cancel their own orders until they ship.
+func handleCancelOrder(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	orderID, err := uuid.Parse(chi.URLParam(r, "orderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+
+	var payload mytypes.CancelOrderPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	reason := strings.TrimSpace(payload.Reason)
+	if len(reason) > 500 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("reason must be at most 500 characters"))
+		return
+	}
+	if reason == "" {
+		reason = "cancelled by customer"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), orderID)
+	if err == sql.ErrNoRows || (err == nil && order.UserID != userID) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	order, err = CancelOrder(r.Context(), qtx, order, uuid.NullUUID{UUID: userID, Valid: true}, reason)
+	var transitionErr *orderstatus.TransitionError
+	if errors.As(err, &transitionErr) {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("order can no longer be cancelled, it is %s", transitionErr.From))
+		return
+	}
+	if errors.Is(err, ErrPartlyShipped) {
+		utils.RespondWithError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "order cancelled",
+		"order_id": order.ID,
+		"status":   order.Status,
+	})
+}
+
+// handleRateSellerOrder lets the customer rate the seller of a delivered sub-order of
+// their order. Rating the same sub-order again replaces the rating.
+func handleRateSellerOrder(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	orderID, err := uuid.Parse(chi.URLParam(r, "orderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+	sellerOrderID, err := uuid.Parse(chi.URLParam(r, "sellerOrderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller order id"))
+		return
+	}
+
+	var payload mytypes.SellerRatingPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+	if payload.Rating < 1 || payload.Rating > 5 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("rating must be between 1 and 5"))
+		return
+	}
+	comment := strings.TrimSpace(payload.Comment)
+	if len(comment) > 2000 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("comment must be at most 2000 characters"))
+		return
+	}
+
+	// Scoped to the caller, so other customers' orders are not found
+	if _, err := q.GetOrderByID(r.Context(), database.GetOrderByIDParams{ID: orderID, UserID: userID}); err != nil {
 		if err == sql.ErrNoRows {
 			utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
 			return
@@ -260,9 +525,206 @@ func handleAdminUpdateStatus(w http.ResponseWriter, r *http.Request, q *database
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"message":  "order status updated successfully",
-		"order_id": orderIdUUID,
-		"status":   statusPayload.Status,
+	sub, err := q.GetSellerOrder(r.Context(), sellerOrderID)
+	if err == sql.ErrNoRows || (err == nil && sub.OrderID != orderID) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("seller order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if sub.Status != database.OrderStatusDelivered {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("only delivered orders can be rated, this one is %s", sub.Status))
+		return
+	}
+
+	rating, err := q.UpsertSellerRating(r.Context(), database.UpsertSellerRatingParams{
+		SellerOrderID: sub.ID,
+		SellerID:      sub.SellerID,
+		UserID:        userID,
+		Rating:        int16(payload.Rating),
+		Comment:       comment,
 	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.SellerRatingResponse{
+		SellerOrderID: rating.SellerOrderID.String(),
+		SellerID:      rating.SellerID.String(),
+		Rating:        rating.Rating,
+		Comment:       rating.Comment,
+		CreatedAt:     rating.CreatedAt,
+		UpdatedAt:     rating.UpdatedAt,
+	})
+}
+
+// invoiceAccess parses the order id and returns the caller's user id and whether they are
+// an admin, who may read any order's invoices.
+func invoiceAccess(w http.ResponseWriter, r *http.Request) (orderID, userID uuid.UUID, admin, ok bool) {
+	orderID, err := uuid.Parse(chi.URLParam(r, "orderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	userID, err = uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	return orderID, userID, claims.Role == "admin", true
+}
+
+// writeInvoice sends a document as PDF (the default), HTML or JSON, chosen with ?format=.
+func writeInvoice(w http.ResponseWriter, r *http.Request, doc invoices.Document) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "pdf"
+	}
+
+	switch format {
+	case "json":
+		utils.RespondWithJSON(w, http.StatusOK, doc)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		invoices.RenderHTML(w, doc)
+	case "pdf":
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", doc.Number))
+		w.Header().Set("Content-Type", "application/pdf")
+		w.WriteHeader(http.StatusOK)
+		w.Write(invoices.RenderPDF(doc))
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("format must be pdf, html or json"))
+	}
+}
+
+// handleGetInvoice downloads the invoice issued when the order was paid.
+func handleGetInvoice(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	orderID, userID, admin, ok := invoiceAccess(w, r)
+	if !ok {
+		return
+	}
+
+	invoice, err := q.GetOrderInvoice(r.Context(), orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("invoice not found"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !admin && invoice.UserID != userID {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("invoice not found"))
+		return
+	}
+
+	doc, err := invoices.Load(invoice, "")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeInvoice(w, r, doc)
+}
+
+// handleListCreditNotes lists the credit notes issued for refunds on the order.
+func handleListCreditNotes(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	orderID, userID, admin, ok := invoiceAccess(w, r)
+	if !ok {
+		return
+	}
+
+	if !admin {
+		if _, err := q.GetOrderByID(r.Context(), database.GetOrderByIDParams{ID: orderID, UserID: userID}); err != nil {
+			if err == sql.ErrNoRows {
+				utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
+				return
+			}
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	notes, err := q.ListCreditNotesByOrder(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.CreditNoteResponse, 0, len(notes))
+	for _, n := range notes {
+		note := mytypes.CreditNoteResponse{
+			ID:       n.ID.String(),
+			Number:   n.Number,
+			TaxTotal: n.TaxTotal.StringFixed(2),
+			Total:    n.Total.StringFixed(2),
+			IssuedAt: n.IssuedAt,
+		}
+		if n.PaymentID.Valid {
+			note.PaymentID = n.PaymentID.UUID.String()
+		}
+		resp = append(resp, note)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleGetCreditNote downloads one of the order's credit notes.
+func handleGetCreditNote(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	orderID, userID, admin, ok := invoiceAccess(w, r)
+	if !ok {
+		return
+	}
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "invoiceID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid credit note id"))
+		return
+	}
+
+	note, err := q.GetInvoiceByID(r.Context(), noteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("credit note not found"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if note.OrderID != orderID || note.Kind != database.InvoiceKindCreditNote || (!admin && note.UserID != userID) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("credit note not found"))
+		return
+	}
+
+	var credited string
+	if note.CreditedInvoiceID.Valid {
+		invoice, err := q.GetInvoiceByID(r.Context(), note.CreditedInvoiceID.UUID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		credited = invoice.Number
+	}
+
+	doc, err := invoices.Load(note, credited)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeInvoice(w, r, doc)
 }
@@ -0,0 +1,386 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/inventory"
+	"github.com/ARCoder181105/ecom/services/orderstatus"
+	"github.com/ARCoder181105/ecom/services/payments"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	"github.com/ARCoder181105/ecom/services/promotions"
+	"github.com/ARCoder181105/ecom/services/shipping"
+	"github.com/ARCoder181105/ecom/services/tax"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// OrderError is a problem with the customer's items, such as an unknown product or
+// missing stock, that should be reported back as a bad request.
+type OrderError struct {
+	Message string
+}
+
+func (e *OrderError) Error() string {
+	return e.Message
+}
+
+// ErrPartlyShipped is returned by CancelOrder when some of the order already left a
+// seller's warehouse, even though the order itself isn't marked shipped yet.
+var ErrPartlyShipped = errors.New("order can no longer be cancelled, some of its items have shipped")
+
+// CheckoutRequest is everything needed to turn a cart into an order.
+type CheckoutRequest struct {
+	UserID     uuid.UUID
+	Items      []mytypes.CreateOrderPayload
+	CouponCode string     // Optional
+	TaxRegion  tax.Region // Where the order is taxed when nothing is shipped
+	// AddressID is the customer's address to ship to. When set, the order is taxed
+	// where it is delivered and ShippingMethod (or the cheapest method) is charged.
+	AddressID      uuid.NullUUID
+	ShippingMethod string
+	// Taxes defaults to the rules configured in tax_rates
+	Taxes tax.TaxCalculator
+}
+
+// PlaceOrder creates a pending order for the request, snapshots item prices and the
+// shipping address, applies the coupon if one is given, adds shipping and taxes and takes
+// the stock out of inventory. The items are split into one sub-order per seller so each
+// seller fulfills their own part. Run it on a transaction-bound Queries: if any step
+// fails the caller must roll back so everything is undone.
+func PlaceOrder(ctx context.Context, qtx *database.Queries, req CheckoutRequest) (database.Order, error) {
+	if len(req.Items) == 0 {
+		return database.Order{}, &OrderError{Message: "cart is empty"}
+	}
+
+	var totalPrice = decimal.NewFromInt(0)
+
+	productCache := make(map[uuid.UUID]database.Product)
+	lines := make([]promotions.Line, 0, len(req.Items))
+	orderTime := time.Now()
+	var weightGrams int64
+
+	for _, item := range req.Items {
+		prodID, err := uuid.Parse(item.ProductID)
+		if err != nil {
+			return database.Order{}, &OrderError{Message: fmt.Sprintf("invalid product id: %s", item.ProductID)}
+		}
+
+		if item.Quantity <= 0 {
+			return database.Order{}, &OrderError{Message: fmt.Sprintf("invalid quantity for product: %s", item.ProductID)}
+		}
+
+		product, err := qtx.GetProductByIDForUpdate(ctx, prodID)
+		if err != nil {
+			return database.Order{}, &OrderError{Message: fmt.Sprintf("product not found: %s", item.ProductID)}
+		}
+
+		if int(product.StockQuantity) < item.Quantity {
+			return database.Order{}, &OrderError{Message: fmt.Sprintf("out of stock: %s", product.Name)}
+		}
+
+		// Scheduled sale prices apply automatically while the sale window is open
+		product.Price = pricing.EffectivePrice(product, orderTime)
+
+		itemTotal := product.Price.Mul(decimal.NewFromInt(int64(item.Quantity))) //price * quantity
+		totalPrice = totalPrice.Add(itemTotal)                                   // total+=price
+
+		weightGrams += int64(product.WeightGrams) * int64(item.Quantity)
+
+		productCache[prodID] = product
+		lines = append(lines, promotions.Line{
+			ProductID: product.ID,
+			SellerID:  product.UserID,
+			Category:  product.Category,
+			UnitPrice: product.Price,
+			Quantity:  int32(item.Quantity),
+		})
+	}
+
+	subtotal := totalPrice
+	discountTotal := decimal.NewFromInt(0)
+	lineDiscounts := make([]decimal.Decimal, len(lines))
+	var discount *promotions.Discount
+	if req.CouponCode != "" {
+		d, err := promotions.Evaluate(ctx, qtx, req.CouponCode, req.UserID, lines, orderTime, true)
+		var couponErr *promotions.CouponError
+		if errors.As(err, &couponErr) {
+			return database.Order{}, &OrderError{Message: couponErr.Message}
+		}
+		if err != nil {
+			return database.Order{}, fmt.Errorf("failed to apply coupon")
+		}
+		discount = &d
+		lineDiscounts = d.LineAmounts
+		discountTotal = d.Amount
+		totalPrice = totalPrice.Sub(d.Amount)
+	}
+
+	region := req.TaxRegion
+	shippingAddress := json.RawMessage("{}")
+	shippingCost := decimal.NewFromInt(0)
+	var shippingMethod string
+	if req.AddressID.Valid {
+		address, err := qtx.GetAddressByID(ctx, database.GetAddressByIDParams{
+			ID:     req.AddressID.UUID,
+			UserID: req.UserID,
+		})
+		if err == sql.ErrNoRows {
+			return database.Order{}, &OrderError{Message: "address not found"}
+		}
+		if err != nil {
+			return database.Order{}, fmt.Errorf("failed to load address")
+		}
+
+		rates, err := shipping.Quote(ctx, qtx, address.Country, shipping.Parcel{
+			WeightGrams: weightGrams,
+			Subtotal:    totalPrice,
+		})
+		if err != nil {
+			return database.Order{}, fmt.Errorf("failed to quote shipping")
+		}
+		rate, err := shipping.Choose(rates, req.ShippingMethod)
+		var shippingErr *shipping.ShippingError
+		if errors.As(err, &shippingErr) {
+			return database.Order{}, &OrderError{Message: shippingErr.Message}
+		}
+		if err != nil {
+			return database.Order{}, err
+		}
+
+		// The address is copied onto the order so later edits to the address book
+		// don't change where past orders were sent
+		shippingAddress, err = json.Marshal(mytypes.NewPostalAddress(address))
+		if err != nil {
+			return database.Order{}, fmt.Errorf("failed to snapshot address")
+		}
+		shippingMethod = rate.Method.Code
+		shippingCost = rate.Cost
+		region = tax.Region{Country: address.Country, State: address.State}
+
+		// A free shipping coupon waives the shipping cost; Evaluate only grants it when
+		// the cart has a line the coupon covers. The discount line records how much was
+		// waived
+		if discount != nil && discount.FreeShipping {
+			discount.Amount = shippingCost
+			discountTotal = discountTotal.Add(shippingCost)
+			totalPrice = totalPrice.Sub(shippingCost)
+		}
+		totalPrice = totalPrice.Add(shippingCost)
+	}
+
+	// Taxes are due on what the customer actually pays for each line
+	taxLines := make([]tax.Line, len(lines))
+	for i, line := range lines {
+		taxLines[i] = tax.Line{
+			TaxClass: productCache[line.ProductID].TaxClass,
+			Amount:   line.Total().Sub(lineDiscounts[i]),
+		}
+	}
+	calculator := req.Taxes
+	if calculator == nil {
+		calculator = tax.NewRulesCalculator(qtx)
+	}
+	region = region.Normalize()
+	taxes, err := calculator.Calculate(ctx, region, taxLines)
+	if err != nil {
+		return database.Order{}, fmt.Errorf("failed to calculate tax")
+	}
+	if !taxes.PricesIncludeTax {
+		totalPrice = totalPrice.Add(taxes.Total)
+	}
+
+	order, err := qtx.CreateOrder(ctx, database.CreateOrderParams{
+		UserID:           req.UserID,
+		TotalPrice:       totalPrice,
+		Status:           database.OrderStatusPending,
+		Subtotal:         subtotal,
+		DiscountTotal:    discountTotal,
+		TaxTotal:         taxes.Total,
+		PricesIncludeTax: taxes.PricesIncludeTax,
+		TaxCountry:       region.Country,
+		TaxState:         region.State,
+		ShippingAddress:  shippingAddress,
+		ShippingMethod:   shippingMethod,
+		ShippingCost:     shippingCost,
+	})
+	if err != nil {
+		return database.Order{}, fmt.Errorf("failed to create order")
+	}
+
+	subOrders, err := createSellerOrders(ctx, qtx, order, lines, lineDiscounts, taxes)
+	if err != nil {
+		return database.Order{}, fmt.Errorf("failed to split order by seller")
+	}
+
+	for i, item := range req.Items {
+		prodID, _ := uuid.Parse(item.ProductID)
+		product := productCache[prodID]
+
+		_, err := qtx.CreateOrderItem(ctx, database.CreateOrderItemParams{
+			OrderID:        order.ID,
+			ProductID:      prodID,
+			Quantity:       int32(item.Quantity),
+			Price:          product.Price,
+			TaxRate:        taxes.Lines[i].Rate,
+			TaxAmount:      taxes.Lines[i].Amount,
+			DiscountAmount: lineDiscounts[i],
+			SellerOrderID:  uuid.NullUUID{UUID: subOrders[product.UserID].ID, Valid: true},
+		})
+		if err != nil {
+			return database.Order{}, fmt.Errorf("failed to create order item")
+		}
+
+		_, err = inventory.RecordMovement(ctx, qtx, inventory.Movement{
+			ProductID: product.ID,
+			Type:      database.InventoryMovementTypeSale,
+			Quantity:  -int32(item.Quantity),
+			ActorID:   uuid.NullUUID{UUID: req.UserID, Valid: true},
+			OrderID:   uuid.NullUUID{UUID: order.ID, Valid: true},
+		})
+
+		if errors.Is(err, inventory.ErrInsufficientStock) {
+			return database.Order{}, &OrderError{Message: fmt.Sprintf("out of stock: %s", product.Name)}
+		}
+
+		if err != nil {
+			return database.Order{}, fmt.Errorf("failed to update stock")
+		}
+	}
+
+	if err := orderstatus.Created(ctx, qtx, order, uuid.NullUUID{UUID: req.UserID, Valid: true}); err != nil {
+		return database.Order{}, fmt.Errorf("failed to record order status")
+	}
+
+	for _, b := range taxes.Breakdown {
+		if err := qtx.CreateOrderTaxLine(ctx, database.CreateOrderTaxLineParams{
+			OrderID:       order.ID,
+			Name:          b.Name,
+			Rate:          b.Rate,
+			TaxableAmount: b.TaxableAmount,
+			Amount:        b.Amount,
+		}); err != nil {
+			return database.Order{}, fmt.Errorf("failed to record tax")
+		}
+	}
+
+	if discount != nil {
+		if err := promotions.Redeem(ctx, qtx, *discount, req.UserID, order.ID); err != nil {
+			return database.Order{}, fmt.Errorf("failed to redeem coupon")
+		}
+	}
+
+	return order, nil
+}
+
+// createSellerOrders creates a sub-order for every seller in the order, in the order
+// their items first appear, with the totals of that seller's lines. Shipping is charged
+// once on the parent order.
+func createSellerOrders(ctx context.Context, qtx *database.Queries, order database.Order, lines []promotions.Line, lineDiscounts []decimal.Decimal, taxes tax.Result) (map[uuid.UUID]database.SellerOrder, error) {
+	var sellers []uuid.UUID
+	params := make(map[uuid.UUID]*database.CreateSellerOrderParams)
+	for i, line := range lines {
+		p, ok := params[line.SellerID]
+		if !ok {
+			p = &database.CreateSellerOrderParams{
+				OrderID:       order.ID,
+				SellerID:      line.SellerID,
+				Status:        order.Status,
+				Subtotal:      decimal.NewFromInt(0),
+				DiscountTotal: decimal.NewFromInt(0),
+				TaxTotal:      decimal.NewFromInt(0),
+			}
+			params[line.SellerID] = p
+			sellers = append(sellers, line.SellerID)
+		}
+		p.Subtotal = p.Subtotal.Add(line.Total())
+		p.DiscountTotal = p.DiscountTotal.Add(lineDiscounts[i])
+		p.TaxTotal = p.TaxTotal.Add(taxes.Lines[i].Amount)
+	}
+
+	subOrders := make(map[uuid.UUID]database.SellerOrder, len(sellers))
+	for _, sellerID := range sellers {
+		p := params[sellerID]
+		p.Total = p.Subtotal.Sub(p.DiscountTotal)
+		if !taxes.PricesIncludeTax {
+			p.Total = p.Total.Add(p.TaxTotal)
+		}
+		sub, err := qtx.CreateSellerOrder(ctx, *p)
+		if err != nil {
+			return nil, err
+		}
+		subOrders[sellerID] = sub
+	}
+	return subOrders, nil
+}
+
+// CancelOrder cancels an order none of which has shipped yet: the stock of every item
+// goes back into inventory, its coupon uses are given back and its payments are queued
+// to be voided or refunded once the caller commits. Once a sub-order is shipped or any
+// shipment went out it returns ErrPartlyShipped; the rest has to come back as a return.
+// The reason is kept in the status history. Lock the order with GetOrderByIDForUpdate
+// on the same transaction first; if any step fails the caller must roll back.
+func CancelOrder(ctx context.Context, qtx *database.Queries, order database.Order, actor uuid.NullUUID, reason string) (database.Order, error) {
+	subs, err := qtx.ListSellerOrdersByOrderForUpdate(ctx, order.ID)
+	if err != nil {
+		return order, err
+	}
+	for _, sub := range subs {
+		if sub.Status == database.OrderStatusShipped || sub.Status == database.OrderStatusDelivered {
+			return order, ErrPartlyShipped
+		}
+	}
+	shipped, err := qtx.CountShipmentItemsByOrder(ctx, order.ID)
+	if err != nil {
+		return order, err
+	}
+	if shipped > 0 {
+		return order, ErrPartlyShipped
+	}
+
+	order, err = orderstatus.Transition(ctx, qtx, order, database.OrderStatusCancelled, actor, reason)
+	if err != nil {
+		return order, err
+	}
+
+	items, err := qtx.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return order, fmt.Errorf("failed to load order items")
+	}
+
+	for _, item := range items {
+		if _, err := inventory.RecordMovement(ctx, qtx, inventory.Movement{
+			ProductID: item.ProductID,
+			Type:      database.InventoryMovementTypeReturn,
+			Quantity:  item.Quantity,
+			ActorID:   actor,
+			OrderID:   uuid.NullUUID{UUID: order.ID, Valid: true},
+			Reason:    "order cancelled",
+		}); err != nil {
+			return order, fmt.Errorf("failed to restock %s", item.ProductName)
+		}
+	}
+
+	if err := promotions.Release(ctx, qtx, order.ID); err != nil {
+		return order, fmt.Errorf("failed to release coupon")
+	}
+
+	attempts, err := qtx.ListPaymentsByOrder(ctx, order.ID)
+	if err != nil {
+		return order, fmt.Errorf("failed to load payments")
+	}
+
+	for _, p := range attempts {
+		if err := payments.Release(ctx, qtx, p.ID); err != nil {
+			return order, err
+		}
+	}
+
+	return order, nil
+}
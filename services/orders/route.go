@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/orderstream"
 	"github.com/ARCoder181105/ecom/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -20,6 +21,9 @@ func Routes(db *sql.DB) chi.Router {
 			handleUserOrdersList(w, r, q)
 		})
 
+		// Live order updates as Server-Sent Events
+		r.Mount("/stream", orderstream.Routes(db))
+
 		r.Get("/orders/{orderID}", func(w http.ResponseWriter, r *http.Request) {
 			handleGetOrderById(w, r, q)
 		})
@@ -29,8 +33,28 @@ func Routes(db *sql.DB) chi.Router {
 			handlePlaceOrder(w, r, db)
 		})
 
+		r.Get("/orders/{orderID}/invoice", func(w http.ResponseWriter, r *http.Request) {
+			handleGetInvoice(w, r, q)
+		})
+
+		r.Get("/orders/{orderID}/credit-notes", func(w http.ResponseWriter, r *http.Request) {
+			handleListCreditNotes(w, r, q)
+		})
+
+		r.Get("/orders/{orderID}/credit-notes/{invoiceID}", func(w http.ResponseWriter, r *http.Request) {
+			handleGetCreditNote(w, r, q)
+		})
+
+		r.Post("/orders/{orderID}/cancel", func(w http.ResponseWriter, r *http.Request) {
+			handleCancelOrder(w, r, db)
+		})
+
+		r.Put("/orders/{orderID}/seller-orders/{sellerOrderID}/rating", func(w http.ResponseWriter, r *http.Request) {
+			handleRateSellerOrder(w, r, q)
+		})
+
 		r.Post("/updateOrderStatus", func(w http.ResponseWriter, r *http.Request) {
-			handleAdminUpdateStatus(w, r, q)
+			handleAdminUpdateStatus(w, r, db)
 		})
 
 	})
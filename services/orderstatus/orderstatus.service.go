@@ -0,0 +1,256 @@
+package orderstatus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/ARCoder181105/ecom/services/invoices"
+	"github.com/ARCoder181105/ecom/services/ledger"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+)
+
+// Statuses lists every order status in lifecycle order.
+var Statuses = []database.OrderStatus{
+	database.OrderStatusPending,
+	database.OrderStatusPaid,
+	database.OrderStatusShipped,
+	database.OrderStatusDelivered,
+	database.OrderStatusCancelled,
+	database.OrderStatusRefunded,
+}
+
+// transitions is the order lifecycle: pending -> paid -> shipped -> delivered. Orders
+// can be cancelled until they ship and refunded once paid. Cancelled and refunded
+// orders are final.
+var transitions = map[database.OrderStatus][]database.OrderStatus{
+	database.OrderStatusPending:   {database.OrderStatusPaid, database.OrderStatusCancelled},
+	database.OrderStatusPaid:      {database.OrderStatusShipped, database.OrderStatusCancelled, database.OrderStatusRefunded},
+	database.OrderStatusShipped:   {database.OrderStatusDelivered, database.OrderStatusRefunded},
+	database.OrderStatusDelivered: {database.OrderStatusRefunded},
+}
+
+// TransitionError is a status change the lifecycle doesn't allow.
+type TransitionError struct {
+	From database.OrderStatus
+	To   database.OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order cannot go from %s to %s", e.From, e.To)
+}
+
+// Parse validates a status name.
+func Parse(s string) (database.OrderStatus, error) {
+	status := database.OrderStatus(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Statuses {
+		if status == known {
+			return status, nil
+		}
+	}
+	names := make([]string, len(Statuses))
+	for i, known := range Statuses {
+		names[i] = string(known)
+	}
+	return "", fmt.Errorf("status must be one of %s", strings.Join(names, ", "))
+}
+
+// CanTransition reports whether an order can move from one status to another.
+func CanTransition(from, to database.OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Created records the initial status of a new order in its history and records
+// order.created for the order and seller_order.created, with its items, for each
+// sub-order. Call it once the items are in.
+func Created(ctx context.Context, qtx *database.Queries, order database.Order, actor uuid.NullUUID) error {
+	if err := qtx.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+		OrderID:  order.ID,
+		ToStatus: order.Status,
+		ActorID:  actor,
+		Note:     "order placed",
+	}); err != nil {
+		return err
+	}
+
+	if err := events.Record(ctx, qtx, events.OrderCreated, order.ID, mytypes.NewOrderEventData(order)); err != nil {
+		return err
+	}
+
+	subs, err := qtx.ListSellerOrdersByOrder(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		items, err := qtx.ListSellerOrderItems(ctx, uuid.NullUUID{UUID: sub.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		data := mytypes.NewSellerOrderResponse(sub)
+		data.Items = make([]mytypes.SellerOrderItemResponse, 0, len(items))
+		for _, item := range items {
+			data.Items = append(data.Items, mytypes.NewSellerOrderItemResponse(item))
+		}
+		if err := events.Record(ctx, qtx, events.SellerOrderCreated, sub.ID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transition moves the order to a new status and records who did it and why. Its
+// per-seller sub-orders follow wherever the lifecycle allows, so paying or cancelling
+// the order pays or cancels every sub-order. Paid orders are booked in the sellers'
+// ledger. Records order.status_changed for the order and seller_order.status_changed
+// for each sub-order that moved. actor is empty for system changes such as payment
+// events. Lock the order row first (GetOrderByIDForUpdate) so concurrent changes can't
+// skip a check.
+func Transition(ctx context.Context, qtx *database.Queries, order database.Order, to database.OrderStatus, actor uuid.NullUUID, note string) (database.Order, error) {
+	if !CanTransition(order.Status, to) {
+		return order, &TransitionError{From: order.Status, To: to}
+	}
+
+	if err := qtx.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
+		ID:     order.ID,
+		Status: to,
+	}); err != nil {
+		return order, err
+	}
+
+	if err := qtx.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+		OrderID:    order.ID,
+		FromStatus: database.NullOrderStatus{OrderStatus: order.Status, Valid: true},
+		ToStatus:   to,
+		ActorID:    actor,
+		Note:       note,
+	}); err != nil {
+		return order, err
+	}
+
+	subs, err := qtx.ListSellerOrdersByOrderForUpdate(ctx, order.ID)
+	if err != nil {
+		return order, err
+	}
+	for _, sub := range subs {
+		if !CanTransition(sub.Status, to) {
+			continue
+		}
+		if err := qtx.UpdateSellerOrderStatus(ctx, database.UpdateSellerOrderStatusParams{
+			ID:     sub.ID,
+			Status: to,
+		}); err != nil {
+			return order, err
+		}
+		if err := recordSellerStatus(ctx, qtx, sub, to, note); err != nil {
+			return order, err
+		}
+	}
+
+	if err := events.Record(ctx, qtx, events.OrderStatusChanged, order.ID, mytypes.OrderStatusEventData{
+		OrderID:    order.ID.String(),
+		FromStatus: string(order.Status),
+		ToStatus:   string(to),
+		Note:       note,
+	}); err != nil {
+		return order, err
+	}
+
+	if to == database.OrderStatusPaid {
+		if err := ledger.RecordSale(ctx, qtx, order); err != nil {
+			return order, err
+		}
+		if _, err := invoices.Issue(ctx, qtx, order); err != nil {
+			return order, err
+		}
+	}
+
+	order.Status = to
+	return order, nil
+}
+
+// TransitionSellerOrder moves one seller's sub-order to a new status and records it in
+// the order's history. Once every sub-order still being fulfilled has shipped (or been
+// delivered) the order itself follows. Lock the order first, then the sub-order.
+func TransitionSellerOrder(ctx context.Context, qtx *database.Queries, order database.Order, sub database.SellerOrder, to database.OrderStatus, actor uuid.NullUUID, note string) (database.SellerOrder, error) {
+	if !CanTransition(sub.Status, to) {
+		return sub, &TransitionError{From: sub.Status, To: to}
+	}
+
+	if err := qtx.UpdateSellerOrderStatus(ctx, database.UpdateSellerOrderStatusParams{
+		ID:     sub.ID,
+		Status: to,
+	}); err != nil {
+		return sub, err
+	}
+
+	if err := qtx.CreateOrderStatusHistory(ctx, database.CreateOrderStatusHistoryParams{
+		OrderID:       order.ID,
+		SellerOrderID: uuid.NullUUID{UUID: sub.ID, Valid: true},
+		FromStatus:    database.NullOrderStatus{OrderStatus: sub.Status, Valid: true},
+		ToStatus:      to,
+		ActorID:       actor,
+		Note:          note,
+	}); err != nil {
+		return sub, err
+	}
+	if err := recordSellerStatus(ctx, qtx, sub, to, note); err != nil {
+		return sub, err
+	}
+	sub.Status = to
+
+	subs, err := qtx.ListSellerOrdersByOrderForUpdate(ctx, order.ID)
+	if err != nil {
+		return sub, err
+	}
+	for _, stage := range []database.OrderStatus{database.OrderStatusShipped, database.OrderStatusDelivered} {
+		if !allReached(subs, stage) || !CanTransition(order.Status, stage) {
+			continue
+		}
+		order, err = Transition(ctx, qtx, order, stage, uuid.NullUUID{}, "all sellers "+string(stage))
+		if err != nil {
+			return sub, err
+		}
+	}
+
+	return sub, nil
+}
+
+// allReached reports whether every active sub-order is at the stage or past it.
+// Cancelled and refunded sub-orders have nothing left to ship and don't count.
+func allReached(subs []database.SellerOrder, stage database.OrderStatus) bool {
+	active := 0
+	for _, sub := range subs {
+		switch sub.Status {
+		case database.OrderStatusCancelled, database.OrderStatusRefunded:
+			continue
+		case database.OrderStatusDelivered:
+		case database.OrderStatusShipped:
+			if stage == database.OrderStatusDelivered {
+				return false
+			}
+		default:
+			return false
+		}
+		active++
+	}
+	return active > 0
+}
+
+// recordSellerStatus records seller_order.status_changed for a sub-order that moved.
+func recordSellerStatus(ctx context.Context, qtx *database.Queries, sub database.SellerOrder, to database.OrderStatus, note string) error {
+	return events.Record(ctx, qtx, events.SellerOrderStatusChanged, sub.ID, mytypes.OrderStatusEventData{
+		OrderID:       sub.OrderID.String(),
+		SellerOrderID: sub.ID.String(),
+		FromStatus:    string(sub.Status),
+		ToStatus:      string(to),
+		Note:          note,
+	})
+}
@@ -0,0 +1,73 @@
+package orderstatus
+
+import (
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+)
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[database.OrderStatus][]database.OrderStatus{
+		database.OrderStatusPending:   {database.OrderStatusPaid, database.OrderStatusCancelled},
+		database.OrderStatusPaid:      {database.OrderStatusShipped, database.OrderStatusCancelled, database.OrderStatusRefunded},
+		database.OrderStatusShipped:   {database.OrderStatusDelivered, database.OrderStatusRefunded},
+		database.OrderStatusDelivered: {database.OrderStatusRefunded},
+		database.OrderStatusCancelled: nil,
+		database.OrderStatusRefunded:  nil,
+	}
+
+	// Every pair of statuses, so a new edge in the lifecycle has to be added here too
+	for _, from := range Statuses {
+		for _, to := range Statuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	if CanTransition("completed", database.OrderStatusPaid) {
+		t.Error("CanTransition from an unknown status should be false")
+	}
+}
+
+func TestAllReached(t *testing.T) {
+	subs := func(statuses ...database.OrderStatus) []database.SellerOrder {
+		out := make([]database.SellerOrder, len(statuses))
+		for i, s := range statuses {
+			out[i] = database.SellerOrder{Status: s}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		subs  []database.SellerOrder
+		stage database.OrderStatus
+		want  bool
+	}{
+		{"all shipped", subs(database.OrderStatusShipped, database.OrderStatusShipped), database.OrderStatusShipped, true},
+		{"delivered counts as shipped", subs(database.OrderStatusShipped, database.OrderStatusDelivered), database.OrderStatusShipped, true},
+		{"one still paid", subs(database.OrderStatusShipped, database.OrderStatusPaid), database.OrderStatusShipped, false},
+		{"one still pending", subs(database.OrderStatusPending, database.OrderStatusDelivered), database.OrderStatusDelivered, false},
+		{"shipped is not delivered", subs(database.OrderStatusDelivered, database.OrderStatusShipped), database.OrderStatusDelivered, false},
+		{"all delivered", subs(database.OrderStatusDelivered, database.OrderStatusDelivered), database.OrderStatusDelivered, true},
+		{"cancelled sub-orders are skipped", subs(database.OrderStatusCancelled, database.OrderStatusShipped), database.OrderStatusShipped, true},
+		{"refunded sub-orders are skipped", subs(database.OrderStatusRefunded, database.OrderStatusDelivered), database.OrderStatusDelivered, true},
+		{"nothing left to ship", subs(database.OrderStatusCancelled, database.OrderStatusRefunded), database.OrderStatusShipped, false},
+		{"no sub-orders", nil, database.OrderStatusShipped, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allReached(tt.subs, tt.stage); got != tt.want {
+				t.Errorf("allReached(%s) = %v, want %v", tt.stage, got, tt.want)
+			}
+		})
+	}
+}
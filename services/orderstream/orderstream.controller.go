@@ -0,0 +1,127 @@
+package orderstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// Comment lines keep proxies from closing an idle stream
+	heartbeatInterval = 15 * time.Second
+	// How long browsers wait before reconnecting, in milliseconds
+	retryMillis = 3000
+	// Most events replayed to a reconnecting client
+	maxReplay = 500
+)
+
+// handleStream sends the user's order events as Server-Sent Events: status changes of
+// their orders, and new sub-orders and their status changes for sellers. ?order_id=
+// narrows it to one order. A client that reconnects with Last-Event-ID (browsers do
+// this on their own) first gets the events it missed.
+func handleStream(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	orderID := ""
+	if s := r.URL.Query().Get("order_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+			return
+		}
+		orderID = id.String()
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// Subscribe before catching up so nothing falls between the two
+	live, cancel := streams.subscribe(userID)
+	defer cancel()
+
+	var missed []mytypes.OrderStreamEvent
+	if lastEventID != "" {
+		id, err := uuid.Parse(lastEventID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid last event id"))
+			return
+		}
+		if missed, err = missedEvents(r.Context(), q, userID, id); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to load missed events"))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func(msg mytypes.OrderStreamEvent) error {
+		if orderID != "" && msg.OrderID != orderID {
+			return nil
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+	seen := make(map[string]bool, len(missed))
+	for _, msg := range missed {
+		seen[msg.ID] = true
+		if err := send(msg); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-live:
+			if !ok {
+				// Dropped by the hub; the client reconnects and catches up
+				return
+			}
+			if seen[msg.ID] {
+				continue
+			}
+			if err := send(msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
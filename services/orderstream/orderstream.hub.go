@@ -0,0 +1,126 @@
+package orderstream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	channel = "order_stream"
+	// A client that falls this far behind is dropped; it reconnects and catches up
+	// from its Last-Event-ID
+	clientBuffer = 32
+	// The listener pings Postgres this often to notice a dead connection
+	listenerPing = 90 * time.Second
+)
+
+// hub hands notifications to the streams open on this server.
+type hub struct {
+	mu      sync.Mutex
+	clients map[uuid.UUID]map[chan mytypes.OrderStreamEvent]struct{}
+}
+
+var streams = &hub{clients: make(map[uuid.UUID]map[chan mytypes.OrderStreamEvent]struct{})}
+
+// subscribe opens a stream for the user. The channel is closed when the client falls
+// behind or the server loses its notifications; call cancel when the client leaves.
+func (h *hub) subscribe(userID uuid.UUID) (<-chan mytypes.OrderStreamEvent, func()) {
+	ch := make(chan mytypes.OrderStreamEvent, clientBuffer)
+
+	h.mu.Lock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[chan mytypes.OrderStreamEvent]struct{})
+	}
+	h.clients[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(userID, ch)
+	}
+}
+
+// remove closes a client's channel once. The caller holds mu.
+func (h *hub) remove(userID uuid.UUID, ch chan mytypes.OrderStreamEvent) {
+	if _, ok := h.clients[userID][ch]; !ok {
+		return
+	}
+	delete(h.clients[userID], ch)
+	if len(h.clients[userID]) == 0 {
+		delete(h.clients, userID)
+	}
+	close(ch)
+}
+
+func (h *hub) publish(userID uuid.UUID, msg mytypes.OrderStreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients[userID] {
+		select {
+		case ch <- msg:
+		default:
+			h.remove(userID, ch)
+		}
+	}
+}
+
+// dropAll ends every stream, so clients reconnect and catch up on what this server
+// may have missed.
+func (h *hub) dropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, clients := range h.clients {
+		for ch := range clients {
+			h.remove(userID, ch)
+		}
+	}
+}
+
+// Listen receives stream notifications from Postgres and passes them to this server's
+// clients until ctx is cancelled. connStr is the database's connection string; the
+// listener needs its own connection.
+func Listen(ctx context.Context, connStr string) {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("⚠️  order stream listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		log.Printf("⚠️  order stream listener: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(listenerPing)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			streams.dropAll()
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				// Reconnected; anything sent meanwhile is lost
+				streams.dropAll()
+				continue
+			}
+			var note notification
+			if err := json.Unmarshal([]byte(n.Extra), &note); err != nil {
+				log.Printf("⚠️  order stream: bad notification: %v", err)
+				continue
+			}
+			streams.publish(note.UserID, note.Event)
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
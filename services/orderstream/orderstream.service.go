@@ -0,0 +1,113 @@
+package orderstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+)
+
+// notification is the pg_notify payload: a stream event and the user it is for.
+type notification struct {
+	UserID uuid.UUID                `json:"user_id"`
+	Event  mytypes.OrderStreamEvent `json:"event"`
+}
+
+// streamEvent builds what clients see from a domain event.
+func streamEvent(e events.Event) (mytypes.OrderStreamEvent, error) {
+	msg := mytypes.OrderStreamEvent{
+		ID:         e.ID.String(),
+		Type:       e.Type,
+		OccurredAt: e.OccurredAt,
+	}
+
+	switch e.Type {
+	case events.OrderCreated:
+		var data mytypes.OrderEventData
+		if err := e.Decode(&data); err != nil {
+			return msg, err
+		}
+		msg.OrderID, msg.Status = data.ID, data.Status
+	case events.SellerOrderCreated:
+		var data mytypes.SellerOrderResponse
+		if err := e.Decode(&data); err != nil {
+			return msg, err
+		}
+		msg.OrderID, msg.SellerOrderID, msg.Status = data.OrderID, data.ID, data.Status
+	case events.OrderStatusChanged, events.SellerOrderStatusChanged:
+		var data mytypes.OrderStatusEventData
+		if err := e.Decode(&data); err != nil {
+			return msg, err
+		}
+		msg.OrderID, msg.SellerOrderID = data.OrderID, data.SellerOrderID
+		msg.Status, msg.FromStatus = data.ToStatus, data.FromStatus
+	default:
+		return msg, fmt.Errorf("%s events aren't streamed", e.Type)
+	}
+	return msg, nil
+}
+
+// HandleEvent is the bus subscriber that feeds the stream. Order events go to the
+// customer, seller order events to the seller. The notification is sent with
+// pg_notify on the subscriber's transaction, so every server gets it, and only once
+// the event is handled.
+func HandleEvent(ctx context.Context, qtx *database.Queries, e events.Event) error {
+	msg, err := streamEvent(e)
+	if err != nil {
+		return err
+	}
+
+	var userID uuid.UUID
+	switch e.Type {
+	case events.OrderCreated, events.OrderStatusChanged:
+		if userID, err = qtx.GetOrderUserID(ctx, e.AggregateID); err != nil {
+			return err
+		}
+	case events.SellerOrderCreated, events.SellerOrderStatusChanged:
+		sub, err := qtx.GetSellerOrder(ctx, e.AggregateID)
+		if err != nil {
+			return err
+		}
+		userID = sub.SellerID
+	}
+
+	payload, err := json.Marshal(notification{UserID: userID, Event: msg})
+	if err != nil {
+		return err
+	}
+	return qtx.NotifyOrderStream(ctx, string(payload))
+}
+
+// SubscribedEvents are the domain events HandleEvent reacts to.
+var SubscribedEvents = []string{
+	events.OrderCreated,
+	events.OrderStatusChanged,
+	events.SellerOrderCreated,
+	events.SellerOrderStatusChanged,
+}
+
+// missedEvents returns the user's stream events recorded after lastEventID, for a
+// client that reconnects with Last-Event-ID.
+func missedEvents(ctx context.Context, q *database.Queries, userID, lastEventID uuid.UUID) ([]mytypes.OrderStreamEvent, error) {
+	rows, err := q.ListOrderStreamEventsSince(ctx, database.ListOrderStreamEventsSinceParams{
+		LastEventID: lastEventID,
+		UserID:      userID,
+		MaxEvents:   maxReplay,
+	})
+	if err != nil {
+		return nil, err
+	}
+	missed := make([]mytypes.OrderStreamEvent, 0, len(rows))
+	for _, row := range rows {
+		msg, err := streamEvent(events.NewEvent(row))
+		if err != nil {
+			return nil, err
+		}
+		missed = append(missed, msg)
+	}
+	return missed, nil
+}
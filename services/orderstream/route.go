@@ -0,0 +1,22 @@
+package orderstream
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the order event stream. It expects to be mounted behind
+// utils.AuthMiddleware.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		handleStream(w, r, q)
+	})
+
+	return r
+}
@@ -0,0 +1,442 @@
+package payments
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// respondWithPaymentError reports an Apply failure: a payment in the wrong state is a
+// conflict, anything else is a server error.
+func respondWithPaymentError(w http.ResponseWriter, err error) {
+	var paymentErr *PaymentError
+	if errors.As(err, &paymentErr) {
+		utils.RespondWithError(w, http.StatusConflict, err)
+		return
+	}
+	utils.RespondWithError(w, http.StatusInternalServerError, err)
+}
+
+// handleCreateIntent starts collecting payment for one of the customer's pending orders.
+// If a payment is already in progress it is returned instead of opening a second one.
+func handleCreateIntent(w http.ResponseWriter, r *http.Request, db *sql.DB, provider PaymentProvider) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	orderID, err := uuid.Parse(chi.URLParam(r, "orderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), orderID)
+	if err == sql.ErrNoRows || (err == nil && order.UserID != userID) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if order.Status != database.OrderStatusPending {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("order is not awaiting payment"))
+		return
+	}
+
+	open, err := qtx.GetOpenPaymentByOrder(r.Context(), order.ID)
+	if err == nil {
+		utils.RespondWithJSON(w, http.StatusOK, mytypes.NewPaymentResponse(open))
+		return
+	}
+	if err != sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	intent, err := provider.CreateIntent(r.Context(), IntentRequest{
+		OrderID: order.ID,
+		Amount:  order.TotalPrice,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadGateway, fmt.Errorf("payment provider error: %v", err))
+		return
+	}
+
+	payment, err := qtx.CreatePayment(r.Context(), database.CreatePaymentParams{
+		OrderID:     order.ID,
+		Provider:    provider.Name(),
+		ProviderRef: intent.Ref,
+		Amount:      order.TotalPrice,
+		Status:      intent.Status,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, mytypes.NewPaymentResponse(payment))
+}
+
+// handleConfirmPayment authorizes the customer's payment and, unless manual capture is
+// configured, captures it straight away.
+func handleConfirmPayment(w http.ResponseWriter, r *http.Request, db *sql.DB, provider PaymentProvider) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	paymentID, err := uuid.Parse(chi.URLParam(r, "paymentID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid payment id"))
+		return
+	}
+
+	var payload mytypes.ConfirmPaymentPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	payment, err := qtx.GetPaymentByIDForUpdate(r.Context(), paymentID)
+	if err == nil {
+		_, err = qtx.GetOrderByID(r.Context(), database.GetOrderByIDParams{
+			ID:     payment.OrderID,
+			UserID: userID,
+		})
+	}
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("payment not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if payment.Status != database.PaymentStatusRequiresConfirmation {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("payment is already %s", payment.Status))
+		return
+	}
+
+	res, err := provider.Confirm(r.Context(), payment.ProviderRef, payload.PaymentMethod)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadGateway, fmt.Errorf("payment provider error: %v", err))
+		return
+	}
+	payment, err = Apply(r.Context(), qtx, payment, res)
+	if err != nil {
+		respondWithPaymentError(w, err)
+		return
+	}
+
+	if payment.Status == database.PaymentStatusAuthorized && autoCapture() {
+		res, err := provider.Capture(r.Context(), payment.ProviderRef, payment.Amount)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadGateway, fmt.Errorf("payment provider error: %v", err))
+			return
+		}
+		payment, err = Apply(r.Context(), qtx, payment, res)
+		if err != nil {
+			respondWithPaymentError(w, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewPaymentResponse(payment))
+}
+
+// lockPaymentForAdmin checks the caller is an admin and locks the payment in the URL.
+// It writes the error response itself and returns false when the request can't go on.
+func lockPaymentForAdmin(w http.ResponseWriter, r *http.Request, qtx *database.Queries) (database.Payment, bool) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return database.Payment{}, false
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return database.Payment{}, false
+	}
+
+	paymentID, err := uuid.Parse(chi.URLParam(r, "paymentID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid payment id"))
+		return database.Payment{}, false
+	}
+
+	payment, err := qtx.GetPaymentByIDForUpdate(r.Context(), paymentID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("payment not found"))
+		return database.Payment{}, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.Payment{}, false
+	}
+
+	return payment, true
+}
+
+func handleCapturePayment(w http.ResponseWriter, r *http.Request, db *sql.DB, provider PaymentProvider) {
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	payment, ok := lockPaymentForAdmin(w, r, qtx)
+	if !ok {
+		return
+	}
+
+	if payment.Status != database.PaymentStatusAuthorized {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("only authorized payments can be captured"))
+		return
+	}
+
+	res, err := provider.Capture(r.Context(), payment.ProviderRef, payment.Amount)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadGateway, fmt.Errorf("payment provider error: %v", err))
+		return
+	}
+	payment, err = Apply(r.Context(), qtx, payment, res)
+	if err != nil {
+		respondWithPaymentError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewPaymentResponse(payment))
+}
+
+func handleRefundPayment(w http.ResponseWriter, r *http.Request, db *sql.DB, provider PaymentProvider) {
+	var payload mytypes.RefundPaymentPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	payment, ok := lockPaymentForAdmin(w, r, qtx)
+	if !ok {
+		return
+	}
+
+	if payment.Status != database.PaymentStatusCaptured {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("only captured payments can be refunded"))
+		return
+	}
+
+	// Refunds still waiting for the gateway, such as those of returns, are spoken for
+	queued, err := qtx.SumPendingRefundsByPayment(r.Context(), payment.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	remaining := payment.Amount.Sub(payment.RefundedAmount).Sub(queued)
+	if !remaining.IsPositive() {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("the rest of the payment is already being refunded"))
+		return
+	}
+	amount := remaining
+	if payload.Amount != "" {
+		amount, err = decimal.NewFromString(payload.Amount)
+		if err != nil || !amount.IsPositive() || amount.GreaterThan(remaining) {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("amount must be between 0 and %s", remaining.StringFixed(2)))
+			return
+		}
+	}
+
+	res, err := provider.Refund(r.Context(), payment.ProviderRef, amount, uuid.NewString())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadGateway, fmt.Errorf("payment provider error: %v", err))
+		return
+	}
+	payment, err = Apply(r.Context(), qtx, payment, res)
+	if err != nil {
+		respondWithPaymentError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewPaymentResponse(payment))
+}
+
+// handleListOrderPayments lists every payment attempt on an order, latest first.
+// Customers see their own orders, admins any order.
+func handleListOrderPayments(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	orderID, err := uuid.Parse(chi.URLParam(r, "orderID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		_, err := q.GetOrderByID(r.Context(), database.GetOrderByIDParams{
+			ID:     orderID,
+			UserID: userID,
+		})
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	payments, err := q.ListPaymentsByOrder(r.Context(), orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		payment := mytypes.NewPaymentResponse(p)
+		// The gateway's reference is for reconciling with the gateway, not for customers
+		if claims.Role == "admin" {
+			payment.ProviderRef = p.ProviderRef
+		}
+		resp = append(resp, payment)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleWebhook applies an event pushed by the gateway. Each event is handled once;
+// events that no longer fit the payment, such as a late authorization for a payment
+// that was already captured, are acknowledged and ignored.
+func handleWebhook(w http.ResponseWriter, r *http.Request, db *sql.DB, provider PaymentProvider) {
+	event, err := provider.ParseWebhook(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	payment, err := qtx.GetPaymentByProviderRefForUpdate(r.Context(), database.GetPaymentByProviderRefForUpdateParams{
+		Provider:    provider.Name(),
+		ProviderRef: event.Ref,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("payment not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	recorded, err := qtx.RecordPaymentEvent(r.Context(), database.RecordPaymentEventParams{
+		Provider:  provider.Name(),
+		EventID:   event.ID,
+		EventType: event.Type,
+		PaymentID: uuid.NullUUID{UUID: payment.ID, Valid: true},
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if recorded == 0 {
+		utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "event already processed"})
+		return
+	}
+
+	message := "event processed"
+	_, err = Apply(r.Context(), qtx, payment, event.Result)
+	var paymentErr *PaymentError
+	if errors.As(err, &paymentErr) {
+		message = "event ignored: " + paymentErr.Message
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+}
@@ -0,0 +1,246 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Payment methods understood by the fake gateway. Any other method succeeds.
+const (
+	FakeCardDeclined          = "fake_card_declined"
+	FakeCardInsufficientFunds = "fake_card_insufficient_funds"
+)
+
+// FakeProvider is an in-memory gateway for local development and tests. Intents live
+// only as long as the process.
+type FakeProvider struct {
+	mu            sync.Mutex
+	intents       map[string]*fakeIntent
+	refunds       map[string]Result // By idempotency key
+	webhookSecret string
+}
+
+type fakeIntent struct {
+	amount   decimal.Decimal
+	refunded decimal.Decimal
+	status   database.PaymentStatus
+}
+
+// NewFakeProvider returns an empty fake gateway. Webhooks must carry the hex HMAC-SHA256
+// of the body keyed with webhookSecret in X-Fake-Signature; with an empty secret every
+// webhook is rejected.
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		intents:       make(map[string]*fakeIntent),
+		refunds:       make(map[string]Result),
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeProvider) CreateIntent(ctx context.Context, req IntentRequest) (Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ref := "fake_pi_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	f.intents[ref] = &fakeIntent{
+		amount:   req.Amount,
+		refunded: decimal.NewFromInt(0),
+		status:   database.PaymentStatusRequiresConfirmation,
+	}
+	return Intent{Ref: ref, Status: database.PaymentStatusRequiresConfirmation}, nil
+}
+
+func (f *FakeProvider) intent(ref string) (*fakeIntent, error) {
+	intent, ok := f.intents[ref]
+	if !ok {
+		return nil, fmt.Errorf("fake gateway: unknown payment intent %s", ref)
+	}
+	return intent, nil
+}
+
+func (f *FakeProvider) result(intent *fakeIntent, failureReason string) Result {
+	return Result{Status: intent.status, RefundedAmount: intent.refunded, FailureReason: failureReason}
+}
+
+func (f *FakeProvider) Confirm(ctx context.Context, ref, paymentMethod string) (Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, err := f.intent(ref)
+	if err != nil {
+		return Result{}, err
+	}
+	if intent.status != database.PaymentStatusRequiresConfirmation {
+		return Result{}, fmt.Errorf("fake gateway: payment intent %s is %s", ref, intent.status)
+	}
+
+	switch paymentMethod {
+	case FakeCardDeclined:
+		intent.status = database.PaymentStatusFailed
+		return f.result(intent, "card declined"), nil
+	case FakeCardInsufficientFunds:
+		intent.status = database.PaymentStatusFailed
+		return f.result(intent, "insufficient funds"), nil
+	}
+
+	intent.status = database.PaymentStatusAuthorized
+	return f.result(intent, ""), nil
+}
+
+func (f *FakeProvider) Capture(ctx context.Context, ref string, amount decimal.Decimal) (Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, err := f.intent(ref)
+	if err != nil {
+		return Result{}, err
+	}
+	if intent.status != database.PaymentStatusAuthorized {
+		return Result{}, fmt.Errorf("fake gateway: payment intent %s is %s", ref, intent.status)
+	}
+	if amount.GreaterThan(intent.amount) {
+		return Result{}, fmt.Errorf("fake gateway: cannot capture more than was authorized")
+	}
+
+	intent.amount = amount
+	intent.status = database.PaymentStatusCaptured
+	return f.result(intent, ""), nil
+}
+
+func (f *FakeProvider) Void(ctx context.Context, ref string) (Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, err := f.intent(ref)
+	if err != nil {
+		return Result{}, err
+	}
+	if intent.status == database.PaymentStatusCancelled {
+		return f.result(intent, ""), nil
+	}
+	if intent.status != database.PaymentStatusRequiresConfirmation && intent.status != database.PaymentStatusAuthorized {
+		return Result{}, fmt.Errorf("fake gateway: payment intent %s is %s", ref, intent.status)
+	}
+
+	intent.status = database.PaymentStatusCancelled
+	return f.result(intent, ""), nil
+}
+
+func (f *FakeProvider) Refund(ctx context.Context, ref string, amount decimal.Decimal, key string) (Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if res, ok := f.refunds[key]; ok {
+		return res, nil
+	}
+
+	intent, err := f.intent(ref)
+	if err != nil {
+		return Result{}, err
+	}
+	if intent.status != database.PaymentStatusCaptured {
+		return Result{}, fmt.Errorf("fake gateway: payment intent %s is %s", ref, intent.status)
+	}
+	if intent.refunded.Add(amount).GreaterThan(intent.amount) {
+		return Result{}, fmt.Errorf("fake gateway: cannot refund more than was captured")
+	}
+
+	intent.refunded = intent.refunded.Add(amount)
+	if intent.refunded.Equal(intent.amount) {
+		intent.status = database.PaymentStatusRefunded
+	}
+	f.refunds[key] = f.result(intent, "")
+	return f.refunds[key], nil
+}
+
+// fakeWebhook is the body of a fake gateway webhook.
+type fakeWebhook struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"` // payment.authorized, payment.captured, payment.failed or payment.refunded
+	ProviderRef    string `json:"provider_ref"`
+	RefundedAmount string `json:"refunded_amount"` // payment.refunded only, total refunded so far
+	FailureReason  string `json:"failure_reason"`
+}
+
+var fakeEventStatus = map[string]database.PaymentStatus{
+	"payment.authorized": database.PaymentStatusAuthorized,
+	"payment.captured":   database.PaymentStatusCaptured,
+	"payment.failed":     database.PaymentStatusFailed,
+	"payment.refunded":   database.PaymentStatusCaptured,
+}
+
+// ParseWebhook decodes a simulated gateway event. Events also update the fake's own
+// state, so a webhook can stand in for things that happen outside the API such as a
+// refund issued from the gateway dashboard.
+func (f *FakeProvider) ParseWebhook(r *http.Request) (Event, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Event{}, ErrInvalidWebhook
+	}
+
+	if f.webhookSecret == "" {
+		return Event{}, ErrInvalidWebhook
+	}
+	mac := hmac.New(sha256.New, []byte(f.webhookSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Fake-Signature"))) {
+		return Event{}, ErrInvalidWebhook
+	}
+
+	var hook fakeWebhook
+	if err := json.Unmarshal(body, &hook); err != nil || hook.ID == "" || hook.ProviderRef == "" {
+		return Event{}, ErrInvalidWebhook
+	}
+	status, ok := fakeEventStatus[hook.Type]
+	if !ok {
+		return Event{}, ErrInvalidWebhook
+	}
+
+	refunded := decimal.NewFromInt(0)
+	if hook.Type == "payment.refunded" {
+		refunded, err = decimal.NewFromString(hook.RefundedAmount)
+		if err != nil || !refunded.IsPositive() {
+			return Event{}, ErrInvalidWebhook
+		}
+	}
+
+	f.mu.Lock()
+	if intent, ok := f.intents[hook.ProviderRef]; ok {
+		intent.status = status
+		if refunded.GreaterThan(intent.refunded) {
+			intent.refunded = refunded
+		}
+		if intent.refunded.GreaterThanOrEqual(intent.amount) {
+			intent.status = database.PaymentStatusRefunded
+		}
+	}
+	f.mu.Unlock()
+
+	return Event{
+		ID:   hook.ID,
+		Type: hook.Type,
+		Ref:  hook.ProviderRef,
+		Result: Result{
+			Status:         status,
+			RefundedAmount: refunded,
+			FailureReason:  hook.FailureReason,
+		},
+	}, nil
+}
@@ -0,0 +1,208 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/jobs"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// refundJob sends a queued refund to the gateway. Gateway calls can't be rolled back,
+// so they never run inside the transaction that asks for them: the refund is written
+// there and this job makes it once that transaction has committed.
+var refundJob = jobs.Type[refundPayload]{Kind: "payments.refund", MaxAttempts: 10}
+
+type refundPayload struct {
+	RefundID uuid.UUID `json:"refund_id"`
+}
+
+// RefundRequest is a refund to queue with QueueRefund.
+type RefundRequest struct {
+	PaymentID uuid.UUID
+	// Amount to refund from a captured payment. Without one, whatever the payment still
+	// holds when the job runs is released: an uncaptured intent is voided and the rest of
+	// a captured payment refunded.
+	Amount       decimal.NullDecimal
+	SellerShares map[uuid.UUID]decimal.Decimal // See Result
+	ReturnID     uuid.NullUUID                 // Marked refunded once the refund is made
+}
+
+// QueueRefund records a refund and queues the job that makes it. Run it on the
+// transaction that asks for the refund, so the refund only happens if that commits.
+func QueueRefund(ctx context.Context, qtx *database.Queries, req RefundRequest) (database.PaymentRefund, error) {
+	shares, err := json.Marshal(req.SellerShares)
+	if err != nil {
+		return database.PaymentRefund{}, err
+	}
+	if req.SellerShares == nil {
+		shares = []byte("{}")
+	}
+
+	refund, err := qtx.CreatePaymentRefund(ctx, database.CreatePaymentRefundParams{
+		PaymentID:    req.PaymentID,
+		Amount:       req.Amount,
+		SellerShares: shares,
+		ReturnID:     req.ReturnID,
+	})
+	if err != nil {
+		return refund, err
+	}
+	if _, err := refundJob.Enqueue(ctx, qtx, refundPayload{RefundID: refund.ID}); err != nil {
+		return refund, err
+	}
+	return refund, nil
+}
+
+// Release queues giving back whatever the payment holds when its order is cancelled.
+// Finished payments are left alone.
+func Release(ctx context.Context, qtx *database.Queries, paymentID uuid.UUID) error {
+	p, err := qtx.GetPaymentByIDForUpdate(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	switch p.Status {
+	case database.PaymentStatusRequiresConfirmation, database.PaymentStatusAuthorized, database.PaymentStatusCaptured:
+		_, err = QueueRefund(ctx, qtx, RefundRequest{PaymentID: p.ID})
+		return err
+	}
+	return nil
+}
+
+// RegisterJobs adds the refund sender to the job pool.
+func RegisterJobs(pool *jobs.Pool, provider PaymentProvider) {
+	jobs.Handle(pool, refundJob, func(ctx context.Context, db *sql.DB, p refundPayload) error {
+		return makeRefund(ctx, db, provider, p.RefundID)
+	})
+}
+
+// makeRefund sends a pending refund to the gateway, then records the result in a
+// transaction of its own. The refund's id goes to the gateway as the idempotency key,
+// so a retry after a crash between the two gets the first result back instead of
+// refunding twice.
+func makeRefund(ctx context.Context, db *sql.DB, provider PaymentProvider, refundID uuid.UUID) error {
+	q := database.New(db)
+
+	refund, err := q.GetPaymentRefund(ctx, refundID)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("refund %s no longer exists", refundID))
+	}
+	if err != nil {
+		return err
+	}
+	if refund.Status != database.PaymentRefundStatusPending {
+		return nil
+	}
+
+	p, err := q.GetPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	res, done, err := sendRefund(ctx, provider, p, refund)
+	var paymentErr *PaymentError
+	if errors.As(err, &paymentErr) {
+		log.Printf("refund %s failed: %v", refund.ID, err)
+		_, err = q.CompletePaymentRefund(ctx, database.CompletePaymentRefundParams{
+			ID:        refund.ID,
+			Status:    database.PaymentRefundStatusFailed,
+			LastError: err.Error(),
+		})
+		return err
+	}
+	if err != nil {
+		err = fmt.Errorf("payment provider error: %v", err)
+		if recordErr := q.RecordPaymentRefundError(ctx, database.RecordPaymentRefundErrorParams{
+			ID:        refund.ID,
+			LastError: err.Error(),
+		}); recordErr != nil {
+			log.Printf("failed to record error of refund %s: %v", refund.ID, recordErr)
+		}
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	refund, err = qtx.GetPaymentRefundForUpdate(ctx, refund.ID)
+	if err != nil {
+		return err
+	}
+	if refund.Status != database.PaymentRefundStatusPending {
+		return nil
+	}
+
+	if !done {
+		p, err = qtx.GetPaymentByIDForUpdate(ctx, refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(refund.SellerShares, &res.SellerShares); err != nil {
+			return jobs.Permanent(err)
+		}
+		if p, err = Apply(ctx, qtx, p, res); err != nil {
+			return err
+		}
+	}
+
+	if _, err := qtx.CompletePaymentRefund(ctx, database.CompletePaymentRefundParams{
+		ID:     refund.ID,
+		Status: database.PaymentRefundStatusSucceeded,
+	}); err != nil {
+		return err
+	}
+
+	if refund.ReturnID.Valid {
+		if _, err := qtx.MarkReturnRefunded(ctx, database.MarkReturnRefundedParams{
+			ID:           refund.ReturnID.UUID,
+			RefundAmount: refund.Amount.Decimal,
+			PaymentID:    uuid.NullUUID{UUID: p.ID, Valid: true},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// sendRefund makes the refund at the gateway, going by the payment's status now rather
+// than when the refund was queued: an intent that was captured in the meantime is
+// refunded instead of voided. done is true when a release finds nothing left to give
+// back. A *PaymentError means the refund can't be made at all.
+func sendRefund(ctx context.Context, provider PaymentProvider, p database.Payment, refund database.PaymentRefund) (res Result, done bool, err error) {
+	switch p.Status {
+	case database.PaymentStatusRequiresConfirmation, database.PaymentStatusAuthorized:
+		if refund.Amount.Valid {
+			return res, false, &PaymentError{Message: fmt.Sprintf("payment is %s, only captured payments can be refunded", p.Status)}
+		}
+		res, err = provider.Void(ctx, p.ProviderRef)
+		return res, false, err
+	case database.PaymentStatusCaptured:
+		remaining := p.Amount.Sub(p.RefundedAmount)
+		amount := remaining
+		if refund.Amount.Valid {
+			amount = refund.Amount.Decimal
+		}
+		if amount.GreaterThan(remaining) {
+			return res, false, &PaymentError{Message: fmt.Sprintf("refund of %s is more than the %s left on the payment", amount.StringFixed(2), remaining.StringFixed(2))}
+		}
+		res, err = provider.Refund(ctx, p.ProviderRef, amount, refund.ID.String())
+		return res, false, err
+	}
+
+	if refund.Amount.Valid {
+		return res, false, &PaymentError{Message: fmt.Sprintf("payment is %s, only captured payments can be refunded", p.Status)}
+	}
+	return res, true, nil
+}
@@ -0,0 +1,138 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// fakePayment creates an intent of 100.00 on the fake gateway, taken as far as status,
+// and the payment row that goes with it.
+func fakePayment(t *testing.T, f *FakeProvider, status database.PaymentStatus) database.Payment {
+	t.Helper()
+	ctx := context.Background()
+	amount := decimal.NewFromInt(100)
+
+	intent, err := f.CreateIntent(ctx, IntentRequest{OrderID: uuid.New(), Amount: amount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != database.PaymentStatusRequiresConfirmation {
+		if _, err := f.Confirm(ctx, intent.Ref, "card"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if status == database.PaymentStatusCaptured {
+		if _, err := f.Capture(ctx, intent.Ref, amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return database.Payment{ID: uuid.New(), ProviderRef: intent.Ref, Amount: amount, RefundedAmount: decimal.Zero, Status: status}
+}
+
+func TestSendRefund(t *testing.T) {
+	amount := func(s string) decimal.NullDecimal {
+		return decimal.NullDecimal{Decimal: decimal.RequireFromString(s), Valid: true}
+	}
+
+	tests := []struct {
+		name         string
+		status       database.PaymentStatus
+		refunded     string // Already refunded on the payment
+		amount       decimal.NullDecimal
+		wantStatus   database.PaymentStatus
+		wantRefunded string
+		wantDone     bool
+		wantErr      bool
+	}{
+		{name: "release voids an unconfirmed intent", status: database.PaymentStatusRequiresConfirmation, wantStatus: database.PaymentStatusCancelled, wantRefunded: "0"},
+		{name: "release voids an authorization", status: database.PaymentStatusAuthorized, wantStatus: database.PaymentStatusCancelled, wantRefunded: "0"},
+		{name: "release refunds a capture in full", status: database.PaymentStatusCaptured, wantStatus: database.PaymentStatusRefunded, wantRefunded: "100"},
+		{name: "release refunds what is left", status: database.PaymentStatusCaptured, refunded: "30", wantStatus: database.PaymentStatusRefunded, wantRefunded: "100"},
+		{name: "partial refund", status: database.PaymentStatusCaptured, amount: amount("25"), wantStatus: database.PaymentStatusCaptured, wantRefunded: "25"},
+		{name: "refund of more than is left", status: database.PaymentStatusCaptured, refunded: "80", amount: amount("25"), wantErr: true},
+		{name: "refund of an uncaptured payment", status: database.PaymentStatusAuthorized, amount: amount("25"), wantErr: true},
+		{name: "release of a finished payment", status: database.PaymentStatusFailed, wantDone: true},
+		{name: "refund of a finished payment", status: database.PaymentStatusRefunded, amount: amount("25"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := NewFakeProvider("secret")
+
+			var p database.Payment
+			switch tt.status {
+			case database.PaymentStatusFailed, database.PaymentStatusRefunded:
+				p = database.Payment{ID: uuid.New(), ProviderRef: "fake_pi_gone", Amount: decimal.NewFromInt(100), Status: tt.status}
+			default:
+				p = fakePayment(t, f, tt.status)
+			}
+			if tt.refunded != "" {
+				refunded := decimal.RequireFromString(tt.refunded)
+				if _, err := f.Refund(ctx, p.ProviderRef, refunded, "earlier"); err != nil {
+					t.Fatal(err)
+				}
+				p.RefundedAmount = refunded
+			}
+
+			refund := database.PaymentRefund{ID: uuid.New(), PaymentID: p.ID, Amount: tt.amount}
+			res, done, err := sendRefund(ctx, f, p, refund)
+
+			var paymentErr *PaymentError
+			if tt.wantErr {
+				if !errors.As(err, &paymentErr) {
+					t.Fatalf("sendRefund() error = %v, want a *PaymentError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sendRefund() error = %v", err)
+			}
+			if done != tt.wantDone {
+				t.Fatalf("done = %v, want %v", done, tt.wantDone)
+			}
+			if done {
+				return
+			}
+			if res.Status != tt.wantStatus || !res.RefundedAmount.Equal(decimal.RequireFromString(tt.wantRefunded)) {
+				t.Errorf("result = %s with %s refunded, want %s with %s", res.Status, res.RefundedAmount, tt.wantStatus, tt.wantRefunded)
+			}
+		})
+	}
+}
+
+// A job retried after the gateway made the refund but before it was recorded sends the
+// same key again and must not refund twice.
+func TestSendRefundRetry(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeProvider("secret")
+	p := fakePayment(t, f, database.PaymentStatusCaptured)
+	refund := database.PaymentRefund{ID: uuid.New(), PaymentID: p.ID, Amount: decimal.NullDecimal{Decimal: decimal.NewFromInt(40), Valid: true}}
+
+	first, _, err := sendRefund(ctx, f, p, refund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, _, err := sendRefund(ctx, f, p, refund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !retry.RefundedAmount.Equal(first.RefundedAmount) || !retry.RefundedAmount.Equal(decimal.NewFromInt(40)) {
+		t.Errorf("refunded %s, then %s on retry, want 40 both times", first.RefundedAmount, retry.RefundedAmount)
+	}
+
+	// A voided intent voided again stays cancelled
+	p = fakePayment(t, f, database.PaymentStatusAuthorized)
+	release := database.PaymentRefund{ID: uuid.New(), PaymentID: p.ID}
+	for i := 0; i < 2; i++ {
+		res, _, err := sendRefund(ctx, f, p, release)
+		if err != nil || res.Status != database.PaymentStatusCancelled {
+			t.Fatalf("void %d = %s, %v", i+1, res.Status, err)
+		}
+	}
+}
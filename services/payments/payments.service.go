@@ -0,0 +1,220 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/invoices"
+	"github.com/ARCoder181105/ecom/services/ledger"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	"github.com/ARCoder181105/ecom/services/orderstatus"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentProvider is a payment gateway. Providers only talk to the gateway; recording
+// payments and moving orders along is done here so every provider behaves the same.
+type PaymentProvider interface {
+	// Name identifies the provider in the payments table and webhook URLs
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (Intent, error)
+	// Confirm authorizes the intent with a payment method from the client
+	Confirm(ctx context.Context, ref, paymentMethod string) (Result, error)
+	Capture(ctx context.Context, ref string, amount decimal.Decimal) (Result, error)
+	// Void cancels an intent that hasn't been captured. Voiding it again returns the
+	// cancelled intent.
+	Void(ctx context.Context, ref string) (Result, error)
+	// Refund gives back amount of a captured payment. The gateway refunds once per key
+	// and returns the first result when a key is sent again, so retries are safe.
+	Refund(ctx context.Context, ref string, amount decimal.Decimal, key string) (Result, error)
+	// ParseWebhook verifies and decodes an event pushed by the gateway
+	ParseWebhook(r *http.Request) (Event, error)
+}
+
+// IntentRequest asks the gateway to get ready to collect an order's total.
+type IntentRequest struct {
+	OrderID uuid.UUID
+	Amount  decimal.Decimal
+}
+
+// Intent is a payment the gateway is ready to collect.
+type Intent struct {
+	Ref    string
+	Status database.PaymentStatus
+}
+
+// Result is the state of a payment at the gateway after an operation or event.
+// RefundedAmount is the total refunded so far, not the amount of the last refund.
+type Result struct {
+	Status         database.PaymentStatus
+	RefundedAmount decimal.Decimal
+	FailureReason  string
+	// SellerShares optionally says which sellers a refund is for, such as the value of
+	// returned items per seller. Providers leave it empty; see ledger.RecordRefund.
+	SellerShares map[uuid.UUID]decimal.Decimal
+}
+
+// Event is a webhook notification from the gateway about one payment.
+type Event struct {
+	ID   string // Unique per event, used to skip redeliveries
+	Type string
+	Ref  string
+	Result
+}
+
+// ErrInvalidWebhook is returned by ParseWebhook for requests that don't come from the
+// gateway or can't be understood.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// PaymentError is an operation that doesn't fit the payment's current state, such as
+// capturing a failed payment.
+type PaymentError struct {
+	Message string
+}
+
+func (e *PaymentError) Error() string {
+	return e.Message
+}
+
+// transitions lists where a payment can go from each status. Failed, refunded and
+// cancelled payments are final.
+var transitions = map[database.PaymentStatus][]database.PaymentStatus{
+	database.PaymentStatusRequiresConfirmation: {database.PaymentStatusAuthorized, database.PaymentStatusCaptured, database.PaymentStatusFailed, database.PaymentStatusCancelled},
+	database.PaymentStatusAuthorized:           {database.PaymentStatusCaptured, database.PaymentStatusFailed, database.PaymentStatusCancelled},
+	database.PaymentStatusCaptured:             {database.PaymentStatusRefunded},
+}
+
+func canTransition(from, to database.PaymentStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NewProvider returns the gateway named by PAYMENT_PROVIDER. There is no default: the
+// only built-in gateway is the fake, which approves any card, so it has to be asked for
+// explicitly and needs FAKE_PAYMENTS_WEBHOOK_SECRET to sign its webhooks.
+func NewProvider() (PaymentProvider, error) {
+	switch name := os.Getenv("PAYMENT_PROVIDER"); name {
+	case "":
+		return nil, fmt.Errorf("PAYMENT_PROVIDER is not set, use PAYMENT_PROVIDER=fake to try checkout without a real gateway")
+	case "fake":
+		secret := os.Getenv("FAKE_PAYMENTS_WEBHOOK_SECRET")
+		if secret == "" {
+			return nil, fmt.Errorf("FAKE_PAYMENTS_WEBHOOK_SECRET must be set to use the fake payment provider")
+		}
+		return NewFakeProvider(secret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", name)
+	}
+}
+
+var (
+	defaultProvider     PaymentProvider
+	defaultProviderErr  error
+	defaultProviderOnce sync.Once
+)
+
+// DefaultProvider returns the process-wide gateway from NewProvider. Everything that
+// talks to the gateway shares it, which matters for the in-memory fake.
+func DefaultProvider() (PaymentProvider, error) {
+	defaultProviderOnce.Do(func() {
+		defaultProvider, defaultProviderErr = NewProvider()
+	})
+	return defaultProvider, defaultProviderErr
+}
+
+// autoCapture reports whether confirmed payments are captured straight away. Set
+// PAYMENTS_MANUAL_CAPTURE=true to only authorize them and capture later.
+func autoCapture() bool {
+	return os.Getenv("PAYMENTS_MANUAL_CAPTURE") != "true"
+}
+
+// Apply records a gateway result on the payment and moves the order with it: a captured
+// payment marks a pending order paid and a full refund marks it refunded. Refunds are
+// booked against the sellers in the ledger. Run it on a transaction holding the
+// payment's row lock.
+func Apply(ctx context.Context, qtx *database.Queries, p database.Payment, res Result) (database.Payment, error) {
+	status := res.Status
+	if res.RefundedAmount.IsPositive() && res.RefundedAmount.GreaterThanOrEqual(p.Amount) {
+		status = database.PaymentStatusRefunded
+	}
+	if !canTransition(p.Status, status) {
+		return p, &PaymentError{Message: fmt.Sprintf("payment is %s and cannot become %s", p.Status, status)}
+	}
+
+	updated, err := qtx.UpdatePayment(ctx, database.UpdatePaymentParams{
+		ID:             p.ID,
+		Status:         status,
+		RefundedAmount: res.RefundedAmount,
+		FailureReason:  res.FailureReason,
+	})
+	if err != nil {
+		return p, err
+	}
+	if updated.Status == p.Status && updated.RefundedAmount.Equal(p.RefundedAmount) {
+		return updated, nil
+	}
+
+	order, err := qtx.GetOrderByIDForUpdate(ctx, p.OrderID)
+	if err != nil {
+		return p, err
+	}
+
+	refunded := updated.RefundedAmount.Sub(p.RefundedAmount)
+	if err := ledger.RecordRefund(ctx, qtx, order, refunded, res.SellerShares); err != nil {
+		return p, err
+	}
+	if _, err := invoices.IssueCreditNote(ctx, qtx, order, refunded, uuid.NullUUID{UUID: p.ID, Valid: true}); err != nil {
+		return p, err
+	}
+
+	var next database.OrderStatus
+	var notificationType, title, body string
+	switch updated.Status {
+	case database.PaymentStatusCaptured:
+		if updated.RefundedAmount.IsPositive() {
+			notificationType, title = notifications.TypeRefunded, "Partial refund issued"
+			body = fmt.Sprintf("%s of your payment for order %s has been refunded.", updated.RefundedAmount.StringFixed(2), order.ID)
+		} else if order.Status == database.OrderStatusPending {
+			next = database.OrderStatusPaid
+			notificationType, title = notifications.TypePaymentReceived, "Payment received"
+			body = fmt.Sprintf("We received your payment of %s for order %s.", updated.Amount.StringFixed(2), order.ID)
+		}
+	case database.PaymentStatusRefunded:
+		// Cancelled orders stay cancelled, the refund just settles them
+		if orderstatus.CanTransition(order.Status, database.OrderStatusRefunded) {
+			next = database.OrderStatusRefunded
+		}
+		notificationType, title = notifications.TypeRefunded, "Order refunded"
+		body = fmt.Sprintf("Your payment for order %s has been refunded in full.", order.ID)
+	case database.PaymentStatusFailed:
+		notificationType, title = notifications.TypePaymentFailed, "Payment failed"
+		body = fmt.Sprintf("Your payment for order %s failed: %s", order.ID, updated.FailureReason)
+	}
+
+	if next != "" {
+		note := fmt.Sprintf("payment %s %s", updated.ID, updated.Status)
+		if _, err := orderstatus.Transition(ctx, qtx, order, next, uuid.NullUUID{}, note); err != nil {
+			return p, err
+		}
+	}
+
+	if notificationType != "" {
+		if err := notifications.Notify(ctx, qtx, order.UserID, notificationType, title, body); err != nil {
+			return p, err
+		}
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,54 @@
+package payments
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up payment collection for orders and the gateway webhook, using the
+// provider picked by PAYMENT_PROVIDER.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	provider, err := DefaultProvider()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// Called by the gateway, authenticated by the provider's webhook signature
+	r.Post("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		handleWebhook(w, r, db, provider)
+	})
+
+	r.Group(func(pr chi.Router) {
+		pr.Use(utils.AuthMiddleware)
+
+		pr.Get("/orders/{orderID}", func(w http.ResponseWriter, r *http.Request) {
+			handleListOrderPayments(w, r, q)
+		})
+
+		pr.Post("/orders/{orderID}", func(w http.ResponseWriter, r *http.Request) {
+			handleCreateIntent(w, r, db, provider)
+		})
+
+		pr.Post("/{paymentID}/confirm", func(w http.ResponseWriter, r *http.Request) {
+			handleConfirmPayment(w, r, db, provider)
+		})
+
+		pr.Post("/{paymentID}/capture", func(w http.ResponseWriter, r *http.Request) {
+			handleCapturePayment(w, r, db, provider)
+		})
+
+		pr.Post("/{paymentID}/refund", func(w http.ResponseWriter, r *http.Request) {
+			handleRefundPayment(w, r, db, provider)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,46 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// SaleActive reports whether the product's scheduled sale price applies at the given time.
+func SaleActive(p database.Product, at time.Time) bool {
+	if !p.SalePrice.Valid {
+		return false
+	}
+	if p.SaleStartsAt.Valid && at.Before(p.SaleStartsAt.Time) {
+		return false
+	}
+	if p.SaleEndsAt.Valid && !at.Before(p.SaleEndsAt.Time) {
+		return false
+	}
+	return true
+}
+
+// EffectivePrice is the unit price a customer pays at the given time: the sale price
+// while a sale is running, otherwise the regular price.
+func EffectivePrice(p database.Product, at time.Time) decimal.Decimal {
+	if SaleActive(p, at) {
+		return p.SalePrice.Decimal
+	}
+	return p.Price
+}
+
+// RecordPriceChange snapshots the product's current pricing into price_history.
+func RecordPriceChange(ctx context.Context, qtx *database.Queries, p database.Product, actorID uuid.UUID) error {
+	return qtx.CreatePriceHistory(ctx, database.CreatePriceHistoryParams{
+		ProductID:      p.ID,
+		Price:          p.Price,
+		CompareAtPrice: p.CompareAtPrice,
+		SalePrice:      p.SalePrice,
+		SaleStartsAt:   p.SaleStartsAt,
+		SaleEndsAt:     p.SaleEndsAt,
+		ChangedBy:      uuid.NullUUID{UUID: actorID, Valid: true},
+	})
+}
@@ -3,14 +3,21 @@ package products
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
 
 	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/ARCoder181105/ecom/services/inventory"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	"github.com/ARCoder181105/ecom/services/tax"
 	mytypes "github.com/ARCoder181105/ecom/types"
 	"github.com/ARCoder181105/ecom/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
@@ -19,6 +26,7 @@ func handleGetAllProducts(w http.ResponseWriter, r *http.Request, q *database.Qu
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
 	search := r.URL.Query().Get("search")
+	inStockOnly := r.URL.Query().Get("in_stock") == "true"
 
 	page := 1
 	limit := 10
@@ -33,11 +41,12 @@ func handleGetAllProducts(w http.ResponseWriter, r *http.Request, q *database.Qu
 	}
 
 	offset := (page - 1) * limit
-	
+
 	params := database.ListProductsParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
-		Column3: sql.NullString{String: search, Valid: true}, 
+		Limit:   int32(limit),
+		Offset:  int32(offset),
+		Column3: sql.NullString{String: search, Valid: true},
+		Column4: inStockOnly,
 	}
 
 	products, err := q.ListProducts(context.Background(), params)
@@ -46,7 +55,10 @@ func handleGetAllProducts(w http.ResponseWriter, r *http.Request, q *database.Qu
 		return
 	}
 
-	totalCount, err := q.CountProducts(context.Background(), sql.NullString{String: search, Valid: true})
+	totalCount, err := q.CountProducts(context.Background(), database.CountProductsParams{
+		Column1: sql.NullString{String: search, Valid: true},
+		Column2: inStockOnly,
+	})
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("error counting products"))
 		return
@@ -54,22 +66,13 @@ func handleGetAllProducts(w http.ResponseWriter, r *http.Request, q *database.Qu
 
 	var responseProducts []mytypes.ProductResponse
 	for _, row := range products {
-		responseProducts = append(responseProducts, mytypes.ProductResponse{
-			ID:            row.ID.String(),
-			Name:          row.Name,
-			Description:   row.Description,
-			Image:         row.Image.String,
-			Price:         row.Price.String(),
-			StockQuantity: int(row.StockQuantity),
-			CreatedAt:     row.CreatedAt,
-			UserID:        row.UserID.String(),
-		})
+		responseProducts = append(responseProducts, mytypes.NewProductResponse(row))
 	}
 
 	response := map[string]interface{}{
-		"data":       responseProducts,
-		"page":       page,
-		"limit":      limit,
+		"data":        responseProducts,
+		"page":        page,
+		"limit":       limit,
 		"total_items": totalCount,
 		"total_pages": (int(totalCount) + limit - 1) / limit,
 	}
@@ -77,6 +80,33 @@ func handleGetAllProducts(w http.ResponseWriter, r *http.Request, q *database.Qu
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
+func handleGetProductBySlug(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	slug := chi.URLParam(r, "slug")
+
+	product, err := q.GetProductBySlug(r.Context(), slug)
+	if err == sql.ErrNoRows {
+		// Renamed products keep their old slugs as permanent redirects
+		current, err := q.GetSlugRedirectTarget(r.Context(), slug)
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.Redirect(w, r, path.Join(path.Dir(r.URL.Path), current), http.StatusMovedPermanently)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewProductResponse(product))
+}
+
 func handleGetProductByID(w http.ResponseWriter, r *http.Request, q *database.Queries) {
 	productIDStr := chi.URLParam(r, "productID")
 
@@ -96,21 +126,12 @@ func handleGetProductByID(w http.ResponseWriter, r *http.Request, q *database.Qu
 		return
 	}
 
-	resp := mytypes.ProductResponse{
-		ID:            product.ID.String(),
-		Name:          product.Name,
-		Description:   product.Description,
-		Image:         product.Image.String,
-		Price:         product.Price.String(),
-		StockQuantity: int(product.StockQuantity),
-		CreatedAt:     product.CreatedAt,
-		UserID:        product.UserID.String(),
-	}
+	resp := mytypes.NewProductResponse(product)
 
 	utils.RespondWithJSON(w, http.StatusOK, resp)
 }
 
-func handleCreateProduct(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+func handleCreateProduct(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	var payload mytypes.CreateProductPayload
 
 	// Parse JSON
@@ -140,25 +161,93 @@ func handleCreateProduct(w http.ResponseWriter, r *http.Request, q *database.Que
 		return
 	}
 
-	// Insert product
-	product, err := q.CreateProduct(context.Background(), database.CreateProductParams{
-		Name:          payload.Name,
-		Description:   payload.Description,
-		Image:         sql.NullString{String: payload.Image, Valid: payload.Image != ""},
-		Price:         price,
-		StockQuantity: int32(payload.StockQuantity),
-		UserID:        userID,
+	if payload.StockQuantity < 0 || payload.LowStockThreshold < 0 || payload.WeightGrams < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("stock quantity, low stock threshold and weight cannot be negative"))
+		return
+	}
+
+	taxClass, err := tax.ParseClass(payload.TaxClass)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := ParseCategory(payload.Category)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	slug, err := UniqueSlug(r.Context(), qtx, payload.Name, uuid.Nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to generate slug"))
+		return
+	}
+
+	// Insert product with no stock, the opening quantity goes through the ledger
+	product, err := qtx.CreateProduct(context.Background(), database.CreateProductParams{
+		Name:              payload.Name,
+		Description:       payload.Description,
+		Image:             sql.NullString{String: payload.Image, Valid: payload.Image != ""},
+		Price:             price,
+		StockQuantity:     0,
+		UserID:            userID,
+		LowStockThreshold: int32(payload.LowStockThreshold),
+		Sku:               sql.NullString{String: payload.SKU, Valid: payload.SKU != ""},
+		Slug:              slug,
+		TaxClass:          taxClass,
+		WeightGrams:       int32(payload.WeightGrams),
+		Category:          category,
 	})
 
+	if constraint, ok := uniqueViolation(err); ok {
+		utils.RespondWithError(w, http.StatusConflict, uniqueViolationError(constraint))
+		return
+	}
+
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err := pricing.RecordPriceChange(r.Context(), qtx, product, userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record price history"))
+		return
+	}
+
+	if payload.StockQuantity > 0 {
+		movement, err := inventory.RecordMovement(r.Context(), qtx, inventory.Movement{
+			ProductID: product.ID,
+			Type:      database.InventoryMovementTypeRestock,
+			Quantity:  int32(payload.StockQuantity),
+			ActorID:   uuid.NullUUID{UUID: userID, Valid: true},
+			Reason:    "initial stock",
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record initial stock"))
+			return
+		}
+		product.StockQuantity = movement.BalanceAfter
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
 	utils.RespondWithJSON(w, http.StatusCreated, product)
 }
 
-func handleUpdateProduct(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+func handleUpdateProduct(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	productIDStr := chi.URLParam(r, "productID")
 	productID, err := uuid.Parse(productIDStr)
 
@@ -186,16 +275,61 @@ func handleUpdateProduct(w http.ResponseWriter, r *http.Request, q *database.Que
 	}
 	userID, _ := uuid.Parse(claims.UserID)
 
-	updatedProduct, err := q.UpdateProduct(context.Background(), database.UpdateProductParams{
-		ID:            productID,
-		Name:          payload.Name,
-		Description:   payload.Description,
-		Image:         sql.NullString{String: payload.Image, Valid: payload.Image != ""},
-		Price:         price,
-		StockQuantity: int32(payload.StockQuantity),
-		UserID:        userID,
+	if payload.StockQuantity < 0 || payload.LowStockThreshold < 0 || payload.WeightGrams < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("stock quantity, low stock threshold and weight cannot be negative"))
+		return
+	}
+
+	taxClass, err := tax.ParseClass(payload.TaxClass)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := ParseCategory(payload.Category)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	previous, err := qtx.GetProductByIDForUpdate(context.Background(), productID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	updatedProduct, err := qtx.UpdateProduct(context.Background(), database.UpdateProductParams{
+		ID:                productID,
+		Name:              payload.Name,
+		Description:       payload.Description,
+		Image:             sql.NullString{String: payload.Image, Valid: payload.Image != ""},
+		Price:             price,
+		UserID:            userID,
+		LowStockThreshold: int32(payload.LowStockThreshold),
+		Sku:               sql.NullString{String: payload.SKU, Valid: payload.SKU != ""},
+		TaxClass:          taxClass,
+		WeightGrams:       int32(payload.WeightGrams),
+		Category:          category,
 	})
 
+	if constraint, ok := uniqueViolation(err); ok {
+		utils.RespondWithError(w, http.StatusConflict, uniqueViolationError(constraint))
+		return
+	}
+
 	if err == sql.ErrNoRows {
 		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("you do not own this product"))
 		return
@@ -206,6 +340,46 @@ func handleUpdateProduct(w http.ResponseWriter, r *http.Request, q *database.Que
 		return
 	}
 
+	if updatedProduct.Name != previous.Name {
+		if updatedProduct.Slug, err = SyncSlug(r.Context(), qtx, updatedProduct); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update slug"))
+			return
+		}
+	}
+
+	if !updatedProduct.Price.Equal(previous.Price) {
+		if err := pricing.RecordPriceChange(r.Context(), qtx, updatedProduct, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record price history"))
+			return
+		}
+	}
+
+	// A changed stock_quantity is recorded as a manual adjustment instead of a silent overwrite
+	if delta := int32(payload.StockQuantity) - updatedProduct.StockQuantity; delta != 0 {
+		movement, err := inventory.RecordMovement(r.Context(), qtx, inventory.Movement{
+			ProductID: productID,
+			Type:      database.InventoryMovementTypeAdjustment,
+			Quantity:  delta,
+			ActorID:   uuid.NullUUID{UUID: userID, Valid: true},
+			Reason:    "stock edited via product update",
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record stock adjustment"))
+			return
+		}
+		updatedProduct.StockQuantity = movement.BalanceAfter
+	}
+
+	if err := events.Record(r.Context(), qtx, events.ProductUpdated, updatedProduct.ID, mytypes.NewProductEventData(updatedProduct)); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record product event"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, updatedProduct)
 }
 
@@ -258,3 +432,252 @@ func handleDeleteProduct(w http.ResponseWriter, r *http.Request, q *database.Que
 		"message": "product deleted successfully",
 	})
 }
+
+func handleSubscribeBackInStock(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	product, err := q.GetProductByID(r.Context(), productID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if product.StockQuantity > 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("product is in stock"))
+		return
+	}
+
+	if _, err := q.CreateStockSubscription(r.Context(), database.CreateStockSubscriptionParams{
+		ProductID: productID,
+		UserID:    userID,
+	}); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{
+		"message": "you will be notified when this product is back in stock",
+	})
+}
+
+func handleUnsubscribeBackInStock(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	affected, err := q.DeleteStockSubscription(r.Context(), database.DeleteStockSubscriptionParams{
+		ProductID: productID,
+		UserID:    userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("subscription not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "back in stock notification cancelled",
+	})
+}
+
+// uniqueViolation reports whether err is a Postgres unique_violation and which
+// constraint or unique index it hit.
+func uniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
+
+// uniqueViolationError explains a unique violation on products to the client. A slug
+// collision means another product took the same name between UniqueSlug and the write.
+func uniqueViolationError(constraint string) error {
+	if constraint == "products_slug_key" {
+		return fmt.Errorf("another product with the same name was just saved, please try again")
+	}
+	return fmt.Errorf("sku already exists in your catalog")
+}
+
+func handleUpdatePricing(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	var payload mytypes.UpdatePricingPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	compareAt, err := parseOptionalPrice(payload.CompareAtPrice)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid compare at price"))
+		return
+	}
+
+	salePrice, err := parseOptionalPrice(payload.SalePrice)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid sale price"))
+		return
+	}
+
+	var saleStarts, saleEnds sql.NullTime
+	if salePrice.Valid {
+		if payload.SaleStartsAt != nil {
+			saleStarts = sql.NullTime{Time: payload.SaleStartsAt.UTC(), Valid: true}
+		}
+		if payload.SaleEndsAt != nil {
+			saleEnds = sql.NullTime{Time: payload.SaleEndsAt.UTC(), Valid: true}
+		}
+		if saleStarts.Valid && saleEnds.Valid && !saleStarts.Time.Before(saleEnds.Time) {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("sale must end after it starts"))
+			return
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	product, err := qtx.UpdateProductPricing(r.Context(), database.UpdateProductPricingParams{
+		ID:             productID,
+		UserID:         userID,
+		CompareAtPrice: compareAt,
+		SalePrice:      salePrice,
+		SaleStartsAt:   saleStarts,
+		SaleEndsAt:     saleEnds,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("you do not own this product"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if salePrice.Valid && !salePrice.Decimal.LessThan(product.Price) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("sale price must be lower than the regular price"))
+		return
+	}
+
+	if err := pricing.RecordPriceChange(r.Context(), qtx, product, userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record price history"))
+		return
+	}
+
+	if err := events.Record(r.Context(), qtx, events.ProductUpdated, product.ID, mytypes.NewProductEventData(product)); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to record product event"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewProductResponse(product))
+}
+
+func handleGetPriceHistory(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	if _, err := q.GetProductByID(r.Context(), productID); err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	history, err := q.ListPriceHistoryByProduct(r.Context(), productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list price history"))
+		return
+	}
+
+	responseHistory := []mytypes.PriceHistoryResponse{}
+	for _, h := range history {
+		entry := mytypes.PriceHistoryResponse{
+			Price:     h.Price.String(),
+			ChangedAt: h.CreatedAt,
+		}
+		if h.CompareAtPrice.Valid {
+			entry.CompareAtPrice = h.CompareAtPrice.Decimal.String()
+		}
+		if h.SalePrice.Valid {
+			entry.SalePrice = h.SalePrice.Decimal.String()
+		}
+		if h.SaleStartsAt.Valid {
+			entry.SaleStartsAt = &h.SaleStartsAt.Time
+		}
+		if h.SaleEndsAt.Valid {
+			entry.SaleEndsAt = &h.SaleEndsAt.Time
+		}
+		responseHistory = append(responseHistory, entry)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, responseHistory)
+}
+
+// parseOptionalPrice parses a non-negative price, treating an empty string as NULL.
+func parseOptionalPrice(s string) (decimal.NullDecimal, error) {
+	if s == "" {
+		return decimal.NullDecimal{}, nil
+	}
+
+	price, err := decimal.NewFromString(s)
+	if err != nil || price.IsNegative() {
+		return decimal.NullDecimal{}, fmt.Errorf("invalid price")
+	}
+
+	return decimal.NullDecimal{Decimal: price, Valid: true}, nil
+}
@@ -0,0 +1,87 @@
+package products
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+var categoryPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// ParseCategory validates a product category such as "home-garden". Products don't
+// need one.
+func ParseCategory(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category != "" && !categoryPattern.MatchString(category) {
+		return "", fmt.Errorf("category may only contain lowercase letters, digits, dashes and underscores")
+	}
+	return category, nil
+}
+
+// Slugify turns a product name into a URL-safe slug such as "blue-cotton-t-shirt".
+func Slugify(name string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 200 {
+		slug = strings.TrimRight(slug[:200], "-")
+	}
+	if slug == "" {
+		slug = "product"
+	}
+	return slug
+}
+
+// UniqueSlug returns a slug for name that no other product uses or used to use, adding a
+// numeric suffix when needed. Pass uuid.Nil for a product that is not created yet.
+func UniqueSlug(ctx context.Context, qtx *database.Queries, name string, productID uuid.UUID) (string, error) {
+	base := Slugify(name)
+	slug := base
+	for n := 2; ; n++ {
+		taken, err := qtx.SlugTaken(ctx, database.SlugTakenParams{
+			Slug:      slug,
+			ProductID: productID,
+		})
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// SyncSlug gives a renamed product a slug matching its new name and keeps the old slug
+// as a redirect. It returns the product's current slug.
+func SyncSlug(ctx context.Context, qtx *database.Queries, product database.Product) (string, error) {
+	slug, err := UniqueSlug(ctx, qtx, product.Name, product.ID)
+	if err != nil || slug == product.Slug {
+		return product.Slug, err
+	}
+
+	if err := qtx.CreateSlugRedirect(ctx, database.CreateSlugRedirectParams{
+		Slug:      product.Slug,
+		ProductID: product.ID,
+	}); err != nil {
+		return product.Slug, err
+	}
+
+	// The product may be taking back one of its own old slugs
+	if err := qtx.DeleteSlugRedirect(ctx, slug); err != nil {
+		return product.Slug, err
+	}
+
+	if err := qtx.UpdateProductSlug(ctx, database.UpdateProductSlugParams{
+		ID:   product.ID,
+		Slug: slug,
+	}); err != nil {
+		return product.Slug, err
+	}
+
+	return slug, nil
+}
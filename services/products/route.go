@@ -18,16 +18,24 @@ func Routes(db *sql.DB) chi.Router {
 		handleGetAllProducts(w, r, q)
 	})
 
-	r.Get("/getProduct/{productId}", func(w http.ResponseWriter, r *http.Request) {
+	r.Get("/getProduct/{productID}", func(w http.ResponseWriter, r *http.Request) {
 		handleGetProductByID(w, r, q)
 	})
 
+	r.Get("/by-slug/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetProductBySlug(w, r, q)
+	})
+
+	r.Get("/{productID}/price-history", func(w http.ResponseWriter, r *http.Request) {
+		handleGetPriceHistory(w, r, q)
+	})
+
 	// protected routes
 	r.Group(func(admin chi.Router) {
-		admin.Use(utils.AuthMiddleware) 
+		admin.Use(utils.AuthMiddleware)
 
 		admin.Post("/create", func(w http.ResponseWriter, r *http.Request) {
-			handleCreateProduct(w, r, q)
+			handleCreateProduct(w, r, db)
 		})
 
 		r.Post("/upload", func(w http.ResponseWriter, r *http.Request) {
@@ -35,12 +43,24 @@ func Routes(db *sql.DB) chi.Router {
 		})
 
 		admin.Put("/{productID}", func(w http.ResponseWriter, r *http.Request) {
-			handleUpdateProduct(w, r, q)
+			handleUpdateProduct(w, r, db)
 		})
 
 		admin.Delete("/{productID}", func(w http.ResponseWriter, r *http.Request) {
 			handleDeleteProduct(w, r, q)
 		})
+
+		admin.Put("/{productID}/pricing", func(w http.ResponseWriter, r *http.Request) {
+			handleUpdatePricing(w, r, db)
+		})
+
+		admin.Post("/{productID}/notify-me", func(w http.ResponseWriter, r *http.Request) {
+			handleSubscribeBackInStock(w, r, q)
+		})
+
+		admin.Delete("/{productID}/notify-me", func(w http.ResponseWriter, r *http.Request) {
+			handleUnsubscribeBackInStock(w, r, q)
+		})
 	})
 
 	return r
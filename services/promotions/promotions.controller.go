@@ -0,0 +1,388 @@
+package promotions
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/products"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+func newCouponResponse(c database.Coupon, categories []string) mytypes.CouponResponse {
+	resp := mytypes.CouponResponse{
+		ID:            c.ID.String(),
+		Code:          c.Code,
+		Description:   c.Description,
+		DiscountType:  string(c.DiscountType),
+		Amount:        c.Amount.String(),
+		MinOrderValue: c.MinOrderValue.String(),
+		Categories:    categories,
+		Active:        c.Active,
+		CreatedAt:     c.CreatedAt,
+	}
+	if c.MaxUses.Valid {
+		n := int(c.MaxUses.Int32)
+		resp.MaxUses = &n
+	}
+	if c.MaxUsesPerUser.Valid {
+		n := int(c.MaxUsesPerUser.Int32)
+		resp.MaxUsesPerUser = &n
+	}
+	if c.StartsAt.Valid {
+		resp.StartsAt = &c.StartsAt.Time
+	}
+	if c.EndsAt.Valid {
+		resp.EndsAt = &c.EndsAt.Time
+	}
+	if c.SellerID.Valid {
+		resp.SellerID = c.SellerID.UUID.String()
+	}
+	return resp
+}
+
+func optionalLimit(n *int) (sql.NullInt32, error) {
+	if n == nil {
+		return sql.NullInt32{}, nil
+	}
+	if *n <= 0 {
+		return sql.NullInt32{}, fmt.Errorf("usage limits must be positive")
+	}
+	return sql.NullInt32{Int32: int32(*n), Valid: true}, nil
+}
+
+func optionalTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func handleCreateCoupon(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "seller" && claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("only sellers can create coupons"))
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var payload mytypes.CreateCouponPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	code := NormalizeCode(payload.Code)
+	if code == "" || len(code) > 50 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("code is required and must be at most 50 characters"))
+		return
+	}
+
+	discountType := database.CouponDiscountType(payload.DiscountType)
+	amount := decimal.NewFromInt(0)
+	switch discountType {
+	case database.CouponDiscountTypePercentage, database.CouponDiscountTypeFixed:
+		amount, err = decimal.NewFromString(payload.Amount)
+		if err != nil || !amount.IsPositive() {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("amount must be a positive number"))
+			return
+		}
+		if discountType == database.CouponDiscountTypePercentage && amount.GreaterThan(decimal.NewFromInt(100)) {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("percentage cannot exceed 100"))
+			return
+		}
+	case database.CouponDiscountTypeFreeShipping:
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("discount_type must be percentage, fixed or free_shipping"))
+		return
+	}
+
+	minOrderValue := decimal.NewFromInt(0)
+	if payload.MinOrderValue != "" {
+		minOrderValue, err = decimal.NewFromString(payload.MinOrderValue)
+		if err != nil || minOrderValue.IsNegative() {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid min_order_value"))
+			return
+		}
+	}
+
+	maxUses, err := optionalLimit(payload.MaxUses)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	maxUsesPerUser, err := optionalLimit(payload.MaxUsesPerUser)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if payload.StartsAt != nil && payload.EndsAt != nil && !payload.EndsAt.After(*payload.StartsAt) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("ends_at must be after starts_at"))
+		return
+	}
+
+	// Sellers can only discount their own products
+	sellerID := uuid.NullUUID{UUID: userID, Valid: true}
+	if claims.Role == "admin" {
+		sellerID = uuid.NullUUID{}
+		if payload.SellerID != "" {
+			id, err := uuid.Parse(payload.SellerID)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller id"))
+				return
+			}
+			sellerID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	coupon, err := qtx.CreateCoupon(r.Context(), database.CreateCouponParams{
+		Code:           code,
+		Description:    payload.Description,
+		DiscountType:   discountType,
+		Amount:         amount,
+		MinOrderValue:  minOrderValue,
+		MaxUses:        maxUses,
+		MaxUsesPerUser: maxUsesPerUser,
+		StartsAt:       optionalTime(payload.StartsAt),
+		EndsAt:         optionalTime(payload.EndsAt),
+		SellerID:       sellerID,
+		CreatedBy:      userID,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("coupon code already exists"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, rawID := range payload.ProductIDs {
+		productID, err := uuid.Parse(rawID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id: %s", rawID))
+			return
+		}
+
+		product, err := qtx.GetProductByID(r.Context(), productID)
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("product not found: %s", rawID))
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if sellerID.Valid && product.UserID != sellerID.UUID {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("product %s does not belong to the coupon's seller", rawID))
+			return
+		}
+
+		if err := qtx.AddCouponProduct(r.Context(), database.AddCouponProductParams{
+			CouponID:  coupon.ID,
+			ProductID: productID,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	categories := make([]string, 0, len(payload.Categories))
+	for _, raw := range payload.Categories {
+		category, err := products.ParseCategory(raw)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+		if category == "" {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("categories cannot be empty"))
+			return
+		}
+
+		if err := qtx.AddCouponCategory(r.Context(), database.AddCouponCategoryParams{
+			CouponID: coupon.ID,
+			Category: category,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		categories = append(categories, category)
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, newCouponResponse(coupon, categories))
+}
+
+func handleListCoupons(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var coupons []database.Coupon
+	switch claims.Role {
+	case "admin":
+		coupons, err = q.ListCoupons(r.Context())
+	case "seller":
+		coupons, err = q.ListCouponsByCreator(r.Context(), userID)
+	default:
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("only sellers can manage coupons"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.CouponResponse, 0, len(coupons))
+	for _, c := range coupons {
+		categories, err := q.ListCouponCategories(r.Context(), c.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		resp = append(resp, newCouponResponse(c, categories))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+func handleDeactivateCoupon(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	couponID, err := uuid.Parse(chi.URLParam(r, "couponID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid coupon id"))
+		return
+	}
+
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var rows int64
+	if claims.Role == "admin" {
+		rows, err = q.DeactivateCouponByAdmin(r.Context(), couponID)
+	} else {
+		rows, err = q.DeactivateCoupon(r.Context(), database.DeactivateCouponParams{
+			ID:        couponID,
+			CreatedBy: userID,
+		})
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("coupon not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "coupon deactivated"})
+}
+
+// handleApplyCoupon is a dry run of a coupon against a prospective order: nothing is
+// redeemed, the customer just sees what the discount would be.
+func handleApplyCoupon(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var payload mytypes.ApplyCouponPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if payload.Code == "" || len(payload.Items) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("code and items are required"))
+		return
+	}
+
+	now := time.Now()
+	lines, err := LinesForItems(r.Context(), q, payload.Items, now)
+	if err != nil {
+		respondWithCouponError(w, err)
+		return
+	}
+
+	d, err := Evaluate(r.Context(), q, payload.Code, userID, lines, now, false)
+	if err != nil {
+		respondWithCouponError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.CouponQuoteResponse{
+		Code:             d.Coupon.Code,
+		Description:      d.Coupon.Description,
+		DiscountType:     string(d.Coupon.DiscountType),
+		Subtotal:         d.Subtotal.String(),
+		EligibleSubtotal: d.EligibleSubtotal.String(),
+		Discount:         d.Amount.String(),
+		FreeShipping:     d.FreeShipping,
+		Total:            d.Subtotal.Sub(d.Amount).String(),
+	})
+}
+
+func respondWithCouponError(w http.ResponseWriter, err error) {
+	var couponErr *CouponError
+	if errors.As(err, &couponErr) {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	utils.RespondWithError(w, http.StatusInternalServerError, err)
+}
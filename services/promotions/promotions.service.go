@@ -0,0 +1,274 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CouponError explains why a coupon cannot be used on an order. It is the customer's
+// to fix, so it is reported as a bad request.
+type CouponError struct {
+	Message string
+}
+
+func (e *CouponError) Error() string {
+	return e.Message
+}
+
+// Line is one order line as the promotion engine sees it.
+type Line struct {
+	ProductID uuid.UUID
+	SellerID  uuid.UUID
+	Category  string
+	UnitPrice decimal.Decimal
+	Quantity  int32
+}
+
+func (l Line) Total() decimal.Decimal {
+	return l.UnitPrice.Mul(decimal.NewFromInt(int64(l.Quantity)))
+}
+
+// Discount is the result of applying a coupon to a set of lines.
+type Discount struct {
+	Coupon           database.Coupon
+	Subtotal         decimal.Decimal // All lines
+	EligibleSubtotal decimal.Decimal // Lines the coupon is scoped to
+	Amount           decimal.Decimal
+	FreeShipping     bool
+	// LineAmounts splits Amount across the lines passed to Evaluate, in the same order,
+	// so taxes can be worked out on discounted prices.
+	LineAmounts []decimal.Decimal
+}
+
+// NormalizeCode upper-cases a coupon code so codes match case-insensitively.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// LinesForItems prices order items at their current effective price.
+func LinesForItems(ctx context.Context, q *database.Queries, items []mytypes.CreateOrderPayload, at time.Time) ([]Line, error) {
+	lines := make([]Line, 0, len(items))
+	for _, item := range items {
+		productID, err := uuid.Parse(item.ProductID)
+		if err != nil {
+			return nil, &CouponError{Message: fmt.Sprintf("invalid product id: %s", item.ProductID)}
+		}
+		if item.Quantity <= 0 {
+			return nil, &CouponError{Message: fmt.Sprintf("invalid quantity for product: %s", item.ProductID)}
+		}
+
+		product, err := q.GetProductByID(ctx, productID)
+		if err == sql.ErrNoRows {
+			return nil, &CouponError{Message: fmt.Sprintf("product not found: %s", item.ProductID)}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, Line{
+			ProductID: product.ID,
+			SellerID:  product.UserID,
+			Category:  product.Category,
+			UnitPrice: pricing.EffectivePrice(product, at),
+			Quantity:  int32(item.Quantity),
+		})
+	}
+	return lines, nil
+}
+
+// Evaluate checks that the coupon can be used by the user on these lines and works
+// out the discount. Set lock when evaluating inside a checkout transaction so the usage
+// limits hold under concurrent orders.
+func Evaluate(ctx context.Context, q *database.Queries, code string, userID uuid.UUID, lines []Line, at time.Time, lock bool) (Discount, error) {
+	code = NormalizeCode(code)
+
+	var coupon database.Coupon
+	var err error
+	if lock {
+		coupon, err = q.GetCouponByCodeForUpdate(ctx, code)
+	} else {
+		coupon, err = q.GetCouponByCode(ctx, code)
+	}
+	if err == sql.ErrNoRows {
+		return Discount{}, &CouponError{Message: "invalid coupon code"}
+	}
+	if err != nil {
+		return Discount{}, err
+	}
+
+	var used usage
+	if coupon.MaxUses.Valid {
+		if used.total, err = q.CountCouponRedemptions(ctx, coupon.ID); err != nil {
+			return Discount{}, err
+		}
+	}
+	if coupon.MaxUsesPerUser.Valid {
+		used.byUser, err = q.CountCouponRedemptionsByUser(ctx, database.CountCouponRedemptionsByUserParams{
+			CouponID: coupon.ID,
+			UserID:   userID,
+		})
+		if err != nil {
+			return Discount{}, err
+		}
+	}
+
+	sc := scope{products: make(map[uuid.UUID]bool), categories: make(map[string]bool)}
+	products, err := q.ListCouponProductIDs(ctx, coupon.ID)
+	if err != nil {
+		return Discount{}, err
+	}
+	for _, id := range products {
+		sc.products[id] = true
+	}
+	categories, err := q.ListCouponCategories(ctx, coupon.ID)
+	if err != nil {
+		return Discount{}, err
+	}
+	for _, category := range categories {
+		sc.categories[category] = true
+	}
+
+	return apply(coupon, used, sc, lines, at)
+}
+
+// usage is how often a coupon has been redeemed, in total and by the customer.
+type usage struct {
+	total, byUser int64
+}
+
+// scope is what a coupon is limited to beyond its seller. Product and category scopes
+// add up: a line in either is covered. An empty scope covers every line.
+type scope struct {
+	products   map[uuid.UUID]bool
+	categories map[string]bool
+}
+
+func (s scope) covers(line Line) bool {
+	if len(s.products) == 0 && len(s.categories) == 0 {
+		return true
+	}
+	return s.products[line.ProductID] || s.categories[line.Category]
+}
+
+// apply is Evaluate once the coupon's usage and scope are loaded.
+func apply(coupon database.Coupon, used usage, sc scope, lines []Line, at time.Time) (Discount, error) {
+	if !coupon.Active {
+		return Discount{}, &CouponError{Message: "coupon is no longer active"}
+	}
+	if coupon.StartsAt.Valid && at.Before(coupon.StartsAt.Time) {
+		return Discount{}, &CouponError{Message: "coupon is not valid yet"}
+	}
+	if coupon.EndsAt.Valid && !at.Before(coupon.EndsAt.Time) {
+		return Discount{}, &CouponError{Message: "coupon has expired"}
+	}
+	if coupon.MaxUses.Valid && used.total >= int64(coupon.MaxUses.Int32) {
+		return Discount{}, &CouponError{Message: "coupon usage limit reached"}
+	}
+	if coupon.MaxUsesPerUser.Valid && used.byUser >= int64(coupon.MaxUsesPerUser.Int32) {
+		return Discount{}, &CouponError{Message: "you have already used this coupon"}
+	}
+
+	d := Discount{
+		Coupon:           coupon,
+		Subtotal:         decimal.NewFromInt(0),
+		EligibleSubtotal: decimal.NewFromInt(0),
+		Amount:           decimal.NewFromInt(0),
+	}
+	eligible := make([]bool, len(lines))
+	for i, line := range lines {
+		d.Subtotal = d.Subtotal.Add(line.Total())
+		if coupon.SellerID.Valid && line.SellerID != coupon.SellerID.UUID {
+			continue
+		}
+		if !sc.covers(line) {
+			continue
+		}
+		eligible[i] = true
+		d.EligibleSubtotal = d.EligibleSubtotal.Add(line.Total())
+	}
+
+	// Checked by line rather than by value, so a free shipping coupon scoped to a free
+	// sample still needs the sample in the cart
+	if !slices.Contains(eligible, true) {
+		return Discount{}, &CouponError{Message: "coupon does not apply to these items"}
+	}
+	if d.EligibleSubtotal.LessThan(coupon.MinOrderValue) {
+		return Discount{}, &CouponError{Message: fmt.Sprintf("coupon requires a minimum order of %s", coupon.MinOrderValue.StringFixed(2))}
+	}
+
+	switch coupon.DiscountType {
+	case database.CouponDiscountTypePercentage:
+		d.Amount = d.EligibleSubtotal.Mul(coupon.Amount).Div(decimal.NewFromInt(100)).Round(2)
+	case database.CouponDiscountTypeFixed:
+		d.Amount = decimal.Min(coupon.Amount, d.EligibleSubtotal)
+	case database.CouponDiscountTypeFreeShipping:
+		d.FreeShipping = true
+	}
+
+	d.LineAmounts = allocate(d.Amount, d.EligibleSubtotal, lines, eligible)
+	return d, nil
+}
+
+// allocate splits a discount across the eligible lines in proportion to their totals.
+// Rounding leftovers go to the last eligible line so the parts add up exactly.
+func allocate(amount, eligibleSubtotal decimal.Decimal, lines []Line, eligible []bool) []decimal.Decimal {
+	parts := make([]decimal.Decimal, len(lines))
+	if amount.IsZero() {
+		for i := range parts {
+			parts[i] = decimal.NewFromInt(0)
+		}
+		return parts
+	}
+	last := -1
+	remaining := amount
+	for i, line := range lines {
+		parts[i] = decimal.NewFromInt(0)
+		if !eligible[i] {
+			continue
+		}
+		parts[i] = amount.Mul(line.Total()).Div(eligibleSubtotal).Round(2)
+		remaining = remaining.Sub(parts[i])
+		last = i
+	}
+	if last >= 0 {
+		parts[last] = parts[last].Add(remaining)
+	}
+	return parts
+}
+
+// Redeem stores the discount line on the order and counts the coupon use. Call it on
+// the same transaction that created the order.
+func Redeem(ctx context.Context, qtx *database.Queries, d Discount, userID, orderID uuid.UUID) error {
+	if _, err := qtx.CreateOrderDiscount(ctx, database.CreateOrderDiscountParams{
+		OrderID:     orderID,
+		CouponID:    uuid.NullUUID{UUID: d.Coupon.ID, Valid: true},
+		Code:        d.Coupon.Code,
+		Description: d.Coupon.Description,
+		Amount:      d.Amount,
+	}); err != nil {
+		return err
+	}
+
+	return qtx.CreateCouponRedemption(ctx, database.CreateCouponRedemptionParams{
+		CouponID: d.Coupon.ID,
+		UserID:   userID,
+		OrderID:  orderID,
+	})
+}
+
+// Release gives back the coupon uses of a cancelled order, so they count towards the
+// usage limits again. The order keeps its discount line as a record of what it was
+// offered.
+func Release(ctx context.Context, qtx *database.Queries, orderID uuid.UUID) error {
+	return qtx.DeleteCouponRedemptionsByOrder(ctx, orderID)
+}
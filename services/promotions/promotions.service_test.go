@@ -0,0 +1,289 @@
+package promotions
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	sellerA, sellerB := uuid.New(), uuid.New()
+	lamp, chair, mug := uuid.New(), uuid.New(), uuid.New()
+	lines := []Line{
+		{ProductID: lamp, SellerID: sellerA, Category: "lighting", UnitPrice: dec("20.00"), Quantity: 2},
+		{ProductID: chair, SellerID: sellerA, Category: "furniture", UnitPrice: dec("60.00"), Quantity: 1},
+		{ProductID: mug, SellerID: sellerB, Category: "kitchen", UnitPrice: dec("5.00"), Quantity: 4},
+	}
+
+	coupon := func(kind database.CouponDiscountType, amount string, edit func(*database.Coupon)) database.Coupon {
+		c := database.Coupon{ID: uuid.New(), Code: "SAVE", DiscountType: kind, Amount: dec(amount), MinOrderValue: dec("0"), Active: true}
+		if edit != nil {
+			edit(&c)
+		}
+		return c
+	}
+	products := func(ids ...uuid.UUID) scope {
+		s := scope{products: map[uuid.UUID]bool{}}
+		for _, id := range ids {
+			s.products[id] = true
+		}
+		return s
+	}
+	categories := func(names ...string) scope {
+		s := scope{categories: map[string]bool{}}
+		for _, name := range names {
+			s.categories[name] = true
+		}
+		return s
+	}
+
+	tests := []struct {
+		name         string
+		coupon       database.Coupon
+		used         usage
+		scope        scope
+		lines        []Line
+		wantErr      string
+		wantEligible string
+		wantAmount   string
+		wantLines    []string
+		wantFree     bool
+	}{
+		{
+			name:         "percentage of the whole cart",
+			coupon:       coupon(database.CouponDiscountTypePercentage, "10", nil),
+			wantEligible: "120.00", wantAmount: "12.00",
+			wantLines: []string{"4.00", "6.00", "2.00"},
+		},
+		{
+			name:    "not active",
+			coupon:  coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.Active = false }),
+			wantErr: "coupon is no longer active",
+		},
+		{
+			name: "before the window",
+			coupon: coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) {
+				c.StartsAt = sql.NullTime{Time: now.Add(time.Minute), Valid: true}
+			}),
+			wantErr: "coupon is not valid yet",
+		},
+		{
+			name: "window just started",
+			coupon: coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) {
+				c.StartsAt = sql.NullTime{Time: now, Valid: true}
+				c.EndsAt = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+			}),
+			wantEligible: "120.00", wantAmount: "12.00",
+		},
+		{
+			name: "window ends at its end time",
+			coupon: coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) {
+				c.EndsAt = sql.NullTime{Time: now, Valid: true}
+			}),
+			wantErr: "coupon has expired",
+		},
+		{
+			name:    "usage limit reached",
+			coupon:  coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.MaxUses = sql.NullInt32{Int32: 3, Valid: true} }),
+			used:    usage{total: 3},
+			wantErr: "coupon usage limit reached",
+		},
+		{
+			name:         "usage limit not reached",
+			coupon:       coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.MaxUses = sql.NullInt32{Int32: 3, Valid: true} }),
+			used:         usage{total: 2, byUser: 5},
+			wantEligible: "120.00", wantAmount: "12.00",
+		},
+		{
+			name:    "per customer limit reached",
+			coupon:  coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.MaxUsesPerUser = sql.NullInt32{Int32: 1, Valid: true} }),
+			used:    usage{total: 40, byUser: 1},
+			wantErr: "you have already used this coupon",
+		},
+		{
+			name:         "seller scope",
+			coupon:       coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.SellerID = uuid.NullUUID{UUID: sellerB, Valid: true} }),
+			wantEligible: "20.00", wantAmount: "2.00",
+			wantLines: []string{"0", "0", "2.00"},
+		},
+		{
+			name:         "product scope",
+			coupon:       coupon(database.CouponDiscountTypePercentage, "50", nil),
+			scope:        products(chair),
+			wantEligible: "60.00", wantAmount: "30.00",
+			wantLines: []string{"0", "30.00", "0"},
+		},
+		{
+			name:         "product and category scopes add up",
+			coupon:       coupon(database.CouponDiscountTypePercentage, "10", nil),
+			scope:        scope{products: map[uuid.UUID]bool{mug: true}, categories: map[string]bool{"lighting": true}},
+			wantEligible: "60.00", wantAmount: "6.00",
+			wantLines: []string{"4.00", "0", "2.00"},
+		},
+		{
+			name:         "seller and category scopes both apply",
+			coupon:       coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.SellerID = uuid.NullUUID{UUID: sellerA, Valid: true} }),
+			scope:        categories("lighting", "kitchen"),
+			wantEligible: "40.00", wantAmount: "4.00",
+			wantLines: []string{"4.00", "0", "0"},
+		},
+		{
+			name:    "nothing in scope",
+			coupon:  coupon(database.CouponDiscountTypePercentage, "10", nil),
+			scope:   categories("garden"),
+			wantErr: "coupon does not apply to these items",
+		},
+		{
+			name:    "minimum order counts the eligible lines only",
+			coupon:  coupon(database.CouponDiscountTypePercentage, "10", func(c *database.Coupon) { c.MinOrderValue = dec("50") }),
+			scope:   products(lamp),
+			wantErr: "coupon requires a minimum order of 50.00",
+		},
+		{
+			name:         "fixed amount",
+			coupon:       coupon(database.CouponDiscountTypeFixed, "15", nil),
+			scope:        products(lamp, chair),
+			wantEligible: "100.00", wantAmount: "15.00",
+			wantLines: []string{"6.00", "9.00", "0"},
+		},
+		{
+			name:         "fixed amount is capped at the eligible lines",
+			coupon:       coupon(database.CouponDiscountTypeFixed, "100", nil),
+			scope:        products(lamp),
+			wantEligible: "40.00", wantAmount: "40.00",
+			wantLines: []string{"40.00", "0", "0"},
+		},
+		{
+			name:   "rounding remainder goes to the last eligible line",
+			coupon: coupon(database.CouponDiscountTypeFixed, "10", nil),
+			lines: []Line{
+				{ProductID: lamp, SellerID: sellerA, UnitPrice: dec("10.00"), Quantity: 1},
+				{ProductID: chair, SellerID: sellerA, UnitPrice: dec("10.00"), Quantity: 1},
+				{ProductID: mug, SellerID: sellerA, UnitPrice: dec("10.00"), Quantity: 1},
+				{ProductID: uuid.New(), SellerID: sellerB, UnitPrice: dec("10.00"), Quantity: 1},
+			},
+			scope:        products(lamp, chair, mug),
+			wantEligible: "30.00", wantAmount: "10.00",
+			wantLines: []string{"3.33", "3.33", "3.34", "0"},
+		},
+		{
+			name:         "free shipping",
+			coupon:       coupon(database.CouponDiscountTypeFreeShipping, "0", nil),
+			wantEligible: "120.00", wantAmount: "0", wantFree: true,
+			wantLines: []string{"0", "0", "0"},
+		},
+		{
+			name:    "scoped free shipping without a covered line",
+			coupon:  coupon(database.CouponDiscountTypeFreeShipping, "0", func(c *database.Coupon) { c.SellerID = uuid.NullUUID{UUID: uuid.New(), Valid: true} }),
+			wantErr: "coupon does not apply to these items",
+		},
+		{
+			name:   "scoped free shipping with a free covered line",
+			coupon: coupon(database.CouponDiscountTypeFreeShipping, "0", nil),
+			lines: []Line{
+				{ProductID: lamp, SellerID: sellerA, UnitPrice: dec("20.00"), Quantity: 1},
+				{ProductID: mug, SellerID: sellerB, UnitPrice: dec("0"), Quantity: 1},
+			},
+			scope:        products(mug),
+			wantEligible: "0", wantAmount: "0", wantFree: true,
+			wantLines: []string{"0", "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartLines := tt.lines
+			if cartLines == nil {
+				cartLines = lines
+			}
+			d, err := apply(tt.coupon, tt.used, tt.scope, cartLines, now)
+			if tt.wantErr != "" {
+				var couponErr *CouponError
+				if !errors.As(err, &couponErr) || couponErr.Message != tt.wantErr {
+					t.Fatalf("apply() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+
+			if !d.EligibleSubtotal.Equal(dec(tt.wantEligible)) {
+				t.Errorf("EligibleSubtotal = %s, want %s", d.EligibleSubtotal, tt.wantEligible)
+			}
+			if !d.Amount.Equal(dec(tt.wantAmount)) {
+				t.Errorf("Amount = %s, want %s", d.Amount, tt.wantAmount)
+			}
+			if d.FreeShipping != tt.wantFree {
+				t.Errorf("FreeShipping = %v, want %v", d.FreeShipping, tt.wantFree)
+			}
+
+			if len(d.LineAmounts) != len(cartLines) {
+				t.Fatalf("%d line amounts for %d lines", len(d.LineAmounts), len(cartLines))
+			}
+			sum := decimal.NewFromInt(0)
+			for i, part := range d.LineAmounts {
+				sum = sum.Add(part)
+				if tt.wantLines != nil && !part.Equal(dec(tt.wantLines[i])) {
+					t.Errorf("LineAmounts[%d] = %s, want %s", i, part, tt.wantLines[i])
+				}
+			}
+			if !sum.Equal(d.Amount) {
+				t.Errorf("line amounts add up to %s, want %s", sum, d.Amount)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	line := func(price string, quantity int32) Line {
+		return Line{UnitPrice: dec(price), Quantity: quantity}
+	}
+
+	tests := []struct {
+		name     string
+		amount   string
+		lines    []Line
+		eligible []bool
+		want     []string
+	}{
+		{"in proportion", "30", []Line{line("10", 1), line("20", 1)}, []bool{true, true}, []string{"10", "20"}},
+		{"thirds", "1.00", []Line{line("1", 1), line("1", 1), line("1", 1)}, []bool{true, true, true}, []string{"0.33", "0.33", "0.34"}},
+		{"remainder skips an ineligible last line", "1.00", []Line{line("1", 1), line("1", 1), line("1", 1), line("5", 1)}, []bool{true, true, true, false}, []string{"0.33", "0.33", "0.34", "0"}},
+		{"a negative remainder when parts round up", "0.05", []Line{line("1", 1), line("1", 1)}, []bool{true, true}, []string{"0.03", "0.02"}},
+		{"nothing to split", "0", []Line{line("0", 1), line("4", 1)}, []bool{true, false}, []string{"0", "0"}},
+		{"no lines", "0", nil, nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eligibleSubtotal := decimal.NewFromInt(0)
+			for i, l := range tt.lines {
+				if tt.eligible[i] {
+					eligibleSubtotal = eligibleSubtotal.Add(l.Total())
+				}
+			}
+
+			got := allocate(dec(tt.amount), eligibleSubtotal, tt.lines, tt.eligible)
+			if len(got) != len(tt.want) {
+				t.Fatalf("allocate() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(dec(tt.want[i])) {
+					t.Errorf("allocate() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
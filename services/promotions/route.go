@@ -0,0 +1,39 @@
+package promotions
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up coupon management for sellers and admins and the coupon dry run
+// used by customers before checkout.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Group(func(pr chi.Router) {
+		pr.Use(utils.AuthMiddleware)
+
+		pr.Post("/apply", func(w http.ResponseWriter, r *http.Request) {
+			handleApplyCoupon(w, r, q)
+		})
+
+		pr.Get("/coupons", func(w http.ResponseWriter, r *http.Request) {
+			handleListCoupons(w, r, q)
+		})
+
+		pr.Post("/coupons", func(w http.ResponseWriter, r *http.Request) {
+			handleCreateCoupon(w, r, db)
+		})
+
+		pr.Post("/coupons/{couponID}/deactivate", func(w http.ResponseWriter, r *http.Request) {
+			handleDeactivateCoupon(w, r, q)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,591 @@
+package returns
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/inventory"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	"github.com/ARCoder181105/ecom/services/payments"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// handleCreateReturn opens a return for items of one of the customer's delivered orders.
+func handleCreateReturn(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var payload mytypes.CreateReturnPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+
+	reason := strings.TrimSpace(payload.Reason)
+	if reason == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("reason is required"))
+		return
+	}
+
+	if len(payload.Items) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("at least one item is required"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	// Locking the order keeps concurrent returns from claiming the same units
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), orderID)
+	if err == sql.ErrNoRows || (err == nil && order.UserID != userID) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("order not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if order.Status != database.OrderStatusDelivered {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("only delivered orders can be returned"))
+		return
+	}
+
+	ret, err := qtx.CreateReturnRequest(r.Context(), database.CreateReturnRequestParams{
+		OrderID: order.ID,
+		UserID:  userID,
+		Reason:  reason,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(payload.Items))
+	for _, item := range payload.Items {
+		orderItemID, err := uuid.Parse(item.OrderItemID)
+		if err != nil || seen[orderItemID] {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid order item: %s", item.OrderItemID))
+			return
+		}
+		seen[orderItemID] = true
+
+		orderItem, err := qtx.GetOrderItemByID(r.Context(), database.GetOrderItemByIDParams{
+			ID:      orderItemID,
+			OrderID: order.ID,
+		})
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("order item not found: %s", item.OrderItemID))
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		returned, err := qtx.SumReturnedQuantity(r.Context(), orderItem.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		available := int64(orderItem.Quantity) - returned
+		if item.Quantity <= 0 || int64(item.Quantity) > available {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("quantity for order item %s must be between 1 and %d", item.OrderItemID, available))
+			return
+		}
+
+		if err := validatePhotos(item.Photos); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+		photos, err := json.Marshal(append([]string{}, item.Photos...))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if _, err := qtx.CreateReturnItem(r.Context(), database.CreateReturnItemParams{
+			ReturnID:    ret.ID,
+			OrderItemID: orderItem.ID,
+			Quantity:    int32(item.Quantity),
+			Reason:      strings.TrimSpace(item.Reason),
+			Photos:      photos,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	items, err := qtx.ListReturnItems(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, NewReturnResponse(ret, items))
+}
+
+// handleListReturns lists the customer's own returns. Sellers see returns of their
+// products and admins see all of them.
+func handleListReturns(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var rets []database.ReturnRequest
+	switch claims.Role {
+	case "admin":
+		rets, err = q.ListReturnRequests(r.Context())
+	case "seller":
+		rets, err = q.ListReturnRequestsBySeller(r.Context(), userID)
+	default:
+		rets, err = q.ListReturnRequestsByUser(r.Context(), userID)
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.ReturnResponse, 0, len(rets))
+	for _, ret := range rets {
+		items, err := q.ListReturnItems(r.Context(), ret.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		resp = append(resp, NewReturnResponse(ret, items))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+func handleGetReturn(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	returnID, err := uuid.Parse(chi.URLParam(r, "returnID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid return id"))
+		return
+	}
+
+	ret, err := q.GetReturnRequestByID(r.Context(), returnID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("return not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	allowed, err := canView(r.Context(), q, claims, ret)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !allowed {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("return not found"))
+		return
+	}
+
+	items, err := q.ListReturnItems(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, NewReturnResponse(ret, items))
+}
+
+// lockReturnForManager locks the return in the URL and checks the caller may manage it
+// and that it is in the expected status. It writes the error response itself and
+// returns false when the request can't go on.
+func lockReturnForManager(w http.ResponseWriter, r *http.Request, qtx *database.Queries, status database.ReturnStatus) (database.ReturnRequest, uuid.UUID, bool) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+	actorID, _ := uuid.Parse(claims.UserID)
+
+	returnID, err := uuid.Parse(chi.URLParam(r, "returnID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid return id"))
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+
+	ret, err := qtx.GetReturnRequestByIDForUpdate(r.Context(), returnID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("return not found"))
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+
+	allowed, err := canManage(r.Context(), qtx, claims, ret)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+	if !allowed {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("you cannot manage this return"))
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+
+	if ret.Status != status {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("return is %s, expected %s", ret.Status, status))
+		return database.ReturnRequest{}, uuid.Nil, false
+	}
+
+	return ret, actorID, true
+}
+
+// handleDecideReturn approves or rejects a requested return. Approved returns get a
+// return label for the parcel.
+func handleDecideReturn(w http.ResponseWriter, r *http.Request, db *sql.DB, approve bool) {
+	var payload mytypes.ReturnDecisionPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	ret, actorID, ok := lockReturnForManager(w, r, qtx, database.ReturnStatusRequested)
+	if !ok {
+		return
+	}
+
+	params := database.DecideReturnRequestParams{
+		ID:           ret.ID,
+		Status:       database.ReturnStatusRejected,
+		DecidedBy:    uuid.NullUUID{UUID: actorID, Valid: true},
+		DecisionNote: strings.TrimSpace(payload.Note),
+	}
+	title := "Return rejected"
+	body := fmt.Sprintf("Your return for order %s was rejected.", ret.OrderID)
+	if approve {
+		params.Status = database.ReturnStatusApproved
+		params.ReturnLabel = returnLabel(ret.ID)
+		title = "Return approved"
+		body = fmt.Sprintf("Your return for order %s was approved. Mark the parcel with %s.", ret.OrderID, params.ReturnLabel)
+	}
+	if params.DecisionNote != "" {
+		body += " " + params.DecisionNote
+	}
+
+	ret, err = qtx.DecideReturnRequest(r.Context(), params)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := notifications.Notify(r.Context(), qtx, ret.UserID, notifications.TypeReturnUpdate, title, body); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err := qtx.ListReturnItems(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, NewReturnResponse(ret, items))
+}
+
+// handleReceiveReturn records the inspection of a returned parcel. Every item is either
+// restocked, which puts it back into inventory, or written off.
+func handleReceiveReturn(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	var payload mytypes.ReceiveReturnPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	ret, actorID, ok := lockReturnForManager(w, r, qtx, database.ReturnStatusApproved)
+	if !ok {
+		return
+	}
+
+	items, err := qtx.ListReturnItems(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	inspections := make(map[string]mytypes.InspectReturnItemPayload, len(payload.Items))
+	for _, inspection := range payload.Items {
+		inspections[inspection.ReturnItemID] = inspection
+	}
+	if len(inspections) != len(items) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("every returned item must be inspected exactly once"))
+		return
+	}
+
+	for _, item := range items {
+		inspection, ok := inspections[item.ID.String()]
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("missing inspection for return item %s", item.ID))
+			return
+		}
+
+		resolution := database.ReturnResolution(inspection.Resolution)
+		if resolution != database.ReturnResolutionRestock && resolution != database.ReturnResolutionWriteOff {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("resolution must be restock or write_off"))
+			return
+		}
+
+		if _, err := qtx.InspectReturnItem(r.Context(), database.InspectReturnItemParams{
+			ID:             item.ID,
+			ReturnID:       ret.ID,
+			Resolution:     database.NullReturnResolution{ReturnResolution: resolution, Valid: true},
+			InspectionNote: strings.TrimSpace(inspection.Note),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Written off items left stock when they were sold and don't come back
+		if resolution == database.ReturnResolutionRestock {
+			if _, err := inventory.RecordMovement(r.Context(), qtx, inventory.Movement{
+				ProductID: item.ProductID,
+				Type:      database.InventoryMovementTypeReturn,
+				Quantity:  item.Quantity,
+				ActorID:   uuid.NullUUID{UUID: actorID, Valid: true},
+				OrderID:   uuid.NullUUID{UUID: ret.OrderID, Valid: true},
+				Reason:    fmt.Sprintf("return %s restocked", ret.ReturnLabel),
+			}); err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to restock %s", item.ProductName))
+				return
+			}
+		}
+	}
+
+	ret, err = qtx.MarkReturnReceived(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err = qtx.ListReturnItems(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, NewReturnResponse(ret, items))
+}
+
+// handleRefundReturn refunds a received return to the order's captured payment. The
+// amount defaults to what the customer paid for the returned units. Sellers can refund
+// less, for example for a damaged item; only admins can refund more, such as shipping.
+// The refund is queued and the return becomes refunded once the gateway has made it.
+func handleRefundReturn(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	var payload mytypes.RefundReturnPayload
+	if err := utils.ParseJson(r, &payload); err != nil && err != io.EOF {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	ret, _, ok := lockReturnForManager(w, r, qtx, database.ReturnStatusReceived)
+	if !ok {
+		return
+	}
+
+	pending, err := qtx.CountPendingRefundsByReturn(r.Context(), uuid.NullUUID{UUID: ret.ID, Valid: true})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if pending > 0 {
+		utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("a refund for this return is already in progress"))
+		return
+	}
+
+	order, err := qtx.GetOrderByIDForUpdate(r.Context(), ret.OrderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err := qtx.ListReturnItems(r.Context(), ret.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemsValue := RefundAmount(items, order.PricesIncludeTax)
+	amount := itemsValue
+	if payload.Amount != "" {
+		amount, err = decimal.NewFromString(payload.Amount)
+		if err != nil || amount.IsNegative() {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid amount"))
+			return
+		}
+		if amount.GreaterThan(itemsValue) && claims.Role != "admin" {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("amount must be at most %s, the value of the returned items", itemsValue.StringFixed(2)))
+			return
+		}
+	}
+
+	// The refund goes back to the payment the order was paid with
+	if amount.IsPositive() {
+		attempts, err := qtx.ListPaymentsByOrder(r.Context(), order.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var payment *database.Payment
+		for i := range attempts {
+			if attempts[i].Status == database.PaymentStatusCaptured {
+				payment = &attempts[i]
+				break
+			}
+		}
+		if payment == nil {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("order has no captured payment to refund"))
+			return
+		}
+
+		locked, err := qtx.GetPaymentByIDForUpdate(r.Context(), payment.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		queued, err := qtx.SumPendingRefundsByPayment(r.Context(), locked.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		remaining := locked.Amount.Sub(locked.RefundedAmount).Sub(queued)
+		if amount.GreaterThan(remaining) {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("amount must be at most %s", remaining.StringFixed(2)))
+			return
+		}
+
+		if _, err := payments.QueueRefund(r.Context(), qtx, payments.RefundRequest{
+			PaymentID:    locked.ID,
+			Amount:       decimal.NullDecimal{Decimal: amount, Valid: true},
+			SellerShares: SellerShares(items, order.PricesIncludeTax),
+			ReturnID:     uuid.NullUUID{UUID: ret.ID, Valid: true},
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusAccepted, NewReturnResponse(ret, items))
+		return
+	}
+
+	// Nothing to send to the gateway
+	ret, err = qtx.MarkReturnRefunded(r.Context(), database.MarkReturnRefundedParams{
+		ID:           ret.ID,
+		RefundAmount: amount,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, NewReturnResponse(ret, items))
+}
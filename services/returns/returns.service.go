@@ -0,0 +1,155 @@
+package returns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// maxPhotos is how many photos a customer can attach to one returned item.
+const maxPhotos = 5
+
+// returnLabel is a placeholder return shipping label until a carrier integration can
+// issue real ones. Customers write it on the parcel.
+func returnLabel(returnID uuid.UUID) string {
+	return "RMA-" + strings.ToUpper(strings.ReplaceAll(returnID.String(), "-", "")[:10])
+}
+
+// RefundAmount is what the customer paid for the returned units: the unit price less the
+// line's coupon discount, plus the line's tax when it was charged on top. Shipping is not
+// refunded.
+func RefundAmount(items []database.ListReturnItemsRow, pricesIncludeTax bool) decimal.Decimal {
+	total := decimal.NewFromInt(0)
+	for _, item := range items {
+		total = total.Add(itemValue(item, pricesIncludeTax))
+	}
+	return total.Round(2)
+}
+
+// SellerShares splits the value of the returned items by the seller who sold them, so
+// the refund is booked against the right sellers in the ledger.
+func SellerShares(items []database.ListReturnItemsRow, pricesIncludeTax bool) map[uuid.UUID]decimal.Decimal {
+	shares := make(map[uuid.UUID]decimal.Decimal)
+	for _, item := range items {
+		shares[item.SellerID] = shares[item.SellerID].Add(itemValue(item, pricesIncludeTax))
+	}
+	return shares
+}
+
+// itemValue is what the customer paid for the returned units of one order line.
+func itemValue(item database.ListReturnItemsRow, pricesIncludeTax bool) decimal.Decimal {
+	ordered := decimal.NewFromInt(int64(item.OrderedQuantity))
+	linePaid := item.Price.Mul(ordered).Sub(item.DiscountAmount)
+	if !pricesIncludeTax {
+		linePaid = linePaid.Add(item.TaxAmount)
+	}
+	return linePaid.Mul(decimal.NewFromInt(int64(item.Quantity))).Div(ordered)
+}
+
+// canManage reports whether the user may approve, receive and refund the return:
+// admins always, sellers when every returned item is one of their products.
+func canManage(ctx context.Context, q *database.Queries, claims *utils.Claims, ret database.ReturnRequest) (bool, error) {
+	switch claims.Role {
+	case "admin":
+		return true, nil
+	case "seller":
+		sellerID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			return false, nil
+		}
+		others, err := q.CountReturnItemsNotOwnedBy(ctx, database.CountReturnItemsNotOwnedByParams{
+			ReturnID: ret.ID,
+			UserID:   sellerID,
+		})
+		if err != nil {
+			return false, err
+		}
+		return others == 0, nil
+	}
+	return false, nil
+}
+
+// canView reports whether the user may see the return: its customer or anyone who
+// can manage it.
+func canView(ctx context.Context, q *database.Queries, claims *utils.Claims, ret database.ReturnRequest) (bool, error) {
+	if claims.UserID == ret.UserID.String() {
+		return true, nil
+	}
+	return canManage(ctx, q, claims, ret)
+}
+
+// validatePhotos checks the photo URLs attached to a returned item.
+func validatePhotos(photos []string) error {
+	if len(photos) > maxPhotos {
+		return fmt.Errorf("at most %d photos per item", maxPhotos)
+	}
+	for _, photo := range photos {
+		if !strings.HasPrefix(photo, "https://") && !strings.HasPrefix(photo, "http://") {
+			return fmt.Errorf("photos must be image URLs")
+		}
+	}
+	return nil
+}
+
+// NewReturnResponse builds the API view of a return and its items.
+func NewReturnResponse(ret database.ReturnRequest, items []database.ListReturnItemsRow) mytypes.ReturnResponse {
+	resp := mytypes.ReturnResponse{
+		ID:           ret.ID.String(),
+		OrderID:      ret.OrderID.String(),
+		Status:       string(ret.Status),
+		Reason:       ret.Reason,
+		ReturnLabel:  ret.ReturnLabel,
+		DecisionNote: ret.DecisionNote,
+		RefundAmount: ret.RefundAmount.String(),
+		Items:        make([]mytypes.ReturnItemResponse, 0, len(items)),
+		CreatedAt:    ret.CreatedAt,
+	}
+	if ret.DecidedAt.Valid {
+		resp.DecidedAt = &ret.DecidedAt.Time
+	}
+	if ret.ReceivedAt.Valid {
+		resp.ReceivedAt = &ret.ReceivedAt.Time
+	}
+	if ret.RefundedAt.Valid {
+		resp.RefundedAt = &ret.RefundedAt.Time
+	}
+
+	for _, item := range items {
+		resp.Items = append(resp.Items, mytypes.ReturnItemResponse{
+			ID:             item.ID.String(),
+			OrderItemID:    item.OrderItemID.String(),
+			ProductID:      item.ProductID.String(),
+			ProductName:    item.ProductName,
+			Quantity:       item.Quantity,
+			Reason:         item.Reason,
+			Photos:         item.Photos,
+			Resolution:     string(item.Resolution.ReturnResolution),
+			InspectionNote: item.InspectionNote,
+		})
+	}
+	return resp
+}
+
+// ListForOrder returns the order's returns with their items, for the order detail.
+func ListForOrder(ctx context.Context, q *database.Queries, orderID uuid.UUID) ([]mytypes.ReturnResponse, error) {
+	rets, err := q.ListReturnRequestsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]mytypes.ReturnResponse, 0, len(rets))
+	for _, ret := range rets {
+		items, err := q.ListReturnItems(ctx, ret.ID)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, NewReturnResponse(ret, items))
+	}
+	return resp, nil
+}
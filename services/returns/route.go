@@ -0,0 +1,49 @@
+package returns
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the returns (RMA) workflow: customers request returns of delivered
+// items, sellers or admins approve them, inspect the parcel and issue the refund.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Use(utils.AuthMiddleware)
+
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		handleCreateReturn(w, r, db)
+	})
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		handleListReturns(w, r, q)
+	})
+
+	r.Get("/{returnID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetReturn(w, r, q)
+	})
+
+	r.Post("/{returnID}/approve", func(w http.ResponseWriter, r *http.Request) {
+		handleDecideReturn(w, r, db, true)
+	})
+
+	r.Post("/{returnID}/reject", func(w http.ResponseWriter, r *http.Request) {
+		handleDecideReturn(w, r, db, false)
+	})
+
+	r.Post("/{returnID}/receive", func(w http.ResponseWriter, r *http.Request) {
+		handleReceiveReturn(w, r, db)
+	})
+
+	r.Post("/{returnID}/refund", func(w http.ResponseWriter, r *http.Request) {
+		handleRefundReturn(w, r, db)
+	})
+
+	return r
+}
@@ -0,0 +1,32 @@
+package sellers
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the public seller storefront and profile management endpoints.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	// public routes
+	r.Get("/{sellerID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetStorefront(w, r, q)
+	})
+
+	// protected routes
+	r.Group(func(seller chi.Router) {
+		seller.Use(utils.AuthMiddleware)
+
+		seller.Put("/me/profile", func(w http.ResponseWriter, r *http.Request) {
+			handleUpdateSellerProfile(w, r, q)
+		})
+	})
+
+	return r
+}
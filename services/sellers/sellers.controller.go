@@ -0,0 +1,153 @@
+package sellers
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func handleGetStorefront(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	sellerID, err := uuid.Parse(chi.URLParam(r, "sellerID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller id"))
+		return
+	}
+
+	seller, err := q.GetUserByID(r.Context(), sellerID)
+	if err == sql.ErrNoRows || (err == nil && seller.Role == database.UserRoleCustomer) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("seller not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Sellers who never filled in a profile still get a storefront under their username
+	profile := mytypes.SellerProfileResponse{
+		ID:           seller.ID.String(),
+		DisplayName:  seller.Username,
+		SellingSince: seller.CreatedAt,
+	}
+	sellerProfile, err := q.GetSellerProfile(r.Context(), sellerID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err == nil {
+		profile.DisplayName = sellerProfile.DisplayName
+		profile.Logo = sellerProfile.Logo.String
+		profile.Description = sellerProfile.Description
+		profile.Policies = sellerProfile.Policies
+	}
+
+	page := 1
+	limit := 10
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	products, err := q.ListSellerProducts(r.Context(), database.ListSellerProductsParams{
+		UserID: sellerID,
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list products"))
+		return
+	}
+
+	stats, err := q.GetSellerStats(r.Context(), sellerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to load seller stats"))
+		return
+	}
+
+	sellerStats := mytypes.SellerStatsResponse{
+		ProductCount:    stats.ProductCount,
+		RatingCount:     stats.RatingCount,
+		FulfilledOrders: stats.FulfilledOrders,
+	}
+	if stats.AverageRating.Valid {
+		// One decimal is all a storefront shows
+		rating := math.Round(stats.AverageRating.Float64*10) / 10
+		sellerStats.AverageRating = &rating
+	}
+
+	responseProducts := []mytypes.ProductResponse{}
+	for _, p := range products {
+		responseProducts = append(responseProducts, mytypes.NewProductResponse(p))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"seller":      profile,
+		"stats":       sellerStats,
+		"products":    responseProducts,
+		"page":        page,
+		"limit":       limit,
+		"total_items": stats.ProductCount,
+		"total_pages": (int(stats.ProductCount) + limit - 1) / limit,
+	})
+}
+
+func handleUpdateSellerProfile(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "seller" && claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("only sellers have a storefront"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	var payload mytypes.SellerProfilePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if payload.DisplayName == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("display_name is required"))
+		return
+	}
+
+	profile, err := q.UpsertSellerProfile(r.Context(), database.UpsertSellerProfileParams{
+		UserID:      userID,
+		DisplayName: payload.DisplayName,
+		Logo:        sql.NullString{String: payload.Logo, Valid: payload.Logo != ""},
+		Description: payload.Description,
+		Policies:    payload.Policies,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.SellerProfileResponse{
+		ID:           profile.UserID.String(),
+		DisplayName:  profile.DisplayName,
+		Logo:         profile.Logo.String,
+		Description:  profile.Description,
+		Policies:     profile.Policies,
+		SellingSince: profile.CreatedAt,
+	})
+}
@@ -0,0 +1,46 @@
+package shipping
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up shipping method management, rate quotes and carrier tracking updates.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+	webhookSecret := os.Getenv("SHIPMENT_WEBHOOK_SECRET")
+
+	// public routes
+	r.Get("/methods", func(w http.ResponseWriter, r *http.Request) {
+		handleListShippingMethods(w, r, q)
+	})
+
+	r.Post("/tracking/webhook", func(w http.ResponseWriter, r *http.Request) {
+		handleTrackingWebhook(w, r, db, webhookSecret)
+	})
+
+	// protected routes
+	r.Group(func(pr chi.Router) {
+		pr.Use(utils.AuthMiddleware)
+
+		pr.Post("/quote", func(w http.ResponseWriter, r *http.Request) {
+			handleQuote(w, r, q)
+		})
+
+		pr.Post("/methods", func(w http.ResponseWriter, r *http.Request) {
+			handleCreateShippingMethod(w, r, q)
+		})
+
+		pr.Post("/methods/{methodID}/deactivate", func(w http.ResponseWriter, r *http.Request) {
+			handleDeactivateShippingMethod(w, r, q)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,344 @@
+package shipping
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/fulfillment"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+var codePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+func toShippingMethodResponse(m database.ShippingMethod) mytypes.ShippingMethodResponse {
+	return mytypes.ShippingMethodResponse{
+		ID:            m.ID.String(),
+		Code:          m.Code,
+		Name:          m.Name,
+		Kind:          string(m.Kind),
+		BaseCost:      m.BaseCost.String(),
+		PerKgCost:     m.PerKgCost.String(),
+		FreeThreshold: m.FreeThreshold.String(),
+		Country:       m.Country.String,
+		Active:        m.Active,
+	}
+}
+
+// parseAmount reads an optional non-negative money amount, empty meaning zero.
+func parseAmount(s, field string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.NewFromInt(0), nil
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil || d.IsNegative() {
+		return decimal.Decimal{}, fmt.Errorf("invalid %s", field)
+	}
+	return d, nil
+}
+
+func handleListShippingMethods(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	methods, err := q.ListShippingMethods(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.ShippingMethodResponse, 0, len(methods))
+	for _, m := range methods {
+		if m.Active {
+			resp = append(resp, toShippingMethodResponse(m))
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+func handleCreateShippingMethod(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	var payload mytypes.ShippingMethodPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	code := strings.ToLower(strings.TrimSpace(payload.Code))
+	if !codePattern.MatchString(code) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("code may only contain lowercase letters, digits, dashes and underscores"))
+		return
+	}
+
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("name is required"))
+		return
+	}
+
+	kind := database.ShippingRateKind(payload.Kind)
+	if _, ok := Calculators[kind]; !ok {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("kind must be flat, weight_based or free_over_threshold"))
+		return
+	}
+
+	baseCost, err := parseAmount(payload.BaseCost, "base_cost")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	perKgCost, err := parseAmount(payload.PerKgCost, "per_kg_cost")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	freeThreshold, err := parseAmount(payload.FreeThreshold, "free_threshold")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(payload.Country))
+	if country != "" && len(country) != 2 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("country must be a two letter ISO code"))
+		return
+	}
+
+	method, err := q.CreateShippingMethod(r.Context(), database.CreateShippingMethodParams{
+		Code:          code,
+		Name:          name,
+		Kind:          kind,
+		BaseCost:      baseCost,
+		PerKgCost:     perKgCost,
+		FreeThreshold: freeThreshold,
+		Country:       sql.NullString{String: country, Valid: country != ""},
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("shipping method code already exists"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, toShippingMethodResponse(method))
+}
+
+func handleDeactivateShippingMethod(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	methodID, err := uuid.Parse(chi.URLParam(r, "methodID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid shipping method id"))
+		return
+	}
+
+	rows, err := q.DeactivateShippingMethod(r.Context(), methodID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("shipping method not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "shipping method deactivated"})
+}
+
+// handleQuote lists what each available method would charge to ship the items to one of
+// the customer's addresses.
+func handleQuote(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+
+	var payload mytypes.ShippingQuotePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	addressID, err := uuid.Parse(payload.AddressID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	address, err := q.GetAddressByID(r.Context(), database.GetAddressByIDParams{
+		ID:     addressID,
+		UserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("address not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	parcel := Parcel{Subtotal: decimal.NewFromInt(0)}
+	now := time.Now()
+	for _, item := range payload.Items {
+		productID, err := uuid.Parse(item.ProductID)
+		if err != nil || item.Quantity <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid item: %s", item.ProductID))
+			return
+		}
+
+		product, err := q.GetProductByID(r.Context(), productID)
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("product not found: %s", item.ProductID))
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		qty := int64(item.Quantity)
+		parcel.WeightGrams += int64(product.WeightGrams) * qty
+		parcel.Subtotal = parcel.Subtotal.Add(pricing.EffectivePrice(product, now).Mul(decimal.NewFromInt(qty)))
+	}
+
+	rates, err := Quote(r.Context(), q, address.Country, parcel)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.ShippingRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		resp = append(resp, mytypes.ShippingRateResponse{
+			Method: rate.Method.Code,
+			Name:   rate.Method.Name,
+			Cost:   rate.Cost.String(),
+		})
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleTrackingWebhook applies a tracking update pushed by a carrier or tracking
+// aggregator. Requests carry the hex HMAC-SHA256 of the body, keyed with
+// SHIPMENT_WEBHOOK_SECRET, in X-Tracking-Signature; without a secret the webhook is off.
+// Redelivered events are acknowledged and ignored.
+func handleTrackingWebhook(w http.ResponseWriter, r *http.Request, db *sql.DB, secret string) {
+	if secret == "" {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, fmt.Errorf("tracking webhook is not configured"))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Tracking-Signature"))) {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("invalid signature"))
+		return
+	}
+
+	var payload mytypes.TrackingWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	carrier, err := fulfillment.ParseCarrier(payload.Carrier)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	status, err := fulfillment.ParseShipmentStatus(payload.Status)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	occurredAt := time.Now()
+	if payload.OccurredAt != nil {
+		occurredAt = *payload.OccurredAt
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	shipment, err := qtx.GetShipmentByTracking(r.Context(), database.GetShipmentByTrackingParams{
+		Carrier:        carrier,
+		TrackingNumber: strings.TrimSpace(payload.TrackingNumber),
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("shipment not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, recorded, err := fulfillment.RecordEvent(r.Context(), qtx, shipment, status,
+		strings.TrimSpace(payload.Description), strings.TrimSpace(payload.Location), occurredAt, uuid.NullUUID{})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	message := "event processed"
+	if !recorded {
+		message = "event already processed"
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+}
@@ -0,0 +1,111 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// ShippingError is a shipping problem the customer can fix, such as picking a method
+// that doesn't ship to their country.
+type ShippingError struct {
+	Message string
+}
+
+func (e *ShippingError) Error() string {
+	return e.Message
+}
+
+// Parcel is what gets shipped: the total weight and the value of the goods after
+// discounts.
+type Parcel struct {
+	WeightGrams int64
+	Subtotal    decimal.Decimal
+}
+
+// RateCalculator prices a parcel for one kind of shipping method.
+type RateCalculator interface {
+	Cost(m database.ShippingMethod, p Parcel) decimal.Decimal
+}
+
+// FlatRate charges the base cost whatever is shipped.
+type FlatRate struct{}
+
+func (FlatRate) Cost(m database.ShippingMethod, p Parcel) decimal.Decimal {
+	return m.BaseCost
+}
+
+// WeightRate charges the base cost plus the per-kg cost for every started kilogram.
+type WeightRate struct{}
+
+func (WeightRate) Cost(m database.ShippingMethod, p Parcel) decimal.Decimal {
+	kgs := (p.WeightGrams + 999) / 1000
+	return m.BaseCost.Add(m.PerKgCost.Mul(decimal.NewFromInt(kgs)))
+}
+
+// FreeOverThreshold charges the base cost unless the order reaches the threshold.
+type FreeOverThreshold struct{}
+
+func (FreeOverThreshold) Cost(m database.ShippingMethod, p Parcel) decimal.Decimal {
+	if p.Subtotal.GreaterThanOrEqual(m.FreeThreshold) {
+		return decimal.NewFromInt(0)
+	}
+	return m.BaseCost
+}
+
+// Calculators maps each method kind to its pricing. New kinds are added here along with
+// a value of the shipping_rate_kind enum.
+var Calculators = map[database.ShippingRateKind]RateCalculator{
+	database.ShippingRateKindFlat:              FlatRate{},
+	database.ShippingRateKindWeightBased:       WeightRate{},
+	database.ShippingRateKindFreeOverThreshold: FreeOverThreshold{},
+}
+
+// Rate is the price of shipping a parcel with one method.
+type Rate struct {
+	Method database.ShippingMethod
+	Cost   decimal.Decimal
+}
+
+// Quote prices the parcel with every active method that ships to the country, cheapest
+// first.
+func Quote(ctx context.Context, q *database.Queries, country string, p Parcel) ([]Rate, error) {
+	methods, err := q.ListShippingMethodsForCountry(ctx, sql.NullString{String: country, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]Rate, 0, len(methods))
+	for _, m := range methods {
+		calc, ok := Calculators[m.Kind]
+		if !ok {
+			continue
+		}
+		rates = append(rates, Rate{Method: m, Cost: calc.Cost(m, p).Round(2)})
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].Cost.LessThan(rates[j].Cost)
+	})
+	return rates, nil
+}
+
+// Choose picks the rate for the method code, or the cheapest one when code is empty.
+func Choose(rates []Rate, code string) (Rate, error) {
+	if len(rates) == 0 {
+		return Rate{}, &ShippingError{Message: "no shipping methods available for this address"}
+	}
+	if code == "" {
+		return rates[0], nil
+	}
+	for _, rate := range rates {
+		if rate.Method.Code == code {
+			return rate, nil
+		}
+	}
+	return Rate{}, &ShippingError{Message: fmt.Sprintf("shipping method %s is not available for this address", code)}
+}
@@ -0,0 +1,87 @@
+package shipping
+
+import (
+	"errors"
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestCalculators(t *testing.T) {
+	method := func(kind database.ShippingRateKind) database.ShippingMethod {
+		return database.ShippingMethod{
+			Kind:          kind,
+			BaseCost:      dec("5"),
+			PerKgCost:     dec("2.50"),
+			FreeThreshold: dec("50"),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		kind   database.ShippingRateKind
+		parcel Parcel
+		want   string
+	}{
+		{"flat ignores weight", database.ShippingRateKindFlat, Parcel{WeightGrams: 12000, Subtotal: dec("10")}, "5"},
+		{"flat ignores subtotal", database.ShippingRateKindFlat, Parcel{Subtotal: dec("500")}, "5"},
+		{"weight rounds up to a started kg", database.ShippingRateKindWeightBased, Parcel{WeightGrams: 1}, "7.5"},
+		{"weight exact kgs", database.ShippingRateKindWeightBased, Parcel{WeightGrams: 2000}, "10"},
+		{"weight just over", database.ShippingRateKindWeightBased, Parcel{WeightGrams: 2001}, "12.5"},
+		{"weightless parcel pays the base", database.ShippingRateKindWeightBased, Parcel{}, "5"},
+		{"below threshold", database.ShippingRateKindFreeOverThreshold, Parcel{Subtotal: dec("49.99")}, "5"},
+		{"at threshold is free", database.ShippingRateKindFreeOverThreshold, Parcel{Subtotal: dec("50")}, "0"},
+		{"over threshold is free", database.ShippingRateKindFreeOverThreshold, Parcel{Subtotal: dec("80")}, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc, ok := Calculators[tt.kind]
+			if !ok {
+				t.Fatalf("no calculator for %s", tt.kind)
+			}
+			if got := calc.Cost(method(tt.kind), tt.parcel); !got.Equal(dec(tt.want)) {
+				t.Errorf("Cost() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculatorsCoverEveryKind(t *testing.T) {
+	for _, kind := range []database.ShippingRateKind{
+		database.ShippingRateKindFlat,
+		database.ShippingRateKindWeightBased,
+		database.ShippingRateKindFreeOverThreshold,
+	} {
+		if _, ok := Calculators[kind]; !ok {
+			t.Errorf("no calculator for %s", kind)
+		}
+	}
+}
+
+func TestChoose(t *testing.T) {
+	rates := []Rate{
+		{Method: database.ShippingMethod{Code: "standard"}, Cost: dec("5")},
+		{Method: database.ShippingMethod{Code: "express"}, Cost: dec("15")},
+	}
+
+	if got, err := Choose(rates, ""); err != nil || got.Method.Code != "standard" {
+		t.Errorf("Choose(\"\") = %s, %v, want the cheapest", got.Method.Code, err)
+	}
+	if got, err := Choose(rates, "express"); err != nil || got.Method.Code != "express" {
+		t.Errorf("Choose(express) = %s, %v", got.Method.Code, err)
+	}
+
+	var shippingErr *ShippingError
+	if _, err := Choose(rates, "drone"); !errors.As(err, &shippingErr) {
+		t.Errorf("Choose(drone) error = %v, want a ShippingError", err)
+	}
+	if _, err := Choose(nil, ""); !errors.As(err, &shippingErr) {
+		t.Errorf("Choose with no rates error = %v, want a ShippingError", err)
+	}
+}
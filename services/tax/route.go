@@ -0,0 +1,37 @@
+package tax
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes exposes the tax rules used at checkout. Anyone can read them, only admins can
+// change them.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	// public routes
+	r.Get("/rates", func(w http.ResponseWriter, r *http.Request) {
+		handleListTaxRates(w, r, q)
+	})
+
+	// admin routes
+	r.Group(func(admin chi.Router) {
+		admin.Use(utils.AuthMiddleware)
+
+		admin.Put("/rates", func(w http.ResponseWriter, r *http.Request) {
+			handleUpsertTaxRate(w, r, q)
+		})
+
+		admin.Delete("/rates/{rateID}", func(w http.ResponseWriter, r *http.Request) {
+			handleDeleteTaxRate(w, r, q)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,131 @@
+package tax
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+func toTaxRateResponse(t database.TaxRate) mytypes.TaxRateResponse {
+	return mytypes.TaxRateResponse{
+		ID:       t.ID.String(),
+		Country:  t.Country,
+		State:    t.State,
+		TaxClass: t.TaxClass,
+		Rate:     t.Rate.String(),
+		Name:     t.Name,
+	}
+}
+
+func handleListTaxRates(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	rates, err := q.ListTaxRates(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.TaxRateResponse, 0, len(rates))
+	for _, t := range rates {
+		resp = append(resp, toTaxRateResponse(t))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+func handleUpsertTaxRate(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	var payload mytypes.TaxRatePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	region := Region{Country: payload.Country, State: payload.State}.Normalize()
+	if !countryPattern.MatchString(region.Country) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("country must be a two letter ISO code"))
+		return
+	}
+
+	class, err := ParseClass(payload.TaxClass)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rate, err := decimal.NewFromString(payload.Rate)
+	if err != nil || rate.IsNegative() || rate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("rate must be a fraction between 0 and 1"))
+		return
+	}
+
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("name is required"))
+		return
+	}
+
+	taxRate, err := q.UpsertTaxRate(r.Context(), database.UpsertTaxRateParams{
+		Country:  region.Country,
+		State:    region.State,
+		TaxClass: class,
+		Rate:     rate,
+		Name:     name,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, toTaxRateResponse(taxRate))
+}
+
+func handleDeleteTaxRate(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	if claims.Role != "admin" {
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not admin"))
+		return
+	}
+
+	rateID, err := uuid.Parse(chi.URLParam(r, "rateID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid tax rate id"))
+		return
+	}
+
+	rows, err := q.DeleteTaxRate(r.Context(), rateID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rows == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("tax rate not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "tax rate deleted"})
+}
@@ -0,0 +1,171 @@
+package tax
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultClass is the tax class of products that don't set one.
+const DefaultClass = "standard"
+
+var classPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+// ParseClass validates a product tax class, defaulting an empty one to DefaultClass.
+func ParseClass(class string) (string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	if class == "" {
+		return DefaultClass, nil
+	}
+	if !classPattern.MatchString(class) {
+		return "", fmt.Errorf("tax_class may only contain lowercase letters, digits and underscores")
+	}
+	return class, nil
+}
+
+// Region is the jurisdiction an order is taxed in.
+type Region struct {
+	Country string // ISO 3166-1 alpha-2
+	State   string
+}
+
+// Normalize upper-cases the region so it matches the stored rules.
+func (r Region) Normalize() Region {
+	return Region{
+		Country: strings.ToUpper(strings.TrimSpace(r.Country)),
+		State:   strings.ToUpper(strings.TrimSpace(r.State)),
+	}
+}
+
+// Line is one taxable order line. Amount is the line total after discounts.
+type Line struct {
+	TaxClass string
+	Amount   decimal.Decimal
+}
+
+// LineTax is the tax on the Line at the same index.
+type LineTax struct {
+	Name   string
+	Rate   decimal.Decimal
+	Amount decimal.Decimal
+}
+
+// Breakdown sums the tax charged at one rate, as printed on an invoice.
+type Breakdown struct {
+	Name          string
+	Rate          decimal.Decimal
+	TaxableAmount decimal.Decimal
+	Amount        decimal.Decimal
+}
+
+// Result is the tax for a whole order. When PricesIncludeTax is set the tax is already
+// part of the line amounts; otherwise Total has to be added on top.
+type Result struct {
+	Lines            []LineTax
+	Breakdown        []Breakdown
+	Total            decimal.Decimal
+	PricesIncludeTax bool
+}
+
+// TaxCalculator works out the tax on an order's lines for a region.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, region Region, lines []Line) (Result, error)
+}
+
+// RateFinder looks up the most specific tax rule for a region and tax class. It is
+// satisfied by *database.Queries.
+type RateFinder interface {
+	FindTaxRate(ctx context.Context, arg database.FindTaxRateParams) (database.TaxRate, error)
+}
+
+// RulesCalculator applies the rates configured in the tax_rates table: the most specific
+// rule for the region and the product's tax class. Lines without a matching rule are not
+// taxed.
+type RulesCalculator struct {
+	Rates            RateFinder
+	PricesIncludeTax bool
+}
+
+// NewRulesCalculator returns the built-in calculator. Catalog prices are treated as
+// tax-inclusive when TAX_PRICES_INCLUSIVE is "true".
+func NewRulesCalculator(q *database.Queries) *RulesCalculator {
+	return &RulesCalculator{
+		Rates:            q,
+		PricesIncludeTax: os.Getenv("TAX_PRICES_INCLUSIVE") == "true",
+	}
+}
+
+func (c *RulesCalculator) Calculate(ctx context.Context, region Region, lines []Line) (Result, error) {
+	region = region.Normalize()
+	result := Result{
+		Lines:            make([]LineTax, len(lines)),
+		Total:            decimal.NewFromInt(0),
+		PricesIncludeTax: c.PricesIncludeTax,
+	}
+	if region.Country == "" {
+		return result, nil
+	}
+
+	rates := make(map[string]*database.TaxRate)
+	breakdown := make(map[string]int)
+	one := decimal.NewFromInt(1)
+
+	for i, line := range lines {
+		class := line.TaxClass
+		if class == "" {
+			class = DefaultClass
+		}
+
+		rule, seen := rates[class]
+		if !seen {
+			found, err := c.Rates.FindTaxRate(ctx, database.FindTaxRateParams{
+				Country:  region.Country,
+				TaxClass: class,
+				State:    region.State,
+			})
+			if err != nil && err != sql.ErrNoRows {
+				return Result{}, err
+			}
+			if err == nil {
+				rule = &found
+			}
+			rates[class] = rule
+		}
+		if rule == nil || rule.Rate.IsZero() {
+			continue
+		}
+
+		var amount decimal.Decimal
+		if c.PricesIncludeTax {
+			amount = line.Amount.Sub(line.Amount.Div(one.Add(rule.Rate))).Round(2)
+		} else {
+			amount = line.Amount.Mul(rule.Rate).Round(2)
+		}
+
+		result.Lines[i] = LineTax{Name: rule.Name, Rate: rule.Rate, Amount: amount}
+		result.Total = result.Total.Add(amount)
+
+		key := rule.Name + "|" + rule.Rate.String()
+		idx, ok := breakdown[key]
+		if !ok {
+			idx = len(result.Breakdown)
+			breakdown[key] = idx
+			result.Breakdown = append(result.Breakdown, Breakdown{
+				Name:          rule.Name,
+				Rate:          rule.Rate,
+				TaxableAmount: decimal.NewFromInt(0),
+				Amount:        decimal.NewFromInt(0),
+			})
+		}
+		result.Breakdown[idx].TaxableAmount = result.Breakdown[idx].TaxableAmount.Add(line.Amount)
+		result.Breakdown[idx].Amount = result.Breakdown[idx].Amount.Add(amount)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,188 @@
+package tax
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	_ "github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+// TestFindTaxRate runs the query against Postgres, on a temporary tax_rates table that
+// shadows the real one for the test's transaction. Set TEST_DATABASE_URL to run it.
+func TestFindTaxRate(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+CREATE TEMP TABLE tax_rates (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  country CHAR(2) NOT NULL,
+  state VARCHAR(50) NOT NULL DEFAULT '',
+  tax_class VARCHAR(50) NOT NULL DEFAULT 'standard',
+  rate DECIMAL(7, 4) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (country, state, tax_class)
+) ON COMMIT DROP`); err != nil {
+		t.Fatal(err)
+	}
+
+	q := database.New(tx)
+	for _, r := range []database.UpsertTaxRateParams{
+		{Country: "US", State: "", TaxClass: "standard", Rate: dec("0.05"), Name: "US tax"},
+		{Country: "US", State: "CA", TaxClass: "standard", Rate: dec("0.0725"), Name: "CA sales tax"},
+		{Country: "US", State: "CA", TaxClass: "food", Rate: dec("0"), Name: "CA food"},
+		{Country: "DE", State: "", TaxClass: "standard", Rate: dec("0.19"), Name: "MwSt"},
+		{Country: "DE", State: "", TaxClass: "reduced", Rate: dec("0.07"), Name: "MwSt reduced"},
+	} {
+		if _, err := q.UpsertTaxRate(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		params   database.FindTaxRateParams
+		wantName string // Empty when no rule should match
+	}{
+		{"state rule wins", database.FindTaxRateParams{Country: "US", State: "CA", TaxClass: "standard"}, "CA sales tax"},
+		{"country rule for other states", database.FindTaxRateParams{Country: "US", State: "NY", TaxClass: "standard"}, "US tax"},
+		{"country rule without a state", database.FindTaxRateParams{Country: "US", State: "", TaxClass: "standard"}, "US tax"},
+		{"state rule for its class only", database.FindTaxRateParams{Country: "US", State: "CA", TaxClass: "food"}, "CA food"},
+		{"class without a country rule", database.FindTaxRateParams{Country: "US", State: "NY", TaxClass: "food"}, ""},
+		{"class per country", database.FindTaxRateParams{Country: "DE", State: "BY", TaxClass: "reduced"}, "MwSt reduced"},
+		{"unknown country", database.FindTaxRateParams{Country: "FR", State: "", TaxClass: "standard"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := q.FindTaxRate(ctx, tt.params)
+			if tt.wantName == "" {
+				if err != sql.ErrNoRows {
+					t.Errorf("FindTaxRate() = %q, %v, want no rows", got.Name, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindTaxRate() error = %v", err)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("FindTaxRate() = %q, want %q", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+// fakeRates looks rules up like FindTaxRate: the state's rule, then the country's.
+type fakeRates map[[3]string]database.TaxRate
+
+func (f fakeRates) FindTaxRate(ctx context.Context, arg database.FindTaxRateParams) (database.TaxRate, error) {
+	if r, ok := f[[3]string{arg.Country, arg.State, arg.TaxClass}]; ok {
+		return r, nil
+	}
+	if r, ok := f[[3]string{arg.Country, "", arg.TaxClass}]; ok {
+		return r, nil
+	}
+	return database.TaxRate{}, sql.ErrNoRows
+}
+
+func TestRulesCalculator(t *testing.T) {
+	rates := fakeRates{
+		{"US", "", "standard"}:   {Name: "US tax", Rate: dec("0.05")},
+		{"US", "CA", "standard"}: {Name: "CA sales tax", Rate: dec("0.0725")},
+		{"US", "CA", "food"}:     {Name: "CA food", Rate: dec("0")},
+		{"DE", "", "standard"}:   {Name: "MwSt", Rate: dec("0.19")},
+		{"DE", "", "reduced"}:    {Name: "MwSt reduced", Rate: dec("0.07")},
+	}
+
+	tests := []struct {
+		name          string
+		region        Region
+		inclusive     bool
+		lines         []Line
+		wantLines     []string
+		wantTotal     string
+		wantBreakdown int
+	}{
+		{
+			name:          "state rate on top",
+			region:        Region{Country: "us", State: "ca"},
+			lines:         []Line{{TaxClass: "standard", Amount: dec("100")}, {Amount: dec("19.99")}},
+			wantLines:     []string{"7.25", "1.45"},
+			wantTotal:     "8.7",
+			wantBreakdown: 1,
+		},
+		{
+			name:          "zero rate and unknown class are not taxed",
+			region:        Region{Country: "US", State: "CA"},
+			lines:         []Line{{TaxClass: "food", Amount: dec("10")}, {TaxClass: "exempt", Amount: dec("10")}},
+			wantLines:     []string{"0", "0"},
+			wantTotal:     "0",
+			wantBreakdown: 0,
+		},
+		{
+			name:          "included in prices",
+			region:        Region{Country: "DE"},
+			inclusive:     true,
+			lines:         []Line{{TaxClass: "standard", Amount: dec("119")}, {TaxClass: "reduced", Amount: dec("10.70")}},
+			wantLines:     []string{"19", "0.7"},
+			wantTotal:     "19.7",
+			wantBreakdown: 2,
+		},
+		{
+			name:          "no country, no tax",
+			region:        Region{},
+			lines:         []Line{{TaxClass: "standard", Amount: dec("100")}},
+			wantLines:     []string{"0"},
+			wantTotal:     "0",
+			wantBreakdown: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := &RulesCalculator{Rates: rates, PricesIncludeTax: tt.inclusive}
+			res, err := calc.Calculate(context.Background(), tt.region, tt.lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.wantLines {
+				if !res.Lines[i].Amount.Equal(dec(want)) {
+					t.Errorf("line %d tax = %s, want %s", i, res.Lines[i].Amount, want)
+				}
+			}
+			if !res.Total.Equal(dec(tt.wantTotal)) {
+				t.Errorf("total = %s, want %s", res.Total, tt.wantTotal)
+			}
+			if len(res.Breakdown) != tt.wantBreakdown {
+				t.Errorf("breakdown has %d rates, want %d", len(res.Breakdown), tt.wantBreakdown)
+			}
+			if res.PricesIncludeTax != tt.inclusive {
+				t.Errorf("PricesIncludeTax = %v, want %v", res.PricesIncludeTax, tt.inclusive)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 
 	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/addresses"
+	"github.com/ARCoder181105/ecom/services/notifications"
 	"github.com/ARCoder181105/ecom/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -17,13 +19,24 @@ func Routes(db *sql.DB) chi.Router {
 
 	// Public routes
 	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
-		handleLogin(w, r, q)
+		handleLogin(w, r, db)
 	})
 
 	r.Post("/register", func(w http.ResponseWriter, r *http.Request) {
-		handleRegister(w, r, q)
+		handleRegister(w, r, db)
 	})
 
+	r.Post("/password/forgot", func(w http.ResponseWriter, r *http.Request) {
+		handleForgotPassword(w, r, db)
+	})
+
+	r.Post("/password/reset", func(w http.ResponseWriter, r *http.Request) {
+		handleResetPassword(w, r, db)
+	})
+
+	// Protects its own endpoints, since unsubscribe links work without logging in
+	r.Mount("/notifications", notifications.Routes(db))
+
 	// Protected routes
 	r.Group(func(pr chi.Router) {
 		pr.Use(utils.AuthMiddleware)
@@ -31,7 +44,9 @@ func Routes(db *sql.DB) chi.Router {
 		pr.Get("/profile", func(w http.ResponseWriter, r *http.Request) {
 			handleProfile(w, r, q)
 		})
-		
+
+		pr.Mount("/addresses", addresses.Routes(db))
+
 	})
 
 	return r
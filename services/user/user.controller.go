@@ -2,10 +2,20 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/cart"
+	"github.com/ARCoder181105/ecom/services/jobs"
+	"github.com/ARCoder181105/ecom/services/notifications"
 	mytypes "github.com/ARCoder181105/ecom/types"
 	"github.com/ARCoder181105/ecom/utils"
 	"github.com/google/uuid"
@@ -32,7 +42,9 @@ func setAccessTokenCookie(w http.ResponseWriter, userID, email string, role stri
 	return token, nil
 }
 
-func handleRegister(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+func handleRegister(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	q := database.New(db)
+
 	// Parse the request payload correctly using &payload
 	var payload mytypes.RegisterUserPayload
 	if err := utils.ParseJson(r, &payload); err != nil {
@@ -79,6 +91,11 @@ func handleRegister(w http.ResponseWriter, r *http.Request, q *database.Queries)
 		return
 	}
 
+	// Anything the user put in their cart before logging in carries over
+	if err := cart.MergeGuestCart(r.Context(), db, w, r, user.ID); err != nil {
+		log.Printf("failed to merge guest cart for user %s: %v", user.ID, err)
+	}
+
 	// Respond with the created user
 	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"token": token,
@@ -93,7 +110,9 @@ func handleRegister(w http.ResponseWriter, r *http.Request, q *database.Queries)
 	})
 }
 
-func handleLogin(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+func handleLogin(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	q := database.New(db)
+
 	var loginUserPayload mytypes.LoginUserPayload
 
 	if err := utils.ParseJson(r, &loginUserPayload); err != nil {
@@ -124,6 +143,11 @@ func handleLogin(w http.ResponseWriter, r *http.Request, q *database.Queries) {
 		return
 	}
 
+	// Anything the user put in their cart before logging in carries over
+	if err := cart.MergeGuestCart(r.Context(), db, w, r, user.ID); err != nil {
+		log.Printf("failed to merge guest cart for user %s: %v", user.ID, err)
+	}
+
 	// Respond with token and user info
 	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"token": token,
@@ -168,3 +192,160 @@ func handleProfile(w http.ResponseWriter, r *http.Request, q *database.Queries)
 		CreatedAt: user.CreatedAt,
 	})
 }
+
+// Password reset links are valid this long
+const passwordResetTTL = time.Hour
+
+// hashResetToken is what password_reset_tokens stores, so a leaked table can't be used
+// to reset anyone's password.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleForgotPassword emails a reset link. It answers the same whether or not the
+// email belongs to an account, so it can't be used to find out who has one.
+func handleForgotPassword(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	var payload mytypes.ForgotPasswordPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	email := strings.TrimSpace(payload.Email)
+	if email == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("email is required"))
+		return
+	}
+
+	response := map[string]string{
+		"message": "if an account exists for that email, a password reset link is on its way",
+	}
+
+	q := database.New(db)
+
+	user, err := q.GetUserByEmail(r.Context(), email)
+	if err == sql.ErrNoRows {
+		utils.RespondWithJSON(w, http.StatusOK, response)
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err := passwordResetJob.Enqueue(r.Context(), q, passwordResetPayload{UserID: user.ID}); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to send reset email"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// passwordResetPayload asks for a reset email. The token is made when the job runs and
+// only its hash is stored, so the link exists nowhere but in the email itself.
+type passwordResetPayload struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+var passwordResetJob = jobs.Type[passwordResetPayload]{Kind: "user.password_reset"}
+
+// RegisterJobs adds the password reset sender to the job pool.
+func RegisterJobs(pool *jobs.Pool, mailer notifications.Mailer) {
+	jobs.Handle(pool, passwordResetJob, func(ctx context.Context, db *sql.DB, p passwordResetPayload) error {
+		return sendPasswordReset(ctx, database.New(db), mailer, p.UserID)
+	})
+}
+
+// sendPasswordReset creates a reset token and emails its link. A retry after a failed
+// send makes a new token; the unused one just expires.
+func sendPasswordReset(ctx context.Context, q *database.Queries, mailer notifications.Mailer, userID uuid.UUID) error {
+	user, err := q.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("user %s no longer exists", userID))
+	}
+	if err != nil {
+		return err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := q.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	return notifications.Send(ctx, mailer, user, notifications.TemplatePasswordReset, notifications.PasswordResetData{
+		Name:     user.FirstName,
+		ResetURL: notifications.FrontendURL("/reset-password?token=" + token),
+	})
+}
+
+// handleResetPassword sets a new password with a token from the reset email. Every
+// outstanding token of the user stops working once one is used.
+func handleResetPassword(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	var payload mytypes.ResetPasswordPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Token == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("token is required"))
+		return
+	}
+	if len(payload.PassWord) < 8 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("password must be at least 8 characters"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.PassWord), 10)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	// Locking the token keeps two requests from using it at once
+	resetToken, err := qtx.GetPasswordResetTokenForUpdate(r.Context(), hashResetToken(payload.Token))
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:       resetToken.UserID,
+		Password: string(hashedPassword),
+	}); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update password"))
+		return
+	}
+	if err := qtx.UsePasswordResetTokens(r.Context(), resetToken.UserID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update password"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "password updated, log in with the new one",
+	})
+}
@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up webhook subscriptions for sellers and admins, with delivery logs and
+// manual redelivery.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Use(utils.AuthMiddleware)
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		handleListSubscriptions(w, r, q)
+	})
+
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		handleCreateSubscription(w, r, q)
+	})
+
+	r.Get("/{subscriptionID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetSubscription(w, r, q)
+	})
+
+	r.Put("/{subscriptionID}", func(w http.ResponseWriter, r *http.Request) {
+		handleUpdateSubscription(w, r, q)
+	})
+
+	r.Delete("/{subscriptionID}", func(w http.ResponseWriter, r *http.Request) {
+		handleDeleteSubscription(w, r, q)
+	})
+
+	r.Post("/{subscriptionID}/ping", func(w http.ResponseWriter, r *http.Request) {
+		handlePing(w, r, q)
+	})
+
+	r.Get("/{subscriptionID}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		handleListDeliveries(w, r, q)
+	})
+
+	r.Get("/deliveries/{deliveryID}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetDelivery(w, r, q)
+	})
+
+	r.Post("/deliveries/{deliveryID}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		handleRedeliver(w, r, q)
+	})
+
+	return r
+}
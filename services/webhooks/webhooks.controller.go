@@ -0,0 +1,402 @@
+package webhooks
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// canManage reports whether the caller may see and change a subscription: the seller it
+// belongs to, or an admin, who also owns the platform-wide ones.
+func canManage(claims *utils.Claims, sub database.WebhookSubscription) bool {
+	if claims.Role == "admin" {
+		return true
+	}
+	return claims.Role == "seller" && sub.SellerID.Valid && sub.SellerID.UUID.String() == claims.UserID
+}
+
+// loadSubscription fetches the subscription named in the URL if the caller may manage it.
+func loadSubscription(w http.ResponseWriter, r *http.Request, q *database.Queries) (database.WebhookSubscription, bool) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return database.WebhookSubscription{}, false
+	}
+
+	subID, err := uuid.Parse(chi.URLParam(r, "subscriptionID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid subscription id"))
+		return database.WebhookSubscription{}, false
+	}
+
+	sub, err := q.GetWebhookSubscription(r.Context(), subID)
+	if err == sql.ErrNoRows || (err == nil && !canManage(claims, sub)) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("subscription not found"))
+		return database.WebhookSubscription{}, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.WebhookSubscription{}, false
+	}
+	return sub, true
+}
+
+// loadDelivery fetches the delivery named in the URL if the caller may manage its
+// subscription.
+func loadDelivery(w http.ResponseWriter, r *http.Request, q *database.Queries) (database.WebhookDelivery, bool) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return database.WebhookDelivery{}, false
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid delivery id"))
+		return database.WebhookDelivery{}, false
+	}
+
+	delivery, err := q.GetWebhookDelivery(r.Context(), deliveryID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("delivery not found"))
+		return database.WebhookDelivery{}, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.WebhookDelivery{}, false
+	}
+
+	sub, err := q.GetWebhookSubscription(r.Context(), delivery.SubscriptionID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.WebhookDelivery{}, false
+	}
+	if !canManage(claims, sub) {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("delivery not found"))
+		return database.WebhookDelivery{}, false
+	}
+	return delivery, true
+}
+
+// handleListSubscriptions lists the seller's subscriptions. Admins get the platform-wide
+// ones, or a seller's with ?seller_id=.
+func handleListSubscriptions(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	var sellerID uuid.NullUUID
+	switch claims.Role {
+	case "admin":
+		if s := r.URL.Query().Get("seller_id"); s != "" {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller id"))
+				return
+			}
+			sellerID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	case "seller":
+		id, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+			return
+		}
+		sellerID = uuid.NullUUID{UUID: id, Valid: true}
+	default:
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not a seller"))
+		return
+	}
+
+	subs, err := q.ListWebhookSubscriptions(r.Context(), sellerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.WebhookSubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		resp = append(resp, mytypes.NewWebhookSubscriptionResponse(sub))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleCreateSubscription registers an endpoint. The signing secret is only shown in
+// this response.
+func handleCreateSubscription(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var payload mytypes.WebhookSubscriptionPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	var sellerID uuid.NullUUID
+	switch claims.Role {
+	case "admin":
+		if payload.SellerID != "" {
+			id, err := uuid.Parse(payload.SellerID)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid seller id"))
+				return
+			}
+			sellerID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	case "seller":
+		sellerID = uuid.NullUUID{UUID: userID, Valid: true}
+	default:
+		utils.RespondWithError(w, http.StatusForbidden, fmt.Errorf("user is not a seller"))
+		return
+	}
+
+	endpoint, err := ParseURL(r.Context(), payload.URL)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	events, err := ParseEvents(payload.Events)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sub, err := q.CreateWebhookSubscription(r.Context(), database.CreateWebhookSubscriptionParams{
+		SellerID:  sellerID,
+		Url:       endpoint,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := mytypes.NewWebhookSubscriptionResponse(sub)
+	resp.Secret = sub.Secret
+	utils.RespondWithJSON(w, http.StatusCreated, resp)
+}
+
+func handleGetSubscription(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	sub, ok := loadSubscription(w, r, q)
+	if !ok {
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewWebhookSubscriptionResponse(sub))
+}
+
+// handleUpdateSubscription changes the URL and events, and pauses or resumes deliveries
+// with active. Fields left empty keep their value.
+func handleUpdateSubscription(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	sub, ok := loadSubscription(w, r, q)
+	if !ok {
+		return
+	}
+
+	var payload mytypes.WebhookSubscriptionPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	params := database.UpdateWebhookSubscriptionParams{
+		ID:     sub.ID,
+		Url:    sub.Url,
+		Events: sub.Events,
+		Active: sub.Active,
+	}
+	var err error
+	if payload.URL != "" {
+		if params.Url, err = ParseURL(r.Context(), payload.URL); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if payload.Events != nil {
+		if params.Events, err = ParseEvents(payload.Events); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if payload.Active != nil {
+		params.Active = *payload.Active
+	}
+
+	sub, err = q.UpdateWebhookSubscription(r.Context(), params)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, mytypes.NewWebhookSubscriptionResponse(sub))
+}
+
+// handleDeleteSubscription removes the subscription with its delivery logs.
+func handleDeleteSubscription(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	sub, ok := loadSubscription(w, r, q)
+	if !ok {
+		return
+	}
+
+	if _, err := q.DeleteWebhookSubscription(r.Context(), sub.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "subscription deleted"})
+}
+
+// handlePing queues a ping event to check that the endpoint is reachable and verifies
+// signatures. The URL is checked again first, as its host may resolve elsewhere now.
+func handlePing(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	sub, ok := loadSubscription(w, r, q)
+	if !ok {
+		return
+	}
+	if _, err := ParseURL(r.Context(), sub.Url); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	delivery, err := Ping(r.Context(), q, sub)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, mytypes.NewWebhookDeliveryResponse(delivery))
+}
+
+// handleListDeliveries is the subscription's delivery log, newest first.
+func handleListDeliveries(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	sub, ok := loadSubscription(w, r, q)
+	if !ok {
+		return
+	}
+
+	page := 1
+	limit := 10
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	deliveries, err := q.ListWebhookDeliveries(r.Context(), database.ListWebhookDeliveriesParams{
+		SubscriptionID: sub.ID,
+		Limit:          int32(limit),
+		Offset:         int32((page - 1) * limit),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	total, err := q.CountWebhookDeliveries(r.Context(), sub.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := make([]mytypes.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, mytypes.NewWebhookDeliveryResponse(d))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries":  resp,
+		"page":        page,
+		"limit":       limit,
+		"total_items": total,
+		"total_pages": (int(total) + limit - 1) / limit,
+	})
+}
+
+// handleGetDelivery shows a delivery with its payload and every attempt made. Only admins
+// see what receivers answered: the endpoint is the seller's to choose, and the body
+// could come from anywhere it manages to reach.
+func handleGetDelivery(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	delivery, ok := loadDelivery(w, r, q)
+	if !ok {
+		return
+	}
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	attempts, err := q.ListWebhookAttempts(r.Context(), delivery.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := mytypes.NewWebhookDeliveryResponse(delivery)
+	resp.Payload = delivery.Payload
+	resp.AttemptLog = make([]mytypes.WebhookAttemptResponse, 0, len(attempts))
+	for _, a := range attempts {
+		attempt := mytypes.WebhookAttemptResponse{
+			Attempt:    a.Attempt,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMs: a.DurationMs,
+			CreatedAt:  a.CreatedAt,
+		}
+		if claims.Role == "admin" {
+			attempt.ResponseBody = a.ResponseBody
+		}
+		resp.AttemptLog = append(resp.AttemptLog, attempt)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleRedeliver sends the event again as a new delivery with a fresh set of retries.
+// The event id stays the same.
+func handleRedeliver(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	delivery, ok := loadDelivery(w, r, q)
+	if !ok {
+		return
+	}
+
+	redelivery, err := q.RedeliverWebhookDelivery(r.Context(), delivery.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, mytypes.NewWebhookDeliveryResponse(redelivery))
+}
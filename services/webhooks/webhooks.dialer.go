@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for endpoints on loopback, private, link-local and other
+// non-public addresses, so a subscription can't be used to reach the internal network.
+var ErrBlockedAddress = errors.New("url must point at a public address")
+
+const resolveTimeout = 5 * time.Second
+
+// sharedAddressSpace is the carrier-grade NAT range, not covered by netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// allowPrivateTargets reports whether endpoints may be local or private. Set
+// WEBHOOKS_ALLOW_PRIVATE=true while developing to deliver to a receiver on localhost.
+func allowPrivateTargets() bool {
+	return os.Getenv("WEBHOOKS_ALLOW_PRIVATE") == "true"
+}
+
+// blockedAddress reports whether ip is somewhere webhooks must not be sent.
+func blockedAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsValid() ||
+		ip.IsUnspecified() ||
+		ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		(ip.Is4() && ip.As4()[0] == 0) ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// checkHost rejects a host that is, or resolves to, a blocked address. The dispatcher
+// checks again when it connects, as the name may resolve differently by then.
+func checkHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if blockedAddress(ip) {
+			return ErrBlockedAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("url host %q could not be resolved", host)
+	}
+	for _, ip := range ips {
+		if blockedAddress(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+// guardedDialer connects only to public addresses, unless allowPrivate. The check runs
+// on the resolved address right before connecting, which covers redirects and names
+// that resolve to a public address at subscription time and a private one later.
+func guardedDialer(allowPrivate bool) *net.Dialer {
+	d := &net.Dialer{Timeout: sendTimeout}
+	if allowPrivate {
+		return d
+	}
+	d.Control = func(network, address string, _ syscall.RawConn) error {
+		addr, err := netip.ParseAddrPort(address)
+		if err != nil || blockedAddress(addr.Addr()) {
+			return ErrBlockedAddress
+		}
+		return nil
+	}
+	return d
+}
+
+// sendError is the attempt's error as kept in the delivery log. Sellers read the log, so
+// it says what went wrong without the addresses and ports a raw network error carries;
+// the full error is logged.
+func sendError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrBlockedAddress):
+		return ErrBlockedAddress.Error()
+	case errors.As(err, &dnsErr):
+		return "could not resolve the receiver's host"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "receiver did not respond in time"
+	default:
+		return "could not connect to the receiver"
+	}
+}
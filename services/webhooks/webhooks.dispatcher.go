@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/background"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	batchSize    = 20
+	sendTimeout  = 10 * time.Second
+	// lease must outlast a send, or another dispatcher could pick the delivery up again
+	leaseSeconds = 60
+	// responseLimit is how much of a receiver's response body is kept in the log
+	responseLimit = 1024
+)
+
+// Dispatcher sends queued deliveries and schedules retries. Several can run against the
+// same database; each claims its own deliveries.
+type Dispatcher struct {
+	q      *database.Queries
+	client *http.Client
+}
+
+// NewDispatcher's client connects to public addresses only (see guardedDialer) and
+// doesn't follow redirects: a 3xx answer is a failed attempt like any other non-2xx.
+func NewDispatcher(db *sql.DB) *Dispatcher {
+	return &Dispatcher{
+		q: database.New(db),
+		client: &http.Client{
+			Timeout: sendTimeout,
+			Transport: &http.Transport{
+				DialContext:         guardedDialer(allowPrivateTargets()).DialContext,
+				TLSHandshakeTimeout: sendTimeout,
+				MaxIdleConns:        batchSize,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	background.Poller{
+		Name:      "webhook dispatch",
+		Interval:  pollInterval,
+		BatchSize: batchSize,
+		Poll:      d.dispatch,
+	}.Run(ctx)
+}
+
+// dispatch claims one batch of due deliveries and sends them concurrently.
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.q.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseSeconds: leaseSeconds,
+		BatchSize:    batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery database.WebhookDelivery) {
+			defer wg.Done()
+			if err := d.deliver(ctx, delivery); err != nil {
+				log.Printf("⚠️  webhook delivery %s: %v", delivery.ID, err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt at a delivery, logs it and schedules what happens next.
+func (d *Dispatcher) deliver(ctx context.Context, delivery database.WebhookDelivery) error {
+	sub, err := d.q.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if !sub.Active {
+		return d.q.RecordWebhookDeliveryResult(ctx, database.RecordWebhookDeliveryResultParams{
+			ID:             delivery.ID,
+			Status:         database.WebhookDeliveryStatusFailed,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      "subscription is disabled",
+		})
+	}
+
+	attempt := delivery.Attempts + 1
+	statusCode, body, duration, sendErr := d.send(ctx, sub, delivery)
+
+	errMsg := ""
+	if sendErr != nil {
+		log.Printf("⚠️  webhook delivery %s: %v", delivery.ID, sendErr)
+		errMsg = sendError(sendErr)
+	} else if statusCode < 200 || statusCode > 299 {
+		errMsg = fmt.Sprintf("receiver responded with %d", statusCode)
+	}
+
+	if err := d.q.CreateWebhookAttempt(ctx, database.CreateWebhookAttemptParams{
+		DeliveryID:   delivery.ID,
+		Attempt:      attempt,
+		StatusCode:   int32(statusCode),
+		Error:        errMsg,
+		ResponseBody: body,
+		DurationMs:   int32(duration.Milliseconds()),
+	}); err != nil {
+		return err
+	}
+
+	status := database.WebhookDeliveryStatusSucceeded
+	next := time.Now()
+	if errMsg != "" {
+		status = database.WebhookDeliveryStatusPending
+		next = next.Add(retryBackoff.Delay(attempt))
+		if attempt >= MaxAttempts {
+			status = database.WebhookDeliveryStatusFailed
+		}
+	}
+
+	return d.q.RecordWebhookDeliveryResult(ctx, database.RecordWebhookDeliveryResultParams{
+		ID:             delivery.ID,
+		Status:         status,
+		Attempts:       attempt,
+		NextAttemptAt:  next,
+		LastStatusCode: int32(statusCode),
+		LastError:      errMsg,
+	})
+}
+
+// send posts the signed payload. A status code of 0 means no response was received.
+func (d *Dispatcher) send(ctx context.Context, sub database.WebhookSubscription, delivery database.WebhookDelivery) (int, string, time.Duration, error) {
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", time.Since(start), err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ecom-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set("X-Webhook-Signature", Sign(sub.Secret, start.Unix(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", time.Since(start), err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseLimit))
+	// Postgres text can't hold invalid UTF-8 or NUL bytes
+	body = bytes.ReplaceAll(bytes.ToValidUTF8(body, nil), []byte{0}, nil)
+	return resp.StatusCode, string(body), time.Since(start), nil
+}
@@ -0,0 +1,209 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/background"
+	"github.com/ARCoder181105/ecom/services/events"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/google/uuid"
+)
+
+// Event types subscriptions can ask for
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventProductUpdated     = "product.updated"
+	EventStockLow           = "stock.low"
+	EventStockOut           = "stock.out"
+)
+
+// EventPing is sent on request to check an endpoint; it can't be subscribed to.
+const EventPing = "ping"
+
+var EventTypes = []string{
+	EventOrderCreated,
+	EventOrderStatusChanged,
+	EventProductUpdated,
+	EventStockLow,
+	EventStockOut,
+}
+
+// Retry schedule: a failed delivery is retried after 30s, 1m, 2m, ... and given up
+// after MaxAttempts, about four hours after the event.
+const MaxAttempts = 10
+
+var retryBackoff = background.Backoff{Base: 30 * time.Second, Max: 6 * time.Hour}
+
+// Event is the JSON body of every delivery.
+type Event struct {
+	ID        uuid.UUID `json:"id"` // Shared by redeliveries, for receivers to drop duplicates
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// ParseEvents validates and de-duplicates the event types of a subscription.
+func ParseEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("events must list at least one of %s", strings.Join(EventTypes, ", "))
+	}
+	seen := make(map[string]bool, len(events))
+	parsed := make([]string, 0, len(events))
+	for _, e := range events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		known := false
+		for _, t := range EventTypes {
+			if e == t {
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event %q, must be one of %s", e, strings.Join(EventTypes, ", "))
+		}
+		if !seen[e] {
+			seen[e] = true
+			parsed = append(parsed, e)
+		}
+	}
+	return parsed, nil
+}
+
+// ParseURL validates an endpoint. Plain http is allowed so a local receiver can be used
+// while developing, but the host must be a public address unless WEBHOOKS_ALLOW_PRIVATE
+// is set.
+func ParseURL(ctx context.Context, s string) (string, error) {
+	s = strings.TrimSpace(s)
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if !allowPrivateTargets() {
+		if err := checkHost(ctx, u.Hostname()); err != nil {
+			return "", err
+		}
+	}
+	return s, nil
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-Webhook-Signature header for a body sent at timestamp: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription's secret. Receivers
+// recompute it and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func newEvent(eventType string, data any) (uuid.UUID, []byte, error) {
+	id := uuid.New()
+	payload, err := json.Marshal(Event{ID: id, Type: eventType, CreatedAt: time.Now().UTC(), Data: data})
+	return id, payload, err
+}
+
+// queue creates a delivery for every subscription in scope that wants the event; a
+// NULL scope means the platform-wide subscriptions.
+func queue(ctx context.Context, qtx *database.Queries, e events.Event, eventType string, scope uuid.NullUUID) error {
+	payload, err := json.Marshal(Event{ID: e.ID, Type: eventType, CreatedAt: e.OccurredAt.UTC(), Data: e.Payload})
+	if err != nil {
+		return err
+	}
+	_, err = qtx.QueueWebhookDeliveries(ctx, database.QueueWebhookDeliveriesParams{
+		EventID:   e.ID,
+		EventType: eventType,
+		Payload:   payload,
+		SellerID:  scope,
+	})
+	return err
+}
+
+// HandleEvent is the bus subscriber that turns domain events into deliveries. Order
+// events go to platform-wide subscriptions, seller order events to the seller's as
+// their own order events, and product and stock events to both. The webhook event id
+// is the domain event's, so a retried event is recognisable downstream.
+func HandleEvent(ctx context.Context, qtx *database.Queries, e events.Event) error {
+	platform := uuid.NullUUID{}
+
+	switch e.Type {
+	case events.OrderCreated:
+		return queue(ctx, qtx, e, EventOrderCreated, platform)
+	case events.OrderStatusChanged:
+		return queue(ctx, qtx, e, EventOrderStatusChanged, platform)
+
+	case events.SellerOrderCreated, events.SellerOrderStatusChanged:
+		sub, err := qtx.GetSellerOrder(ctx, e.AggregateID)
+		if err != nil {
+			return err
+		}
+		eventType := EventOrderCreated
+		if e.Type == events.SellerOrderStatusChanged {
+			eventType = EventOrderStatusChanged
+		}
+		return queue(ctx, qtx, e, eventType, uuid.NullUUID{UUID: sub.SellerID, Valid: true})
+
+	case events.ProductUpdated, events.StockLow, events.StockOut:
+		var data mytypes.ProductEventData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		sellerID, err := uuid.Parse(data.SellerID)
+		if err != nil {
+			return fmt.Errorf("product event without a seller: %w", err)
+		}
+		eventType := map[string]string{
+			events.ProductUpdated: EventProductUpdated,
+			events.StockLow:       EventStockLow,
+			events.StockOut:       EventStockOut,
+		}[e.Type]
+		if err := queue(ctx, qtx, e, eventType, platform); err != nil {
+			return err
+		}
+		return queue(ctx, qtx, e, eventType, uuid.NullUUID{UUID: sellerID, Valid: true})
+	}
+	return nil
+}
+
+// SubscribedEvents are the domain events HandleEvent reacts to.
+var SubscribedEvents = []string{
+	events.OrderCreated,
+	events.OrderStatusChanged,
+	events.SellerOrderCreated,
+	events.SellerOrderStatusChanged,
+	events.ProductUpdated,
+	events.StockLow,
+	events.StockOut,
+}
+
+// Ping queues a ping to one subscription, whatever events it asked for.
+func Ping(ctx context.Context, q *database.Queries, sub database.WebhookSubscription) (database.WebhookDelivery, error) {
+	id, payload, err := newEvent(EventPing, map[string]string{"subscription_id": sub.ID.String()})
+	if err != nil {
+		return database.WebhookDelivery{}, err
+	}
+	return q.QueueWebhookDelivery(ctx, database.QueueWebhookDeliveryParams{
+		SubscriptionID: sub.ID,
+		EventID:        id,
+		EventType:      EventPing,
+		Payload:        payload,
+	})
+}
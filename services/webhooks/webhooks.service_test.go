@@ -0,0 +1,109 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestBlockedAddress(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.10", true},
+		{"169.254.169.254", true}, // Cloud metadata
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"::", true},
+		{"100.64.0.1", true},
+		{"224.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"93.184.215.14", false},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"2606:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		if got := blockedAddress(netip.MustParseAddr(tt.ip)); got != tt.blocked {
+			t.Errorf("blockedAddress(%s) = %v, want %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"public address", "https://93.184.215.14/hooks", false, false},
+		{"trimmed", "  http://8.8.8.8:8080/x ", false, false},
+		{"not http", "ftp://8.8.8.8/", false, true},
+		{"relative", "/hooks", false, true},
+		{"no host", "http:///hooks", false, true},
+		{"loopback", "http://127.0.0.1:9000/", false, true},
+		{"ipv6 loopback", "http://[::1]:9000/", false, true},
+		{"metadata", "http://169.254.169.254/latest/meta-data/", false, true},
+		{"private", "https://10.0.0.5/", false, true},
+		{"mapped loopback", "http://[::ffff:127.0.0.1]/", false, true},
+		{"loopback allowed while developing", "http://127.0.0.1:9000/", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.allowPrivate {
+				t.Setenv("WEBHOOKS_ALLOW_PRIVATE", "true")
+			} else {
+				t.Setenv("WEBHOOKS_ALLOW_PRIVATE", "")
+			}
+			_, err := ParseURL(context.Background(), tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGuardedDialer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	_, err = guardedDialer(false).DialContext(context.Background(), "tcp", ln.Addr().String())
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("dial to %s: error = %v, want ErrBlockedAddress", ln.Addr(), err)
+	}
+	if got := sendError(err); got != ErrBlockedAddress.Error() {
+		t.Errorf("sendError() = %q, want %q", got, ErrBlockedAddress.Error())
+	}
+
+	conn, err := guardedDialer(true).DialContext(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial with private targets allowed: %v", err)
+	}
+	conn.Close()
+}
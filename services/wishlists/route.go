@@ -0,0 +1,59 @@
+package wishlists
+
+import (
+	"database/sql"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes sets up the wishlist endpoints. Shared lists are public, everything else
+// works on the logged in user's lists.
+func Routes(db *sql.DB) chi.Router {
+	r := chi.NewRouter()
+	q := database.New(db)
+
+	r.Get("/shared/{shareToken}", func(w http.ResponseWriter, r *http.Request) {
+		handleGetSharedWishlist(w, r, q)
+	})
+
+	r.Group(func(pr chi.Router) {
+		pr.Use(utils.AuthMiddleware)
+
+		pr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			handleListWishlists(w, r, q)
+		})
+
+		pr.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			handleCreateWishlist(w, r, q)
+		})
+
+		pr.Get("/{wishlistID}", func(w http.ResponseWriter, r *http.Request) {
+			handleGetWishlist(w, r, q)
+		})
+
+		pr.Put("/{wishlistID}", func(w http.ResponseWriter, r *http.Request) {
+			handleUpdateWishlist(w, r, q)
+		})
+
+		pr.Delete("/{wishlistID}", func(w http.ResponseWriter, r *http.Request) {
+			handleDeleteWishlist(w, r, q)
+		})
+
+		pr.Post("/{wishlistID}/items", func(w http.ResponseWriter, r *http.Request) {
+			handleAddWishlistItem(w, r, q)
+		})
+
+		pr.Delete("/{wishlistID}/items/{productID}", func(w http.ResponseWriter, r *http.Request) {
+			handleRemoveWishlistItem(w, r, q)
+		})
+
+		pr.Post("/{wishlistID}/items/{productID}/move-to-cart", func(w http.ResponseWriter, r *http.Request) {
+			handleMoveToCart(w, r, db)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,399 @@
+package wishlists
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/cart"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	mytypes "github.com/ARCoder181105/ecom/types"
+	"github.com/ARCoder181105/ecom/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// requestUser returns the logged in user, or responds 401.
+func requestUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, err := utils.GetClaims(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return uuid.Nil, false
+	}
+	userID, _ := uuid.Parse(claims.UserID)
+	return userID, true
+}
+
+// loadWishlist finds the URL's wishlist among the user's, or responds with an error.
+func loadWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries, userID uuid.UUID) (database.Wishlist, bool) {
+	wishlistID, err := uuid.Parse(chi.URLParam(r, "wishlistID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid wishlist id"))
+		return database.Wishlist{}, false
+	}
+
+	wishlist, err := q.GetWishlist(r.Context(), database.GetWishlistParams{
+		ID:     wishlistID,
+		UserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("wishlist not found"))
+		return database.Wishlist{}, false
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return database.Wishlist{}, false
+	}
+	return wishlist, true
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func respondWithWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries, wishlist database.Wishlist, status int) {
+	resp, err := buildWishlistResponse(r.Context(), q, wishlist)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to load wishlist"))
+		return
+	}
+	utils.RespondWithJSON(w, status, resp)
+}
+
+func handleListWishlists(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := q.ListWishlistsByUser(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to list wishlists"))
+		return
+	}
+
+	wishlists := make([]mytypes.WishlistResponse, 0, len(rows))
+	for _, row := range rows {
+		wishlists = append(wishlists, newWishlistResponse(database.Wishlist{
+			ID:         row.ID,
+			UserID:     row.UserID,
+			Name:       row.Name,
+			ShareToken: row.ShareToken,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+		}, int(row.ItemCount)))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+		"wishlists": wishlists,
+	})
+}
+
+func handleCreateWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+
+	var payload mytypes.WishlistPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+	name, err := parseName(payload.Name)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	shareToken, err := updateShareToken(sql.NullString{}, payload.Public)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	wishlist, err := q.CreateWishlist(r.Context(), database.CreateWishlistParams{
+		UserID:     userID,
+		Name:       name,
+		ShareToken: shareToken,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("you already have a wishlist with that name"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to create wishlist"))
+		return
+	}
+
+	respondWithWishlist(w, r, q, wishlist, http.StatusCreated)
+}
+
+func handleGetWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+	wishlist, ok := loadWishlist(w, r, q, userID)
+	if !ok {
+		return
+	}
+
+	respondWithWishlist(w, r, q, wishlist, http.StatusOK)
+}
+
+// handleUpdateWishlist renames a wishlist and makes it public or private. Making a
+// list private and public again gives it a new share link, so old links stop working.
+func handleUpdateWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+	wishlist, ok := loadWishlist(w, r, q, userID)
+	if !ok {
+		return
+	}
+
+	var payload mytypes.WishlistPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	name := wishlist.Name
+	if payload.Name != "" {
+		var err error
+		if name, err = parseName(payload.Name); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	shareToken, err := updateShareToken(wishlist.ShareToken, payload.Public)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	wishlist, err = q.UpdateWishlist(r.Context(), database.UpdateWishlistParams{
+		ID:         wishlist.ID,
+		UserID:     userID,
+		Name:       name,
+		ShareToken: shareToken,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			utils.RespondWithError(w, http.StatusConflict, fmt.Errorf("you already have a wishlist with that name"))
+			return
+		}
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to update wishlist"))
+		return
+	}
+
+	respondWithWishlist(w, r, q, wishlist, http.StatusOK)
+}
+
+func handleDeleteWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+	wishlistID, err := uuid.Parse(chi.URLParam(r, "wishlistID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid wishlist id"))
+		return
+	}
+
+	affected, err := q.DeleteWishlist(r.Context(), database.DeleteWishlistParams{
+		ID:     wishlistID,
+		UserID: userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete wishlist"))
+		return
+	}
+	if affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("wishlist not found"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "wishlist deleted",
+	})
+}
+
+func handleAddWishlistItem(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+	wishlist, ok := loadWishlist(w, r, q, userID)
+	if !ok {
+		return
+	}
+
+	var payload mytypes.WishlistItemPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+	productID, err := uuid.Parse(payload.ProductID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	product, err := q.GetProductByID(r.Context(), productID)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("product not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := q.AddWishlistItem(r.Context(), database.AddWishlistItemParams{
+		WishlistID: wishlist.ID,
+		ProductID:  product.ID,
+		AddedPrice: pricing.EffectivePrice(product, time.Now()),
+	}); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to add item"))
+		return
+	}
+
+	respondWithWishlist(w, r, q, wishlist, http.StatusOK)
+}
+
+func handleRemoveWishlistItem(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+	wishlist, ok := loadWishlist(w, r, q, userID)
+	if !ok {
+		return
+	}
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	affected, err := q.DeleteWishlistItem(r.Context(), database.DeleteWishlistItemParams{
+		WishlistID: wishlist.ID,
+		ProductID:  productID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to remove item"))
+		return
+	}
+	if affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("item not in wishlist"))
+		return
+	}
+
+	respondWithWishlist(w, r, q, wishlist, http.StatusOK)
+}
+
+// handleMoveToCart puts a wishlist item in the user's cart and takes it off the list.
+func handleMoveToCart(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	userID, ok := requestUser(w, r)
+	if !ok {
+		return
+	}
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid product id"))
+		return
+	}
+
+	payload := mytypes.MoveToCartPayload{Quantity: 1}
+	if r.ContentLength > 0 {
+		if err := utils.ParseJson(r, &payload); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+			return
+		}
+	}
+	if payload.Quantity <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("quantity must be positive"))
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to start transaction"))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(db).WithTx(tx)
+
+	wishlist, ok := loadWishlist(w, r, qtx, userID)
+	if !ok {
+		return
+	}
+
+	affected, err := qtx.DeleteWishlistItem(r.Context(), database.DeleteWishlistItemParams{
+		WishlistID: wishlist.ID,
+		ProductID:  productID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to remove item"))
+		return
+	}
+	if affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("item not in wishlist"))
+		return
+	}
+
+	product, err := qtx.GetProductByID(r.Context(), productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if int(product.StockQuantity) < payload.Quantity {
+		utils.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("only %d in stock", product.StockQuantity))
+		return
+	}
+
+	if err := cart.AddItem(r.Context(), qtx, userID, product, int32(payload.Quantity)); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to add item to cart"))
+		return
+	}
+
+	resp, err := buildWishlistResponse(r.Context(), qtx, wishlist)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("unable to load wishlist"))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, fmt.Errorf("failed to commit transaction"))
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// handleGetSharedWishlist shows a public wishlist to anyone with its link.
+func handleGetSharedWishlist(w http.ResponseWriter, r *http.Request, q *database.Queries) {
+	token := chi.URLParam(r, "shareToken")
+
+	wishlist, err := q.GetWishlistByShareToken(r.Context(), sql.NullString{String: token, Valid: token != ""})
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusNotFound, fmt.Errorf("wishlist not found"))
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondWithWishlist(w, r, q, wishlist, http.StatusOK)
+}
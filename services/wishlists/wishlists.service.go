@@ -0,0 +1,157 @@
+package wishlists
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/ARCoder181105/ecom/services/notifications"
+	"github.com/ARCoder181105/ecom/services/pricing"
+	mytypes "github.com/ARCoder181105/ecom/types"
+)
+
+const maxNameLength = 100
+
+// parseName validates a wishlist name.
+func parseName(s string) (string, error) {
+	name := strings.TrimSpace(s)
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	return name, nil
+}
+
+// newShareToken makes the secret part of a public list's link.
+func newShareToken() (sql.NullString, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: hex.EncodeToString(b), Valid: true}, nil
+}
+
+// updateShareToken is the list's share token after asking for it to be public or
+// private, or leaving it as is when public is nil. A list made public keeps the link it
+// already has, one made private loses it, so making it public again gives a new one.
+func updateShareToken(current sql.NullString, public *bool) (sql.NullString, error) {
+	switch {
+	case public == nil:
+		return current, nil
+	case !*public:
+		return sql.NullString{}, nil
+	case current.Valid:
+		return current, nil
+	}
+	return newShareToken()
+}
+
+func newWishlistResponse(w database.Wishlist, itemCount int) mytypes.WishlistResponse {
+	return mytypes.WishlistResponse{
+		ID:         w.ID.String(),
+		Name:       w.Name,
+		Public:     w.ShareToken.Valid,
+		ShareToken: w.ShareToken.String,
+		ItemCount:  itemCount,
+		CreatedAt:  w.CreatedAt,
+		UpdatedAt:  w.UpdatedAt,
+	}
+}
+
+// buildWishlistResponse lists the wishlist's items with their current price and stock.
+func buildWishlistResponse(ctx context.Context, q *database.Queries, w database.Wishlist) (mytypes.WishlistResponse, error) {
+	items, err := q.ListWishlistItems(ctx, w.ID)
+	if err != nil {
+		return mytypes.WishlistResponse{}, err
+	}
+
+	now := time.Now()
+	resp := newWishlistResponse(w, len(items))
+	resp.Items = make([]mytypes.WishlistItemResponse, 0, len(items))
+	for _, item := range items {
+		product, err := q.GetProductByID(ctx, item.ProductID)
+		if err != nil {
+			return mytypes.WishlistResponse{}, err
+		}
+
+		price := pricing.EffectivePrice(product, now)
+		resp.Items = append(resp.Items, mytypes.WishlistItemResponse{
+			ProductID:    product.ID.String(),
+			Slug:         product.Slug,
+			Name:         product.Name,
+			Image:        product.Image.String,
+			AddedPrice:   item.AddedPrice.String(),
+			Price:        price.String(),
+			PriceDropped: price.LessThan(item.AddedPrice),
+			InStock:      product.StockQuantity > 0,
+			AddedAt:      item.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// HandleEvent is the bus subscriber that tells users when a product on their
+// wishlists gets cheaper than it was at its last update: in-app and by email, once per
+// user however many of their lists have it.
+func HandleEvent(ctx context.Context, qtx *database.Queries, e events.Event) error {
+	if e.Type != events.ProductUpdated {
+		return nil
+	}
+
+	product, err := qtx.GetProductByID(ctx, e.AggregateID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	price := pricing.EffectivePrice(product, time.Now())
+
+	drops, err := qtx.ListWishlistPriceDrops(ctx, database.ListWishlistPriceDropsParams{
+		ProductID: product.ID,
+		LastPrice: price,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, drop := range drops {
+		if err := notifications.Notify(ctx, qtx, drop.UserID, notifications.TypePriceDrop, "Price drop",
+			fmt.Sprintf("%s from your wishlist %q dropped from %s to %s.", product.Name, drop.Name, drop.LastPrice.StringFixed(2), price.StringFixed(2))); err != nil {
+			return err
+		}
+
+		user, err := qtx.GetUserByID(ctx, drop.UserID)
+		if err != nil {
+			return err
+		}
+		if err := notifications.Email(ctx, qtx, user, notifications.TemplatePriceDrop, notifications.PriceDropData{
+			Name:       user.FirstName,
+			Product:    product.Name,
+			Wishlist:   drop.Name,
+			OldPrice:   drop.LastPrice.StringFixed(2),
+			NewPrice:   price.StringFixed(2),
+			ProductURL: notifications.FrontendURL("/products/" + product.Slug),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return qtx.UpdateWishlistItemPrices(ctx, database.UpdateWishlistItemPricesParams{
+		ProductID: product.ID,
+		LastPrice: price,
+	})
+}
+
+// SubscribedEvents are the domain events HandleEvent reacts to.
+var SubscribedEvents = []string{
+	events.ProductUpdated,
+}
@@ -0,0 +1,260 @@
+package wishlists
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/events"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"plain", "Birthday", "Birthday", ""},
+		{"trimmed", "  Birthday ideas \n", "Birthday ideas", ""},
+		{"empty", "", "", "name is required"},
+		{"only spaces", " \t ", "", "name is required"},
+		{"at the limit", strings.Repeat("a", maxNameLength), strings.Repeat("a", maxNameLength), ""},
+		{"limit counts the trimmed name", " " + strings.Repeat("a", maxNameLength) + " ", strings.Repeat("a", maxNameLength), ""},
+		{"too long", strings.Repeat("a", maxNameLength+1), "", "name must be at most 100 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseName(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseName() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseName() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateShareToken(t *testing.T) {
+	public, private := true, false
+	existing := sql.NullString{String: "0123456789abcdef0123456789abcdef", Valid: true}
+
+	tests := []struct {
+		name    string
+		current sql.NullString
+		public  *bool
+		want    sql.NullString // Ignored when wantNew is set
+		wantNew bool           // A freshly made token
+	}{
+		{name: "private list left as is", current: sql.NullString{}, public: nil, want: sql.NullString{}},
+		{name: "public list left as is", current: existing, public: nil, want: existing},
+		{name: "private list made public", current: sql.NullString{}, public: &public, wantNew: true},
+		{name: "public list made public again keeps its link", current: existing, public: &public, want: existing},
+		{name: "public list made private", current: existing, public: &private, want: sql.NullString{}},
+		{name: "private list made private", current: sql.NullString{}, public: &private, want: sql.NullString{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateShareToken(tt.current, tt.public)
+			if err != nil {
+				t.Fatalf("updateShareToken() error = %v", err)
+			}
+			if !tt.wantNew {
+				if got != tt.want {
+					t.Errorf("updateShareToken() = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+			if !got.Valid || got == existing {
+				t.Errorf("updateShareToken() = %+v, want a new token", got)
+			}
+		})
+	}
+
+	// Private and public again gives a link the old one can't reach
+	token, err := updateShareToken(existing, &private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err = updateShareToken(token, &public); err != nil {
+		t.Fatal(err)
+	}
+	if !token.Valid || token == existing {
+		t.Errorf("list made private and public again has token %+v, want a new one", token)
+	}
+}
+
+func TestNewShareToken(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		token, err := newShareToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := hex.DecodeString(token.String); !token.Valid || err != nil || len(b) != 16 {
+			t.Fatalf("newShareToken() = %+v, want 16 random bytes in hex", token)
+		}
+		if seen[token.String] {
+			t.Fatalf("newShareToken() gave %s twice", token.String)
+		}
+		seen[token.String] = true
+	}
+}
+
+// HandleEvent only looks at product updates; anything else returns before touching
+// the database.
+func TestHandleEventIgnoresOtherEvents(t *testing.T) {
+	for _, eventType := range []string{events.OrderCreated, events.StockLow, events.StockOut, "unknown"} {
+		if err := HandleEvent(context.Background(), nil, events.Event{Type: eventType, AggregateID: uuid.New()}); err != nil {
+			t.Errorf("HandleEvent(%s) error = %v", eventType, err)
+		}
+	}
+}
+
+// TestPriceDrops runs the queries behind HandleEvent's price-drop check against
+// Postgres, on temporary wishlist tables that shadow the real ones for the test's
+// transaction. Set TEST_DATABASE_URL to run it.
+func TestPriceDrops(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+CREATE TEMP TABLE wishlists (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  share_token VARCHAR(64) UNIQUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (user_id, name)
+) ON COMMIT DROP;
+
+CREATE TEMP TABLE wishlist_items (
+  wishlist_id UUID NOT NULL REFERENCES wishlists(id) ON DELETE CASCADE,
+  product_id UUID NOT NULL,
+  added_price DECIMAL(10, 2) NOT NULL,
+  last_price DECIMAL(10, 2) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  PRIMARY KEY (wishlist_id, product_id)
+) ON COMMIT DROP`); err != nil {
+		t.Fatal(err)
+	}
+
+	q := database.New(tx)
+	lamp, mug := uuid.New(), uuid.New()
+	ana, ben, cy := uuid.New(), uuid.New(), uuid.New()
+
+	add := func(user uuid.UUID, list string, product uuid.UUID, price string) {
+		t.Helper()
+		w, err := q.CreateWishlist(ctx, database.CreateWishlistParams{UserID: user, Name: list})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.AddWishlistItem(ctx, database.AddWishlistItemParams{WishlistID: w.ID, ProductID: product, AddedPrice: dec(price)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(ana, "Home", lamp, "20.00")
+	add(ana, "Gifts", lamp, "25.00")
+	add(ben, "Home", lamp, "18.00")
+	add(cy, "Home", lamp, "15.00")
+	add(cy, "Kitchen", mug, "30.00")
+
+	type drop struct {
+		user  uuid.UUID
+		list  string // Empty when the owner's lists tie on price
+		price string
+	}
+	// Each update runs after the ones before it, like HandleEvent on successive events
+	tests := []struct {
+		name    string
+		product uuid.UUID
+		price   string
+		want    []drop
+	}{
+		{
+			name:    "owners above the new price, once each with their highest price",
+			product: lamp, price: "18.00",
+			want: []drop{{ana, "Gifts", "25.00"}},
+		},
+		{name: "same price again is no drop", product: lamp, price: "18.00"},
+		{name: "price going up is no drop", product: lamp, price: "22.00"},
+		{
+			name:    "drops are measured from the last update, not when added",
+			product: lamp, price: "21.00",
+			want: []drop{{ana, "", "22.00"}, {ben, "Home", "22.00"}, {cy, "Home", "22.00"}},
+		},
+		{name: "another product keeps its own price", product: mug, price: "30.00"},
+		{
+			name:    "a drop on another product",
+			product: mug, price: "29.99",
+			want: []drop{{cy, "Kitchen", "30.00"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := dec(tt.price)
+			got, err := q.ListWishlistPriceDrops(ctx, database.ListWishlistPriceDropsParams{ProductID: tt.product, LastPrice: price})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := q.UpdateWishlistItemPrices(ctx, database.UpdateWishlistItemPricesParams{ProductID: tt.product, LastPrice: price}); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListWishlistPriceDrops() = %+v, want %d drops", got, len(tt.want))
+			}
+			for _, w := range tt.want {
+				found := false
+				for _, g := range got {
+					if g.UserID == w.user {
+						found = true
+						if (w.list != "" && g.Name != w.list) || !g.LastPrice.Equal(dec(w.price)) {
+							t.Errorf("drop for %s = %s at %s, want %s at %s", w.user, g.Name, g.LastPrice, w.list, w.price)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("no drop for %s", w.user)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package mytypes
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+	"github.com/ARCoder181105/ecom/services/pricing"
+)
 
 type UserResponse struct {
 	ID        string    `json:"id"`
@@ -24,23 +30,82 @@ type LoginUserPayload struct {
 	PassWord string `json:"password"`
 }
 
+type ForgotPasswordPayload struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token"`
+	PassWord string `json:"password"`
+}
+
 type ProductResponse struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	Image         string    `json:"image"`
-	Price         string    `json:"price"`
-	StockQuantity int       `json:"stock_quantity"`
-	CreatedAt     time.Time `json:"created_at"`
-	UserID        string    `json:"user_id"`
+	ID                string     `json:"id"`
+	Slug              string     `json:"slug"`
+	Name              string     `json:"name"`
+	Description       string     `json:"description"`
+	Image             string     `json:"image"`
+	Price             string     `json:"price"`         // What the customer pays right now
+	RegularPrice      string     `json:"regular_price"` // Price outside of sales
+	CompareAtPrice    string     `json:"compare_at_price,omitempty"`
+	OnSale            bool       `json:"on_sale"`
+	SaleEndsAt        *time.Time `json:"sale_ends_at,omitempty"`
+	StockQuantity     int        `json:"stock_quantity"`
+	LowStockThreshold int        `json:"low_stock_threshold"`
+	SoldOut           bool       `json:"sold_out"`
+	SKU               string     `json:"sku,omitempty"`
+	TaxClass          string     `json:"tax_class"`
+	WeightGrams       int        `json:"weight_grams"`
+	Category          string     `json:"category,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UserID            string     `json:"user_id"`
+}
+
+// NewProductResponse converts a products row into its public JSON shape, applying any
+// sale that is running right now.
+func NewProductResponse(p database.Product) ProductResponse {
+	now := time.Now()
+	resp := ProductResponse{
+		ID:                p.ID.String(),
+		Slug:              p.Slug,
+		Name:              p.Name,
+		Description:       p.Description,
+		Image:             p.Image.String,
+		Price:             pricing.EffectivePrice(p, now).String(),
+		RegularPrice:      p.Price.String(),
+		OnSale:            pricing.SaleActive(p, now),
+		StockQuantity:     int(p.StockQuantity),
+		LowStockThreshold: int(p.LowStockThreshold),
+		SoldOut:           p.StockQuantity == 0,
+		SKU:               p.Sku.String,
+		TaxClass:          p.TaxClass,
+		WeightGrams:       int(p.WeightGrams),
+		Category:          p.Category,
+		CreatedAt:         p.CreatedAt,
+		UserID:            p.UserID.String(),
+	}
+	if p.CompareAtPrice.Valid {
+		resp.CompareAtPrice = p.CompareAtPrice.Decimal.String()
+	} else if resp.OnSale {
+		resp.CompareAtPrice = p.Price.String()
+	}
+	if resp.OnSale && p.SaleEndsAt.Valid {
+		resp.SaleEndsAt = &p.SaleEndsAt.Time
+	}
+	return resp
 }
 
 type CreateProductPayload struct {
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	Image         string `json:"image"`
-	Price         string `json:"price"`
-	StockQuantity int    `json:"stock_quantity"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Image             string `json:"image"`
+	Price             string `json:"price"`
+	StockQuantity     int    `json:"stock_quantity"`
+	LowStockThreshold int    `json:"low_stock_threshold"` // 0 disables low-stock alerts
+	SKU               string `json:"sku"`
+	TaxClass          string `json:"tax_class"` // Defaults to standard
+	WeightGrams       int    `json:"weight_grams"`
+	Category          string `json:"category"` // Optional, e.g. electronics
 }
 
 type CreateOrderPayload struct {
@@ -50,5 +115,842 @@ type CreateOrderPayload struct {
 
 type AdminUpdateStatusPayload struct {
 	OrderID string `json:"order_id"`
-	Status  string    `json:"status"`
+	Status  string `json:"status"`
+	Note    string `json:"note"`
+}
+
+type StockMovementPayload struct {
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+	Type     string `json:"type"` // adjustment (default) or return, only used by /adjust
+}
+
+type InventoryMovementResponse struct {
+	ID           string    `json:"id"`
+	ProductID    string    `json:"product_id"`
+	Type         string    `json:"type"`
+	Quantity     int       `json:"quantity"`
+	BalanceAfter int       `json:"balance_after"`
+	ActorID      string    `json:"actor_id,omitempty"`
+	OrderID      string    `json:"order_id,omitempty"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type NotificationResponse struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationPreferenceResponse shows one category's channels; a channel the user
+// never changed is on.
+type NotificationPreferenceResponse struct {
+	Category string `json:"category"`
+	Email    bool   `json:"email"`
+	InApp    bool   `json:"in_app"`
+}
+
+// NotificationPreferencePayload changes one category; channels left out stay as they are.
+type NotificationPreferencePayload struct {
+	Category string `json:"category"`
+	Email    *bool  `json:"email"`
+	InApp    *bool  `json:"in_app"`
+}
+
+type UpdateNotificationPreferencesPayload struct {
+	Preferences []NotificationPreferencePayload `json:"preferences"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportJobResponse struct {
+	ID            string          `json:"id"`
+	Format        string          `json:"format"`
+	Status        string          `json:"status"`
+	TotalRows     int             `json:"total_rows"`
+	ProcessedRows int             `json:"processed_rows"`
+	CreatedCount  int             `json:"created_count"`
+	UpdatedCount  int             `json:"updated_count"`
+	ErrorCount    int             `json:"error_count"`
+	Errors        json.RawMessage `json:"errors"`
+	CreatedAt     time.Time       `json:"created_at"`
+	FinishedAt    *time.Time      `json:"finished_at"`
+}
+
+type UpdatePricingPayload struct {
+	CompareAtPrice string     `json:"compare_at_price"` // Empty clears it
+	SalePrice      string     `json:"sale_price"`       // Empty ends any sale
+	SaleStartsAt   *time.Time `json:"sale_starts_at"`
+	SaleEndsAt     *time.Time `json:"sale_ends_at"`
+}
+
+type PriceHistoryResponse struct {
+	Price          string     `json:"price"`
+	CompareAtPrice string     `json:"compare_at_price,omitempty"`
+	SalePrice      string     `json:"sale_price,omitempty"`
+	SaleStartsAt   *time.Time `json:"sale_starts_at,omitempty"`
+	SaleEndsAt     *time.Time `json:"sale_ends_at,omitempty"`
+	ChangedAt      time.Time  `json:"changed_at"`
+}
+
+type SellerProfilePayload struct {
+	DisplayName string `json:"display_name"`
+	Logo        string `json:"logo"`
+	Description string `json:"description"`
+	Policies    string `json:"policies"`
+}
+
+type SellerProfileResponse struct {
+	ID           string    `json:"id"`
+	DisplayName  string    `json:"display_name"`
+	Logo         string    `json:"logo"`
+	Description  string    `json:"description"`
+	Policies     string    `json:"policies"`
+	SellingSince time.Time `json:"selling_since"`
+}
+
+type SellerStatsResponse struct {
+	ProductCount    int64    `json:"product_count"`
+	AverageRating   *float64 `json:"average_rating"` // null until the seller has ratings
+	RatingCount     int64    `json:"rating_count"`
+	FulfilledOrders int64    `json:"fulfilled_orders"`
+}
+
+type SellerRatingPayload struct {
+	Rating  int    `json:"rating"` // 1 to 5
+	Comment string `json:"comment"`
+}
+
+type SellerRatingResponse struct {
+	SellerOrderID string    `json:"seller_order_id"`
+	SellerID      string    `json:"seller_id"`
+	Rating        int16     `json:"rating"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type UpdateCartItemPayload struct {
+	Quantity int `json:"quantity"` // 0 removes the item
+}
+
+type CartItemResponse struct {
+	ProductID         string `json:"product_id"`
+	Slug              string `json:"slug"`
+	Name              string `json:"name"`
+	Image             string `json:"image"`
+	Quantity          int    `json:"quantity"`
+	AddedPrice        string `json:"added_price"` // Price when the item was added
+	UnitPrice         string `json:"unit_price"`  // Current effective price, charged at checkout
+	PriceChanged      bool   `json:"price_changed"`
+	AvailableStock    int    `json:"available_stock"`
+	InsufficientStock bool   `json:"insufficient_stock"`
+	LineTotal         string `json:"line_total"`
+}
+
+type CartResponse struct {
+	ID        string             `json:"id,omitempty"`
+	Items     []CartItemResponse `json:"items"`
+	ItemCount int                `json:"item_count"`
+	Subtotal  string             `json:"subtotal"`
+	Valid     bool               `json:"valid"` // False when an item no longer has enough stock
+}
+
+type WishlistPayload struct {
+	Name   string `json:"name"`
+	Public *bool  `json:"public"` // Unchanged when left out of an update
+}
+
+type WishlistItemPayload struct {
+	ProductID string `json:"product_id"`
+}
+
+type MoveToCartPayload struct {
+	Quantity int `json:"quantity"` // 1 when left out
+}
+
+type WishlistItemResponse struct {
+	ProductID    string    `json:"product_id"`
+	Slug         string    `json:"slug"`
+	Name         string    `json:"name"`
+	Image        string    `json:"image,omitempty"`
+	AddedPrice   string    `json:"added_price"`
+	Price        string    `json:"price"`
+	PriceDropped bool      `json:"price_dropped"`
+	InStock      bool      `json:"in_stock"`
+	AddedAt      time.Time `json:"added_at"`
+}
+
+type WishlistResponse struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Public     bool                   `json:"public"`
+	ShareToken string                 `json:"share_token,omitempty"`
+	ItemCount  int                    `json:"item_count"`
+	Items      []WishlistItemResponse `json:"items,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+type CheckoutPayload struct {
+	CouponCode     string `json:"coupon_code"`
+	AddressID      string `json:"address_id"`      // Address book entry to ship to
+	ShippingMethod string `json:"shipping_method"` // Code, defaults to the cheapest
+	Country        string `json:"country"`         // Tax region when nothing is shipped
+	State          string `json:"state"`
+}
+
+type CreateCouponPayload struct {
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type"` // percentage, fixed or free_shipping
+	Amount         string     `json:"amount"`        // Percent off or fixed amount off
+	MinOrderValue  string     `json:"min_order_value"`
+	MaxUses        *int       `json:"max_uses"`          // Omit for unlimited
+	MaxUsesPerUser *int       `json:"max_uses_per_user"` // Omit for unlimited
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	SellerID       string     `json:"seller_id"`   // Admin only, sellers' coupons are always scoped to themselves
+	ProductIDs     []string   `json:"product_ids"` // Empty applies to every product in scope
+	Categories     []string   `json:"categories"`  // Product categories, together with product_ids
+}
+
+type CouponResponse struct {
+	ID             string     `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type"`
+	Amount         string     `json:"amount"`
+	MinOrderValue  string     `json:"min_order_value"`
+	MaxUses        *int       `json:"max_uses"`
+	MaxUsesPerUser *int       `json:"max_uses_per_user"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	SellerID       string     `json:"seller_id,omitempty"`
+	Categories     []string   `json:"categories,omitempty"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ApplyCouponPayload struct {
+	Code  string               `json:"code"`
+	Items []CreateOrderPayload `json:"items"`
+}
+
+type CouponQuoteResponse struct {
+	Code             string `json:"code"`
+	Description      string `json:"description"`
+	DiscountType     string `json:"discount_type"`
+	Subtotal         string `json:"subtotal"`
+	EligibleSubtotal string `json:"eligible_subtotal"`
+	Discount         string `json:"discount"`
+	FreeShipping     bool   `json:"free_shipping"`
+	Total            string `json:"total"`
+}
+
+type OrderDiscountResponse struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Amount      string `json:"amount"`
+}
+
+type TaxRatePayload struct {
+	Country  string `json:"country"`
+	State    string `json:"state"`     // Empty applies to the whole country
+	TaxClass string `json:"tax_class"` // Defaults to standard
+	Rate     string `json:"rate"`      // Fraction, "0.0825" is 8.25%
+	Name     string `json:"name"`
+}
+
+type TaxRateResponse struct {
+	ID       string `json:"id"`
+	Country  string `json:"country"`
+	State    string `json:"state"`
+	TaxClass string `json:"tax_class"`
+	Rate     string `json:"rate"`
+	Name     string `json:"name"`
+}
+
+type OrderTaxLineResponse struct {
+	Name          string `json:"name"`
+	Rate          string `json:"rate"`
+	TaxableAmount string `json:"taxable_amount"`
+	Amount        string `json:"amount"`
+}
+
+// PostalAddress is also the snapshot stored on orders at checkout.
+type PostalAddress struct {
+	FullName   string `json:"full_name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"` // ISO 3166-1 alpha-2
+	Phone      string `json:"phone"`
+}
+
+type AddressPayload struct {
+	Label string `json:"label"`
+	PostalAddress
+}
+
+type AddressResponse struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	PostalAddress
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ShippingMethodPayload struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"` // flat, weight_based or free_over_threshold
+	BaseCost      string `json:"base_cost"`
+	PerKgCost     string `json:"per_kg_cost"`
+	FreeThreshold string `json:"free_threshold"`
+	Country       string `json:"country"` // Empty ships everywhere
+}
+
+type ShippingMethodResponse struct {
+	ID            string `json:"id"`
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	BaseCost      string `json:"base_cost"`
+	PerKgCost     string `json:"per_kg_cost"`
+	FreeThreshold string `json:"free_threshold"`
+	Country       string `json:"country,omitempty"`
+	Active        bool   `json:"active"`
+}
+
+type ShippingQuotePayload struct {
+	AddressID string               `json:"address_id"`
+	Items     []CreateOrderPayload `json:"items"`
+}
+
+type ShippingRateResponse struct {
+	Method string `json:"method"`
+	Name   string `json:"name"`
+	Cost   string `json:"cost"`
+}
+
+// NewPostalAddress copies an address book entry into the shape snapshotted on orders.
+func NewPostalAddress(a database.Address) PostalAddress {
+	return PostalAddress{
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		State:      a.State,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	}
+}
+
+type CancelOrderPayload struct {
+	Reason string `json:"reason"`
+}
+
+type OrderStatusHistoryResponse struct {
+	SellerOrderID string    `json:"seller_order_id,omitempty"` // Set when a seller updated their sub-order
+	FromStatus    string    `json:"from_status,omitempty"`     // Empty for the initial status
+	ToStatus      string    `json:"to_status"`
+	ActorID       string    `json:"actor_id,omitempty"` // Empty for system changes
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CreditNoteResponse summarises a credit note; the document itself is downloaded
+// separately.
+type CreditNoteResponse struct {
+	ID        string    `json:"id"`
+	Number    string    `json:"number"`
+	PaymentID string    `json:"payment_id,omitempty"`
+	TaxTotal  string    `json:"tax_total"`
+	Total     string    `json:"total"`
+	IssuedAt  time.Time `json:"issued_at"`
+}
+
+type ConfirmPaymentPayload struct {
+	PaymentMethod string `json:"payment_method"`
+}
+
+type RefundPaymentPayload struct {
+	Amount string `json:"amount"` // Defaults to everything not yet refunded
+}
+
+type PaymentResponse struct {
+	ID             string    `json:"id"`
+	OrderID        string    `json:"order_id"`
+	Provider       string    `json:"provider"`
+	ProviderRef    string    `json:"provider_ref,omitempty"` // Admins only
+	Amount         string    `json:"amount"`
+	RefundedAmount string    `json:"refunded_amount"`
+	Status         string    `json:"status"`
+	FailureReason  string    `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func NewPaymentResponse(p database.Payment) PaymentResponse {
+	return PaymentResponse{
+		ID:             p.ID.String(),
+		OrderID:        p.OrderID.String(),
+		Provider:       p.Provider,
+		Amount:         p.Amount.String(),
+		RefundedAmount: p.RefundedAmount.String(),
+		Status:         string(p.Status),
+		FailureReason:  p.FailureReason,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+type ReturnItemPayload struct {
+	OrderItemID string   `json:"order_item_id"`
+	Quantity    int      `json:"quantity"`
+	Reason      string   `json:"reason"`
+	Photos      []string `json:"photos"` // Image URLs, e.g. from /product/upload
+}
+
+type CreateReturnPayload struct {
+	OrderID string              `json:"order_id"`
+	Reason  string              `json:"reason"`
+	Items   []ReturnItemPayload `json:"items"`
+}
+
+type ReturnDecisionPayload struct {
+	Note string `json:"note"`
+}
+
+type InspectReturnItemPayload struct {
+	ReturnItemID string `json:"return_item_id"`
+	Resolution   string `json:"resolution"` // restock or write_off
+	Note         string `json:"note"`
+}
+
+type ReceiveReturnPayload struct {
+	Items []InspectReturnItemPayload `json:"items"`
+}
+
+type RefundReturnPayload struct {
+	Amount string `json:"amount"` // Defaults to the value of the returned items, only admins can go above it
+}
+
+type ReturnItemResponse struct {
+	ID             string          `json:"id"`
+	OrderItemID    string          `json:"order_item_id"`
+	ProductID      string          `json:"product_id"`
+	ProductName    string          `json:"product_name"`
+	Quantity       int32           `json:"quantity"`
+	Reason         string          `json:"reason,omitempty"`
+	Photos         json.RawMessage `json:"photos"`
+	Resolution     string          `json:"resolution,omitempty"`
+	InspectionNote string          `json:"inspection_note,omitempty"`
+}
+
+type ReturnResponse struct {
+	ID           string               `json:"id"`
+	OrderID      string               `json:"order_id"`
+	Status       string               `json:"status"`
+	Reason       string               `json:"reason"`
+	ReturnLabel  string               `json:"return_label,omitempty"`
+	DecisionNote string               `json:"decision_note,omitempty"`
+	RefundAmount string               `json:"refund_amount"`
+	Items        []ReturnItemResponse `json:"items"`
+	CreatedAt    time.Time            `json:"created_at"`
+	DecidedAt    *time.Time           `json:"decided_at"`
+	ReceivedAt   *time.Time           `json:"received_at"`
+	RefundedAt   *time.Time           `json:"refunded_at"`
+}
+
+type SellerOrderItemResponse struct {
+	OrderItemID     string `json:"order_item_id"`
+	ProductID       string `json:"product_id"`
+	ProductName     string `json:"product_name"`
+	SKU             string `json:"sku,omitempty"`
+	Quantity        int32  `json:"quantity"`
+	Price           string `json:"price"`
+	TaxAmount       string `json:"tax_amount"`
+	DiscountAmount  string `json:"discount_amount"`
+	ShippedQuantity int32  `json:"shipped_quantity"`
+}
+
+type SellerOrderResponse struct {
+	ID              string                    `json:"id"`
+	OrderID         string                    `json:"order_id"`
+	SellerID        string                    `json:"seller_id,omitempty"`
+	Status          string                    `json:"status"`
+	Subtotal        string                    `json:"subtotal"`
+	DiscountTotal   string                    `json:"discount_total"`
+	TaxTotal        string                    `json:"tax_total"`
+	Total           string                    `json:"total"`
+	ShippingAddress json.RawMessage           `json:"shipping_address,omitempty"`
+	ShippingMethod  string                    `json:"shipping_method,omitempty"`
+	Items           []SellerOrderItemResponse `json:"items,omitempty"`
+	Shipments       []ShipmentResponse        `json:"shipments,omitempty"`
+	CreatedAt       time.Time                 `json:"created_at"`
+}
+
+func NewSellerOrderResponse(s database.SellerOrder) SellerOrderResponse {
+	return SellerOrderResponse{
+		ID:            s.ID.String(),
+		OrderID:       s.OrderID.String(),
+		SellerID:      s.SellerID.String(),
+		Status:        string(s.Status),
+		Subtotal:      s.Subtotal.String(),
+		DiscountTotal: s.DiscountTotal.String(),
+		TaxTotal:      s.TaxTotal.String(),
+		Total:         s.Total.String(),
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+func NewSellerOrderItemResponse(i database.ListSellerOrderItemsRow) SellerOrderItemResponse {
+	return SellerOrderItemResponse{
+		OrderItemID:     i.ID.String(),
+		ProductID:       i.ProductID.String(),
+		ProductName:     i.ProductName,
+		SKU:             i.ProductSku.String,
+		Quantity:        i.Quantity,
+		Price:           i.Price.String(),
+		TaxAmount:       i.TaxAmount.String(),
+		DiscountAmount:  i.DiscountAmount.String(),
+		ShippedQuantity: i.ShippedQuantity,
+	}
+}
+
+type UpdateSellerOrderStatusPayload struct {
+	Status string `json:"status"` // shipped or delivered
+	Note   string `json:"note"`
+}
+
+type ShipmentItemPayload struct {
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int32  `json:"quantity"`
+}
+
+type CreateShipmentPayload struct {
+	Carrier        string                `json:"carrier"`
+	TrackingNumber string                `json:"tracking_number"`
+	TrackingURL    string                `json:"tracking_url"` // Optional for carriers we know
+	Items          []ShipmentItemPayload `json:"items"`        // Empty ships everything not shipped yet
+}
+
+type ShipmentEventPayload struct {
+	Status      string     `json:"status"`
+	Description string     `json:"description"`
+	Location    string     `json:"location"`
+	OccurredAt  *time.Time `json:"occurred_at"` // Defaults to now
+}
+
+// TrackingWebhookPayload is a tracking update pushed by a carrier.
+type TrackingWebhookPayload struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	ShipmentEventPayload
+}
+
+type ShipmentItemResponse struct {
+	OrderItemID string `json:"order_item_id"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int32  `json:"quantity"`
+}
+
+type ShipmentEventResponse struct {
+	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+type ShipmentResponse struct {
+	ID             string                  `json:"id"`
+	OrderID        string                  `json:"order_id"`
+	SellerOrderID  string                  `json:"seller_order_id"`
+	Carrier        string                  `json:"carrier"`
+	TrackingNumber string                  `json:"tracking_number"`
+	TrackingURL    string                  `json:"tracking_url,omitempty"`
+	Status         string                  `json:"status"`
+	ShippedAt      time.Time               `json:"shipped_at"`
+	DeliveredAt    *time.Time              `json:"delivered_at,omitempty"`
+	Items          []ShipmentItemResponse  `json:"items"`
+	Events         []ShipmentEventResponse `json:"events"`
+}
+
+type CommissionRatePayload struct {
+	SellerID string `json:"seller_id"` // Empty applies to every seller
+	Category string `json:"category"`  // Empty applies to every category
+	Rate     string `json:"rate"`      // Fraction, "0.15" is 15%
+}
+
+type CommissionRateResponse struct {
+	ID        string    `json:"id"`
+	SellerID  string    `json:"seller_id,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LedgerEntryResponse struct {
+	ID            string    `json:"id"`
+	Kind          string    `json:"kind"`
+	Amount        string    `json:"amount"` // Positive adds to the seller's balance
+	Description   string    `json:"description"`
+	OrderID       string    `json:"order_id,omitempty"`
+	SellerOrderID string    `json:"seller_order_id,omitempty"`
+	PayoutID      string    `json:"payout_id,omitempty"` // Set once the entry has been paid out
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PayoutResponse struct {
+	ID         string    `json:"id"`
+	BatchID    string    `json:"batch_id"`
+	SellerID   string    `json:"seller_id"`
+	Sales      string    `json:"sales"`
+	Commission string    `json:"commission"`
+	Refunds    string    `json:"refunds"`
+	Amount     string    `json:"amount"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewPayoutResponse(p database.Payout) PayoutResponse {
+	return PayoutResponse{
+		ID:         p.ID.String(),
+		BatchID:    p.BatchID.String(),
+		SellerID:   p.SellerID.String(),
+		Sales:      p.Sales.String(),
+		Commission: p.Commission.String(),
+		Refunds:    p.Refunds.String(),
+		Amount:     p.Amount.String(),
+		CreatedAt:  p.CreatedAt,
+	}
+}
+
+type PayoutBatchResponse struct {
+	ID          string           `json:"id"`
+	SellerCount int32            `json:"seller_count"`
+	Total       string           `json:"total"`
+	Payouts     []PayoutResponse `json:"payouts,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+type RunPayoutsPayload struct {
+	MinAmount string `json:"min_amount"` // Sellers owed less are paid in a later batch
+}
+
+type WebhookSubscriptionPayload struct {
+	SellerID string   `json:"seller_id"` // Admins only; empty creates a platform-wide subscription
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`
+	Active   *bool    `json:"active"` // Updates only, defaults to unchanged
+}
+
+type WebhookSubscriptionResponse struct {
+	ID        string    `json:"id"`
+	SellerID  string    `json:"seller_id,omitempty"` // Empty for platform-wide subscriptions
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when the subscription is created
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewWebhookSubscriptionResponse(s database.WebhookSubscription) WebhookSubscriptionResponse {
+	resp := WebhookSubscriptionResponse{
+		ID:        s.ID.String(),
+		URL:       s.Url,
+		Events:    s.Events,
+		Active:    s.Active,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+	if s.SellerID.Valid {
+		resp.SellerID = s.SellerID.UUID.String()
+	}
+	return resp
+}
+
+type WebhookAttemptResponse struct {
+	Attempt      int32     `json:"attempt"`
+	StatusCode   int32     `json:"status_code"` // 0 when there was no response
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"` // Admins only
+	DurationMs   int32     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                   `json:"id"`
+	SubscriptionID string                   `json:"subscription_id"`
+	EventID        string                   `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Status         string                   `json:"status"`
+	Attempts       int32                    `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at,omitempty"` // Pending deliveries only
+	LastStatusCode int32                    `json:"last_status_code"`
+	LastError      string                   `json:"last_error,omitempty"`
+	Payload        json.RawMessage          `json:"payload,omitempty"`
+	AttemptLog     []WebhookAttemptResponse `json:"attempt_log,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+func NewWebhookDeliveryResponse(d database.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:             d.ID.String(),
+		SubscriptionID: d.SubscriptionID.String(),
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.Status == database.WebhookDeliveryStatusPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+// Webhook event data. order.created sends OrderEventData to platform-wide subscriptions
+// and a SellerOrderResponse with items to each seller; order.status_changed sends
+// OrderStatusEventData; product.updated, stock.low and stock.out send ProductEventData.
+
+type OrderEventData struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	Status        string    `json:"status"`
+	Subtotal      string    `json:"subtotal"`
+	DiscountTotal string    `json:"discount_total"`
+	ShippingCost  string    `json:"shipping_cost"`
+	TaxTotal      string    `json:"tax_total"`
+	Total         string    `json:"total"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func NewOrderEventData(o database.Order) OrderEventData {
+	return OrderEventData{
+		ID:            o.ID.String(),
+		UserID:        o.UserID.String(),
+		Status:        string(o.Status),
+		Subtotal:      o.Subtotal.String(),
+		DiscountTotal: o.DiscountTotal.String(),
+		ShippingCost:  o.ShippingCost.String(),
+		TaxTotal:      o.TaxTotal.String(),
+		Total:         o.TotalPrice.String(),
+		CreatedAt:     o.CreatedAt,
+	}
+}
+
+type OrderStatusEventData struct {
+	OrderID       string `json:"order_id"`
+	SellerOrderID string `json:"seller_order_id,omitempty"` // Set in events sent to sellers
+	FromStatus    string `json:"from_status"`
+	ToStatus      string `json:"to_status"`
+	Note          string `json:"note,omitempty"`
+}
+
+type ProductEventData struct {
+	ID                string `json:"id"`
+	SellerID          string `json:"seller_id"`
+	Name              string `json:"name"`
+	SKU               string `json:"sku,omitempty"`
+	Price             string `json:"price,omitempty"`
+	StockQuantity     int32  `json:"stock_quantity"`
+	LowStockThreshold int32  `json:"low_stock_threshold"`
+}
+
+func NewProductEventData(p database.Product) ProductEventData {
+	return ProductEventData{
+		ID:                p.ID.String(),
+		SellerID:          p.UserID.String(),
+		Name:              p.Name,
+		SKU:               p.Sku.String,
+		Price:             p.Price.String(),
+		StockQuantity:     p.StockQuantity,
+		LowStockThreshold: p.LowStockThreshold,
+	}
+}
+
+// OrderStreamEvent is what the order stream sends for an order.created,
+// order.status_changed, seller_order.created or seller_order.status_changed event.
+type OrderStreamEvent struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	OrderID       string    `json:"order_id"`
+	SellerOrderID string    `json:"seller_order_id,omitempty"` // Seller events only
+	Status        string    `json:"status"`
+	FromStatus    string    `json:"from_status,omitempty"` // Status changes only
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// ShipmentEventData is the shipment.created event's payload.
+type ShipmentEventData struct {
+	ID             string `json:"id"`
+	OrderID        string `json:"order_id"`
+	SellerOrderID  string `json:"seller_order_id"`
+	UserID         string `json:"user_id"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	TrackingURL    string `json:"tracking_url,omitempty"`
+}
+
+func NewShipmentEventData(s database.Shipment, o database.Order) ShipmentEventData {
+	return ShipmentEventData{
+		ID:             s.ID.String(),
+		OrderID:        s.OrderID.String(),
+		SellerOrderID:  s.SellerOrderID.String(),
+		UserID:         o.UserID.String(),
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		TrackingURL:    s.TrackingUrl,
+	}
+}
+
+type JobResponse struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"` // Running jobs only
+	LastError   string          `json:"last_error,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+func NewJobResponse(j database.Job) JobResponse {
+	resp := JobResponse{
+		ID:          j.ID.String(),
+		Kind:        j.Kind,
+		Status:      string(j.Status),
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		LastError:   j.LastError,
+		CreatedAt:   j.CreatedAt,
+	}
+	if j.Status == database.JobStatusRunning && j.LockedUntil.Valid {
+		resp.LockedUntil = &j.LockedUntil.Time
+	}
+	if j.FinishedAt.Valid {
+		resp.FinishedAt = &j.FinishedAt.Time
+	}
+	return resp
 }
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+)
+
+// IdempotencyKeyHeader carries a client-chosen key that makes retries of a mutating
+// request safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotentBodySize caps the body read into memory to fingerprint a request. It
+// matches the largest upload any endpoint takes, the 10 MB catalog imports and images.
+const maxIdempotentBodySize = 10 << 20
+
+// idempotencyScope says who a key belongs to, so two clients picking the same key never
+// see each other's responses. Requests from neither a user nor a guest cart are not
+// deduplicated.
+func idempotencyScope(r *http.Request) string {
+	if cookie, err := r.Cookie("accessToken"); err == nil && cookie.Value != "" {
+		if claims, err := ValidateJWT(cookie.Value); err == nil {
+			return "user:" + claims.UserID
+		}
+	}
+	// Guest carts, see the cart package
+	if cookie, err := r.Cookie("cartToken"); err == nil && cookie.Value != "" {
+		return "guest:" + cookie.Value
+	}
+	return ""
+}
+
+// idempotencyRecorder passes the response through while keeping a copy to store.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotencyStore is where claimed keys and their responses are kept, the
+// idempotency_keys table outside of tests.
+type idempotencyStore interface {
+	ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (int64, error)
+	GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error)
+	SaveIdempotencyResponse(ctx context.Context, arg database.SaveIdempotencyResponseParams) error
+	DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error
+}
+
+// IdempotencyMiddleware makes POST, PUT, PATCH and DELETE requests that carry an
+// Idempotency-Key header run at most once. The response to the first request is stored
+// and replayed for retries with the same key; reusing a key for a different request is
+// rejected. Server errors are not stored, so the request can be retried.
+func IdempotencyMiddleware(db *sql.DB) func(http.Handler) http.Handler {
+	return idempotencyMiddleware(database.New(db))
+}
+
+func idempotencyMiddleware(q idempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scope := idempotencyScope(r)
+			if scope == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > 255 {
+				RespondWithError(w, http.StatusBadRequest, fmt.Errorf("idempotency key is too long"))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body must be at most %d MB", maxIdempotentBodySize>>20))
+				return
+			}
+			if err != nil {
+				RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.RequestURI())
+			hash.Write(body)
+			fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+			claimed, err := q.ClaimIdempotencyKey(r.Context(), database.ClaimIdempotencyKeyParams{
+				Scope:          scope,
+				IdempotencyKey: key,
+				RequestHash:    fingerprint,
+			})
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			if claimed == 0 {
+				stored, err := q.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{
+					Scope:          scope,
+					IdempotencyKey: key,
+				})
+				if err != nil {
+					RespondWithError(w, http.StatusInternalServerError, err)
+					return
+				}
+				if stored.RequestHash != fingerprint {
+					RespondWithError(w, http.StatusUnprocessableEntity, fmt.Errorf("idempotency key was already used for a different request"))
+					return
+				}
+				if !stored.ResponseStatus.Valid {
+					RespondWithError(w, http.StatusConflict, fmt.Errorf("a request with this idempotency key is still being processed"))
+					return
+				}
+
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(int(stored.ResponseStatus.Int32))
+				w.Write(stored.ResponseBody)
+				return
+			}
+
+			rec := &idempotencyRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			// The client may have given up waiting, but the outcome still has to be
+			// recorded for its retry
+			ctx := context.WithoutCancel(r.Context())
+			if rec.status == 0 || rec.status >= 500 {
+				err = q.DeleteIdempotencyKey(ctx, database.DeleteIdempotencyKeyParams{
+					Scope:          scope,
+					IdempotencyKey: key,
+				})
+			} else {
+				err = q.SaveIdempotencyResponse(ctx, database.SaveIdempotencyResponseParams{
+					Scope:          scope,
+					IdempotencyKey: key,
+					ResponseStatus: sql.NullInt32{Int32: int32(rec.status), Valid: true},
+					ContentType:    rec.Header().Get("Content-Type"),
+					ResponseBody:   rec.body.Bytes(),
+				})
+			}
+			if err != nil {
+				log.Printf("failed to store idempotent response for key %s: %v", key, err)
+			}
+		})
+	}
+}
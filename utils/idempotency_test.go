@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	database "github.com/ARCoder181105/ecom/db/migrate/sqlc"
+)
+
+// memoryStore keeps idempotency keys like the idempotency_keys table does, including
+// when a taken key can be claimed again.
+type memoryStore struct {
+	mu   sync.Mutex
+	keys map[[2]string]database.IdempotencyKey
+	now  time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{keys: make(map[[2]string]database.IdempotencyKey), now: time.Now()}
+}
+
+func (m *memoryStore) ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := [2]string{arg.Scope, arg.IdempotencyKey}
+	if stored, ok := m.keys[id]; ok {
+		stale := stored.CreatedAt.Before(m.now.Add(-24*time.Hour)) ||
+			(!stored.ResponseStatus.Valid && stored.CreatedAt.Before(m.now.Add(-5*time.Minute)))
+		if !stale {
+			return 0, nil
+		}
+	}
+	m.keys[id] = database.IdempotencyKey{Scope: arg.Scope, IdempotencyKey: arg.IdempotencyKey, RequestHash: arg.RequestHash, CreatedAt: m.now}
+	return 1, nil
+}
+
+func (m *memoryStore) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.keys[[2]string{arg.Scope, arg.IdempotencyKey}]
+	if !ok {
+		return stored, sql.ErrNoRows
+	}
+	return stored, nil
+}
+
+func (m *memoryStore) SaveIdempotencyResponse(ctx context.Context, arg database.SaveIdempotencyResponseParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := [2]string{arg.Scope, arg.IdempotencyKey}
+	stored := m.keys[id]
+	stored.ResponseStatus = arg.ResponseStatus
+	stored.ContentType = arg.ContentType
+	stored.ResponseBody = arg.ResponseBody
+	m.keys[id] = stored
+	return nil
+}
+
+func (m *memoryStore) DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, [2]string{arg.Scope, arg.IdempotencyKey})
+	return nil
+}
+
+// countingHandler answers with the next status in statuses, or 201 once they run out,
+// and counts how often it ran.
+type countingHandler struct {
+	calls    int
+	statuses []int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	status := http.StatusCreated
+	if len(h.statuses) > 0 {
+		status, h.statuses = h.statuses[0], h.statuses[1:]
+	}
+	RespondWithJSON(w, status, map[string]any{"call": h.calls})
+}
+
+type testRequest struct {
+	method, target, body, key string
+	cookie                    *http.Cookie
+}
+
+func guest(token string) *http.Cookie {
+	return &http.Cookie{Name: "cartToken", Value: token}
+}
+
+func serve(h http.Handler, req testRequest) *httptest.ResponseRecorder {
+	method, target := req.method, req.target
+	if method == "" {
+		method = http.MethodPost
+	}
+	if target == "" {
+		target = "/api/v1/orders/placeOrder"
+	}
+	r := httptest.NewRequest(method, target, strings.NewReader(req.body))
+	if req.key != "" {
+		r.Header.Set(IdempotencyKeyHeader, req.key)
+	}
+	if req.cookie != nil {
+		r.AddCookie(req.cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	first := testRequest{body: `{"coupon":"SAVE"}`, key: "key-1", cookie: guest("cart-1")}
+
+	tests := []struct {
+		name      string
+		statuses  []int // Of the handler, 201 once they run out
+		requests  []testRequest
+		want      []int // Status of each response
+		wantCalls int
+		replayed  []bool // Whether each response is a replay
+	}{
+		{
+			name:      "retry is replayed",
+			requests:  []testRequest{first, first, first},
+			want:      []int{http.StatusCreated, http.StatusCreated, http.StatusCreated},
+			wantCalls: 1,
+			replayed:  []bool{false, true, true},
+		},
+		{
+			name:      "client errors are replayed too",
+			statuses:  []int{http.StatusBadRequest},
+			requests:  []testRequest{first, first},
+			want:      []int{http.StatusBadRequest, http.StatusBadRequest},
+			wantCalls: 1,
+			replayed:  []bool{false, true},
+		},
+		{
+			name:      "same key with another body",
+			requests:  []testRequest{first, {body: `{"coupon":"OTHER"}`, key: "key-1", cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls: 1,
+		},
+		{
+			name:      "same key on another endpoint",
+			requests:  []testRequest{first, {target: "/api/v1/cart/checkout", body: first.body, key: "key-1", cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls: 1,
+		},
+		{
+			name:      "same key with another method",
+			requests:  []testRequest{first, {method: http.MethodPut, body: first.body, key: "key-1", cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls: 1,
+		},
+		{
+			name:      "server error frees the key",
+			statuses:  []int{http.StatusInternalServerError, http.StatusBadGateway},
+			requests:  []testRequest{first, first, first, first},
+			want:      []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusCreated, http.StatusCreated},
+			wantCalls: 3,
+			replayed:  []bool{false, false, false, true},
+		},
+		{
+			name:      "keys are per client",
+			requests:  []testRequest{first, {body: first.body, key: "key-1", cookie: guest("cart-2")}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+			replayed:  []bool{false, false},
+		},
+		{
+			name:      "another key runs again",
+			requests:  []testRequest{first, {body: first.body, key: "key-2", cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:      "GET is not deduplicated",
+			requests:  []testRequest{{method: http.MethodGet, key: "key-1", cookie: guest("cart-1")}, {method: http.MethodGet, key: "key-1", cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+			replayed:  []bool{false, false},
+		},
+		{
+			name:      "requests without a user or guest cart are not deduplicated",
+			requests:  []testRequest{{body: first.body, key: "key-1"}, {body: first.body, key: "key-1"}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+			replayed:  []bool{false, false},
+		},
+		{
+			name:      "an invalid access token doesn't scope the request",
+			requests:  []testRequest{{body: first.body, key: "key-1", cookie: &http.Cookie{Name: "accessToken", Value: "forged"}}, {body: first.body, key: "key-1", cookie: &http.Cookie{Name: "accessToken", Value: "forged"}}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:      "requests without a key",
+			requests:  []testRequest{{body: first.body, cookie: guest("cart-1")}, {body: first.body, cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:      "key too long",
+			requests:  []testRequest{{body: first.body, key: strings.Repeat("k", 256), cookie: guest("cart-1")}},
+			want:      []int{http.StatusBadRequest},
+			wantCalls: 0,
+		},
+		{
+			name:      "body over the limit",
+			requests:  []testRequest{{body: strings.Repeat("x", maxIdempotentBodySize+1), key: "key-1", cookie: guest("cart-1")}},
+			want:      []int{http.StatusRequestEntityTooLarge},
+			wantCalls: 0,
+		},
+		{
+			name:      "body at the limit",
+			requests:  []testRequest{{body: strings.Repeat("x", maxIdempotentBodySize), key: "key-1", cookie: guest("cart-1")}},
+			want:      []int{http.StatusCreated},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &countingHandler{statuses: tt.statuses}
+			h := idempotencyMiddleware(newMemoryStore())(handler)
+
+			var firstBody []byte
+			for i, req := range tt.requests {
+				w := serve(h, req)
+				if w.Code != tt.want[i] {
+					t.Fatalf("request %d: status %d, want %d: %s", i+1, w.Code, tt.want[i], w.Body)
+				}
+				replayed := w.Header().Get("Idempotent-Replayed") == "true"
+				if tt.replayed != nil && replayed != tt.replayed[i] {
+					t.Errorf("request %d: replayed = %v, want %v", i+1, replayed, tt.replayed[i])
+				}
+				if replayed {
+					if !bytes.Equal(w.Body.Bytes(), firstBody) {
+						t.Errorf("request %d: replayed body %q, want %q", i+1, w.Body, firstBody)
+					}
+					if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+						t.Errorf("request %d: replayed Content-Type %q", i+1, ct)
+					}
+				} else if w.Code < 500 {
+					firstBody = w.Body.Bytes()
+				}
+			}
+			if handler.calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", handler.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareInProgress(t *testing.T) {
+	store := newMemoryStore()
+	handler := &countingHandler{}
+	h := idempotencyMiddleware(store)(handler)
+	req := testRequest{body: `{}`, key: "key-1", cookie: guest("cart-1")}
+
+	// The first request claimed the key but hasn't answered yet
+	var inFlight *httptest.ResponseRecorder
+	block := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = serve(h, req)
+		RespondWithJSON(w, http.StatusCreated, map[string]string{})
+	})
+	serve(idempotencyMiddleware(store)(block), req)
+
+	if inFlight.Code != http.StatusConflict {
+		t.Errorf("retry while the first request runs: status %d, want %d", inFlight.Code, http.StatusConflict)
+	}
+	if handler.calls != 0 {
+		t.Errorf("handler ran %d times while the key was taken", handler.calls)
+	}
+
+	// A request that died without answering frees the key after five minutes
+	stored := store.keys[[2]string{"guest:cart-1", "key-1"}]
+	stored.ResponseStatus = sql.NullInt32{}
+	store.keys[[2]string{"guest:cart-1", "key-1"}] = stored
+	store.now = store.now.Add(6 * time.Minute)
+	if w := serve(h, req); w.Code != http.StatusCreated || handler.calls != 1 {
+		t.Errorf("after the claim went stale: status %d with %d handler runs, want %d and 1", w.Code, handler.calls, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	token, err := GenerateJWT("user-1", "ada@example.com", "customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		want    string
+	}{
+		{"user", []*http.Cookie{{Name: "accessToken", Value: token}}, "user:user-1"},
+		{"user wins over the guest cart", []*http.Cookie{{Name: "accessToken", Value: token}, guest("cart-1")}, "user:user-1"},
+		{"guest cart", []*http.Cookie{guest("cart-1")}, "guest:cart-1"},
+		{"invalid token falls back to the guest cart", []*http.Cookie{{Name: "accessToken", Value: "forged"}, guest("cart-1")}, "guest:cart-1"},
+		{"empty guest cart", []*http.Cookie{guest("")}, ""},
+		{"nobody", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			for _, c := range tt.cookies {
+				r.AddCookie(c)
+			}
+			if got := idempotencyScope(r); got != tt.want {
+				t.Errorf("idempotencyScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -29,3 +29,26 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// OptionalAuthMiddleware attaches the JWT claims when a valid accessToken cookie is
+// present but lets anonymous requests through, for routes that also serve guests.
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		cookie, err := r.Cookie("accessToken")
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := ValidateJWT(cookie.Value)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ClaimsContextKey, claims)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}